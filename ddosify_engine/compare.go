@@ -0,0 +1,82 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"go.ddosify.com/ddosify/core/report"
+)
+
+const (
+	defaultLatencyThreshold = 10
+	defaultSuccessThreshold = 5
+)
+
+// runCompare compares two stdout-json reports, exits with 1 if the new report has a regression.
+func runCompare(args []string) {
+	fs := flag.NewFlagSet(cmdCompare, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: ddosify %s [flags] base.json new.json\n", cmdCompare)
+		fs.PrintDefaults()
+	}
+	latencyThreshold := fs.Float64("threshold", defaultLatencyThreshold,
+		"Latency increase in percent to count as a regression")
+	successThreshold := fs.Float64("success-threshold", defaultSuccessThreshold,
+		"Success rate decrease in percentage points to count as a regression")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		exitWithMsg("Please provide the base and the new stdout-json reports")
+	}
+	if *latencyThreshold < 0 || *successThreshold < 0 {
+		exitWithMsg("Thresholds should not be negative")
+	}
+
+	base := readReport(fs.Arg(0))
+	new := readReport(fs.Arg(1))
+
+	c := report.Compare(base, new, report.CompareOpts{
+		LatencyThreshold: *latencyThreshold,
+		SuccessThreshold: *successThreshold,
+	})
+	c.Print(os.Stdout)
+
+	if len(c.Regressions) > 0 {
+		os.Exit(1)
+	}
+}
+
+func readReport(path string) *report.Result {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		exitWithMsg(err.Error())
+	}
+	r, err := report.ParseResult(b)
+	if err != nil {
+		exitWithMsg(fmt.Sprintf("%s: %v", path, err))
+	}
+	return r
+}
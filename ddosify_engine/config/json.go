@@ -33,6 +33,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 	"unsafe"
 
 	"go.ddosify.com/ddosify/core/proxy"
@@ -50,10 +51,28 @@ type timeRunCount []struct {
 	Count    int `json:"count"`
 }
 
+type loadStages []struct {
+	Duration int    `json:"duration"`
+	LoadType string `json:"load_type"`
+	Rate     int    `json:"rate"`
+}
+
+type vuStages []struct {
+	Duration int `json:"duration"`
+	Target   int `json:"target"`
+}
+
 type auth struct {
-	Type     string `json:"type"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Type          string `json:"type"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	TokenURL      string `json:"token_url"`
+	ClientID      string `json:"client_id"`
+	ClientSecret  string `json:"client_secret"`
+	Scope         string `json:"scope"`
+	Grant         string `json:"grant"`
+	Cache         string `json:"cache"`
+	RefreshBefore int    `json:"refresh_before"`
 }
 
 type multipartFormData struct {
@@ -75,6 +94,44 @@ type capturePath struct {
 	From       string            `json:"from"` // body,header,cookie
 	CookieName *string           `json:"cookie_name"`
 	HeaderKey  *string           `json:"header_key"` // header key
+	Event      string            `json:"event"`      // event type for sse steps
+}
+
+type grpcConf struct {
+	Method      string   `json:"method"`
+	ProtoFile   string   `json:"proto_file"`
+	ImportPaths []string `json:"import_paths"`
+}
+
+type wsAction struct {
+	Type     string `json:"type"`
+	Payload  string `json:"payload"`
+	JsonPath string `json:"json_path"`
+	Equals   string `json:"equals"`
+	Regex    string `json:"regex"`
+	Timeout  int    `json:"timeout"`
+}
+
+type wsConf struct {
+	Actions []wsAction `json:"actions"`
+}
+
+type rawConf struct {
+	Format      string `json:"format"`
+	Delimiter   string `json:"delimiter"`
+	ReadBytes   int    `json:"read_bytes"`
+	ReadTimeout int    `json:"read_timeout"`
+}
+
+type graphqlConf struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+type sseConf struct {
+	Duration   int `json:"duration"`
+	EventCount int `json:"event_count"`
 }
 
 type step struct {
@@ -94,6 +151,11 @@ type step struct {
 	CertKeyPath      string                 `json:"cert_key_path"`
 	CaptureEnv       map[string]capturePath `json:"capture_env"`
 	Assertions       []string               `json:"assertion"`
+	Grpc             grpcConf               `json:"grpc"`
+	Ws               wsConf                 `json:"ws"`
+	Raw              rawConf                `json:"raw"`
+	Graphql          graphqlConf            `json:"graphql"`
+	Sse              sseConf                `json:"sse"`
 }
 
 func (s *step) UnmarshalJSON(data []byte) error {
@@ -147,21 +209,96 @@ func (c *CsvConf) UnmarshalJSON(data []byte) error {
 }
 
 type JsonReader struct {
-	ReqCount     *int                   `json:"request_count"`
-	IterCount    *int                   `json:"iteration_count"`
-	LoadType     string                 `json:"load_type"`
-	Duration     int                    `json:"duration"`
-	Assertions   []TestAssertion        `json:"success_criterias"`
-	TimeRunCount timeRunCount           `json:"manual_load"`
-	Steps        []step                 `json:"steps"`
-	Output       string                 `json:"output"`
-	Proxy        string                 `json:"proxy"`
-	Envs         map[string]interface{} `json:"env"`
-	Data         map[string]CsvConf     `json:"data"`
-	Debug        bool                   `json:"debug"`
-	SamplingRate *int                   `json:"sampling_rate"`
-	EngineMode   string                 `json:"engine_mode"`
-	Cookies      CookieConf             `json:"cookie_jar"`
+	ReqCount           *int                   `json:"request_count"`
+	IterCount          *int                   `json:"iteration_count"`
+	LoadType           string                 `json:"load_type"`
+	Duration           int                    `json:"duration"`
+	Assertions         []TestAssertion        `json:"success_criterias"`
+	TimeRunCount       timeRunCount           `json:"manual_load"`
+	LoadStages         loadStages             `json:"stages"`
+	Steps              []step                 `json:"steps"`
+	Output             string                 `json:"output"`
+	Proxy              string                 `json:"proxy"`
+	ProxyPool          *proxyPoolConf         `json:"proxy_pool"`
+	Envs               map[string]interface{} `json:"env"`
+	Data               map[string]CsvConf     `json:"data"`
+	Debug              bool                   `json:"debug"`
+	SamplingRate       *int                   `json:"sampling_rate"`
+	HistogramPrecision int                    `json:"histogram_precision"`
+	EngineMode         string                 `json:"engine_mode"`
+	Cookies            CookieConf             `json:"cookie_jar"`
+	Executor           string                 `json:"executor"`
+	Arrival            string                 `json:"arrival_distribution"`
+	MaxVUs             int                    `json:"max_vus"`
+	VUs                int                    `json:"vus"`
+	VUStages           vuStages               `json:"vu_stages"`
+	Prometheus         prometheusConf         `json:"prometheus"`
+	Influxdb           influxdbConf           `json:"influxdb"`
+	StdoutJson         stdoutJsonConf         `json:"stdout_json"`
+	Html               htmlConf               `json:"html"`
+	Junit              junitConf              `json:"junit"`
+	Export             exportConf             `json:"export"`
+	Tracing            tracingConf            `json:"tracing"`
+	SourceAddr         sourceAddrConf         `json:"source_address"`
+}
+
+type stdoutJsonConf struct {
+	BucketInterval int `json:"bucket_interval"`
+}
+
+type htmlConf struct {
+	Path string `json:"path"`
+}
+
+type junitConf struct {
+	Path string `json:"path"`
+}
+
+type exportConf struct {
+	Path        string `json:"path"`
+	Format      string `json:"format"`
+	CaptureBody bool   `json:"capture_body"`
+}
+
+type proxyPoolConf struct {
+	Rotation    string      `json:"rotation"`
+	File        string      `json:"file"`
+	Proxies     []poolProxy `json:"proxies"`
+	MaxFails    int         `json:"max_fails"`
+	FailTimeout int         `json:"fail_timeout"` // in seconds
+}
+
+type poolProxy struct {
+	Addr    string `json:"addr"`
+	Weight  int    `json:"weight"`
+	Country string `json:"country"`
+}
+
+type tracingConf struct {
+	Endpoint    string            `json:"endpoint"`
+	ServiceName string            `json:"service_name"`
+	Headers     map[string]string `json:"headers"`
+}
+
+type sourceAddrConf struct {
+	IPs       []string `json:"ips"`
+	Interface string   `json:"interface"`
+	Rotation  string   `json:"rotation"`
+}
+
+type influxdbConf struct {
+	URL           string `json:"url"`
+	Org           string `json:"org"`
+	Bucket        string `json:"bucket"`
+	Token         string `json:"token"`
+	Mode          string `json:"mode"`
+	FlushInterval int    `json:"flush_interval"`
+}
+
+type prometheusConf struct {
+	ListenAddr     string `json:"listen_addr"`
+	RemoteWriteURL string `json:"remote_write_url"`
+	PushInterval   int    `json:"push_interval"`
 }
 
 type CookieConf struct {
@@ -245,6 +382,16 @@ func (j *JsonReader) CreateHammer() (h types.Hammer, err error) {
 		Strategy: proxy.ProxyTypeSingle,
 		Addr:     proxyURL,
 	}
+	if j.ProxyPool != nil {
+		if proxyURL != nil {
+			err = fmt.Errorf("proxy and proxy_pool can not be used together")
+			return
+		}
+		p, err = j.ProxyPool.toProxy()
+		if err != nil {
+			return
+		}
+	}
 
 	// for backwards compatibility
 	var iterationCount int
@@ -266,6 +413,23 @@ func (j *JsonReader) CreateHammer() (h types.Hammer, err error) {
 		}
 	}
 
+	// LoadStages
+	if len(j.LoadStages) > 0 {
+		j.Duration = 0
+		for i, s := range j.LoadStages {
+			j.Duration += s.Duration
+			j.LoadStages[i].LoadType = strings.ToLower(s.LoadType)
+		}
+	}
+
+	// VUStages
+	if len(j.VUStages) > 0 {
+		j.Duration = 0
+		for _, s := range j.VUStages {
+			j.Duration += s.Duration
+		}
+	}
+
 	var samplingRate int
 	if j.SamplingRate != nil {
 		samplingRate = *j.SamplingRate
@@ -310,21 +474,66 @@ func (j *JsonReader) CreateHammer() (h types.Hammer, err error) {
 
 	// Hammer
 	h = types.Hammer{
-		IterationCount:    *j.IterCount,
-		LoadType:          strings.ToLower(j.LoadType),
-		TestDuration:      j.Duration,
-		TimeRunCountMap:   types.TimeRunCount(j.TimeRunCount),
-		Scenario:          s,
-		Proxy:             p,
-		ReportDestination: j.Output,
-		Debug:             j.Debug,
-		SamplingRate:      samplingRate,
-		EngineMode:        j.EngineMode,
-		TestDataConf:      testDataConf,
-		Cookies:           *(*[]types.CustomCookie)(unsafe.Pointer(&j.Cookies.Cookies)),
-		CookiesEnabled:    j.Cookies.Enabled,
-		Assertions:        testAssertions,
-		SingleMode:        types.DefaultSingleMode,
+		IterationCount:      *j.IterCount,
+		LoadType:            strings.ToLower(j.LoadType),
+		TestDuration:        j.Duration,
+		TimeRunCountMap:     types.TimeRunCount(j.TimeRunCount),
+		LoadStages:          types.LoadStages(j.LoadStages),
+		Scenario:            s,
+		Proxy:               p,
+		ReportDestination:   j.Output,
+		Debug:               j.Debug,
+		SamplingRate:        samplingRate,
+		HistogramPrecision:  j.HistogramPrecision,
+		EngineMode:          j.EngineMode,
+		TestDataConf:        testDataConf,
+		Cookies:             *(*[]types.CustomCookie)(unsafe.Pointer(&j.Cookies.Cookies)),
+		CookiesEnabled:      j.Cookies.Enabled,
+		Assertions:          testAssertions,
+		SingleMode:          types.DefaultSingleMode,
+		Executor:            strings.ToLower(j.Executor),
+		ArrivalDistribution: strings.ToLower(j.Arrival),
+		MaxVUs:              j.MaxVUs,
+		VUs:                 j.VUs,
+		VUStages:            types.VUStages(j.VUStages),
+		Prometheus:          types.PrometheusConf(j.Prometheus),
+		Influxdb:            types.InfluxdbConf(j.Influxdb),
+		StdoutJson:          types.StdoutJsonConf(j.StdoutJson),
+		Html:                types.HtmlConf(j.Html),
+		Junit:               types.JunitConf(j.Junit),
+		Export: types.ExportConf{
+			Path:        j.Export.Path,
+			Format:      strings.ToLower(j.Export.Format),
+			CaptureBody: j.Export.CaptureBody,
+		},
+		Tracing: types.TracingConf(j.Tracing),
+		SourceAddr: types.SourceAddrConf{
+			IPs:       j.SourceAddr.IPs,
+			Interface: j.SourceAddr.Interface,
+			Rotation:  strings.ToLower(j.SourceAddr.Rotation),
+		},
+	}
+	return
+}
+
+func (c *proxyPoolConf) toProxy() (p proxy.Proxy, err error) {
+	conf := proxy.PoolConf{
+		Rotation:    strings.ToLower(c.Rotation),
+		File:        c.File,
+		MaxFails:    c.MaxFails,
+		FailTimeout: time.Duration(c.FailTimeout) * time.Second,
+	}
+	for _, pp := range c.Proxies {
+		addr, err := url.Parse(pp.Addr)
+		if err != nil || addr.Host == "" {
+			return p, fmt.Errorf("invalid address of pool proxy: %s", pp.Addr)
+		}
+		conf.Proxies = append(conf.Proxies, proxy.PoolProxy{Addr: addr, Weight: pp.Weight, Country: pp.Country})
+	}
+
+	p = proxy.Proxy{
+		Strategy: proxy.ProxyTypePool,
+		Others:   map[string]interface{}{proxy.PoolConfKey: conf},
 	}
 	return
 }
@@ -357,6 +566,23 @@ func stepToScenarioStep(s step) (types.ScenarioStep, error) {
 		} else {
 			return types.ScenarioStep{}, fmt.Errorf("payload file %s not found", s.PayloadFile)
 		}
+	} else if s.Graphql.Query != "" {
+		if s.Payload != "" {
+			return types.ScenarioStep{}, fmt.Errorf("payload and graphql can not be used together in step %d", s.Id)
+		}
+
+		gql := types.GraphqlConf(s.Graphql)
+		if payload, err = gql.Payload(); err != nil {
+			return types.ScenarioStep{}, err
+		}
+
+		if s.Headers == nil {
+			s.Headers = make(map[string]string)
+		}
+		if _, ok := s.Headers["Content-Type"]; !ok {
+			s.Headers["Content-Type"] = "application/json"
+		}
+		s.Method = http.MethodPost
 	} else {
 		payload = s.Payload
 	}
@@ -365,6 +591,20 @@ func stepToScenarioStep(s step) (types.ScenarioStep, error) {
 	if s.Auth != (auth{}) && s.Auth.Type == "" {
 		s.Auth.Type = types.AuthHttpBasic
 	}
+	s.Auth.Type = strings.ToLower(s.Auth.Type)
+	if s.Auth.Type == types.AuthOAuth2 {
+		s.Auth.Grant = strings.ToLower(s.Auth.Grant)
+		if s.Auth.Grant == "" {
+			s.Auth.Grant = types.OAuth2GrantClientCredentials
+		}
+		s.Auth.Cache = strings.ToLower(s.Auth.Cache)
+		if s.Auth.Cache == "" {
+			s.Auth.Cache = types.OAuth2CacheGlobal
+		}
+		if s.Auth.RefreshBefore == 0 {
+			s.Auth.RefreshBefore = types.DefaultOAuth2RefreshBefore
+		}
+	}
 
 	err = types.IsTargetValid(s.Url)
 	if err != nil {
@@ -381,6 +621,7 @@ func stepToScenarioStep(s step) (types.ScenarioStep, error) {
 			From:       types.SourceType(path.From),
 			Key:        path.HeaderKey,
 			CookieName: path.CookieName,
+			Event:      path.Event,
 		}
 
 		if path.RegExp != nil {
@@ -393,6 +634,15 @@ func stepToScenarioStep(s step) (types.ScenarioStep, error) {
 		capturedEnvs = append(capturedEnvs, capConf)
 	}
 
+	if s.Sse.Duration > 0 || s.Sse.EventCount > 0 {
+		if s.Headers == nil {
+			s.Headers = make(map[string]string)
+		}
+		if _, ok := s.Headers["Accept"]; !ok {
+			s.Headers["Accept"] = "text/event-stream"
+		}
+	}
+
 	item := types.ScenarioStep{
 		ID:            s.Id,
 		Name:          s.Name,
@@ -406,6 +656,14 @@ func stepToScenarioStep(s step) (types.ScenarioStep, error) {
 		Custom:        s.Others,
 		EnvsToCapture: capturedEnvs,
 		Assertions:    s.Assertions,
+		Grpc:          types.GrpcConf(s.Grpc),
+		Raw:           types.RawConf(s.Raw),
+		Graphql:       types.GraphqlConf(s.Graphql),
+		Sse:           types.SseConf(s.Sse),
+	}
+
+	for _, a := range s.Ws.Actions {
+		item.Ws.Actions = append(item.Ws.Actions, types.WsAction(a))
 	}
 
 	if s.CertPath != "" && s.CertKeyPath != "" {
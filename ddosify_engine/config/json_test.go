@@ -31,6 +31,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"go.ddosify.com/ddosify/core/proxy"
 	"go.ddosify.com/ddosify/core/report"
@@ -282,6 +283,127 @@ func TestCreateHammerManualLoad(t *testing.T) {
 	}
 }
 
+func TestCreateHammerArrivalRate(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_arrival_rate.json"), ConfigTypeJson)
+	expectedHammer := types.Hammer{
+		IterationCount:    200,
+		LoadType:          types.DefaultLoadType,
+		TestDuration:      2,
+		ReportDestination: types.DefaultOutputType,
+		Scenario: types.Scenario{
+			Steps: []types.ScenarioStep{{
+				ID:      1,
+				URL:     "test.com",
+				Method:  types.DefaultMethod,
+				Timeout: types.DefaultTimeout,
+			}},
+		},
+		Proxy: proxy.Proxy{
+			Strategy: proxy.ProxyTypeSingle,
+		},
+		SamplingRate:        types.DefaultSamplingCount,
+		EngineMode:          types.EngineModeDdosify,
+		TestDataConf:        make(map[string]types.CsvConf),
+		SingleMode:          true,
+		Executor:            types.ExecutorArrivalRate,
+		ArrivalDistribution: types.ArrivalDistributionPoisson,
+		MaxVUs:              50,
+	}
+
+	h, err := jsonReader.CreateHammer()
+
+	if err != nil {
+		t.Errorf("TestCreateHammerArrivalRate error occurred: %v", err)
+	}
+
+	if !reflect.DeepEqual(expectedHammer, h) {
+		t.Errorf("Expected: %v, Found: %v", expectedHammer, h)
+	}
+}
+
+func TestCreateHammerVirtualUser(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_virtual_user.json"), ConfigTypeJson)
+	expectedHammer := types.Hammer{
+		IterationCount:    types.DefaultIterCount,
+		LoadType:          types.DefaultLoadType,
+		TestDuration:      660,
+		ReportDestination: types.DefaultOutputType,
+		Scenario: types.Scenario{
+			Steps: []types.ScenarioStep{{
+				ID:      1,
+				URL:     "test.com",
+				Method:  types.DefaultMethod,
+				Timeout: types.DefaultTimeout,
+				Sleep:   "1000-2000",
+			}},
+		},
+		Proxy: proxy.Proxy{
+			Strategy: proxy.ProxyTypeSingle,
+		},
+		SamplingRate: types.DefaultSamplingCount,
+		EngineMode:   types.EngineModeRepeatedUser,
+		TestDataConf: make(map[string]types.CsvConf),
+		SingleMode:   true,
+		Executor:     types.ExecutorVirtualUser,
+		VUs:          10,
+		VUStages:     types.VUStages{{Duration: 30, Target: 200}, {Duration: 600, Target: 200}, {Duration: 30, Target: 0}},
+	}
+
+	h, err := jsonReader.CreateHammer()
+
+	if err != nil {
+		t.Errorf("TestCreateHammerVirtualUser error occurred: %v", err)
+	}
+
+	if !reflect.DeepEqual(expectedHammer, h) {
+		t.Errorf("Expected: %v, Found: %v", expectedHammer, h)
+	}
+}
+
+func TestCreateHammerLoadStages(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_load_stages.json"), ConfigTypeJson)
+	expectedHammer := types.Hammer{
+		IterationCount: types.DefaultIterCount,
+		LoadType:       types.DefaultLoadType,
+		TestDuration:   400,
+		LoadStages: types.LoadStages{
+			{Duration: 60, LoadType: types.LoadTypeIncremental, Rate: 500},
+			{Duration: 300, LoadType: types.LoadTypeLinear, Rate: 500},
+			{Duration: 10, LoadType: types.LoadTypeLinear, Rate: 2000},
+			{Duration: 30, LoadType: types.LoadTypeIncremental, Rate: 0},
+		},
+		ReportDestination: types.DefaultOutputType,
+		Scenario: types.Scenario{
+			Steps: []types.ScenarioStep{{
+				ID:      1,
+				URL:     "test.com",
+				Method:  types.DefaultMethod,
+				Timeout: types.DefaultTimeout,
+			}},
+		},
+		Proxy: proxy.Proxy{
+			Strategy: proxy.ProxyTypeSingle,
+		},
+		SamplingRate: types.DefaultSamplingCount,
+		EngineMode:   types.EngineModeDdosify,
+		TestDataConf: make(map[string]types.CsvConf),
+		SingleMode:   true,
+	}
+
+	h, err := jsonReader.CreateHammer()
+
+	if err != nil {
+		t.Errorf("TestCreateHammerLoadStages error occurred: %v", err)
+	}
+
+	if !reflect.DeepEqual(expectedHammer, h) {
+		t.Errorf("Expected: %v, Found: %v", expectedHammer, h)
+	}
+}
+
 func TestCreateHammerManualLoadOverrideOthers(t *testing.T) {
 	t.Parallel()
 
@@ -410,6 +532,447 @@ func TestCreateHammerAuth(t *testing.T) {
 	}
 }
 
+func TestCreateHammerOAuth2(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_oauth2.json"), ConfigTypeJson)
+	expectedAuths := []types.Auth{
+		{
+			Type:          types.AuthOAuth2,
+			TokenURL:      "https://auth.test.com/oauth/token",
+			ClientID:      "load-test",
+			ClientSecret:  "{{CLIENT_SECRET}}",
+			Scope:         "orders:read orders:write",
+			Grant:         types.OAuth2GrantClientCredentials,
+			Cache:         types.OAuth2CacheGlobal,
+			RefreshBefore: types.DefaultOAuth2RefreshBefore,
+		},
+		{
+			Type:          types.AuthOAuth2,
+			Username:      "user",
+			Password:      "12345",
+			TokenURL:      "https://auth.test.com/oauth/token",
+			ClientID:      "load-test",
+			ClientSecret:  "{{CLIENT_SECRET}}",
+			Grant:         types.OAuth2GrantPassword,
+			Cache:         types.OAuth2CacheUser,
+			RefreshBefore: 60,
+		},
+	}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerOAuth2 error occurred: %v", err)
+	}
+	if err = h.Validate(); err != nil {
+		t.Errorf("TestCreateHammerOAuth2 validation error occurred: %v", err)
+	}
+
+	for i, expected := range expectedAuths {
+		if h.Scenario.Steps[i].Auth != expected {
+			t.Errorf("Expected: %v, Found: %v", expected, h.Scenario.Steps[i].Auth)
+		}
+	}
+}
+
+func TestCreateHammerGrpc(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_grpc.json"), ConfigTypeJson)
+	expectedConfs := []types.GrpcConf{
+		{
+			Method:      "helloworld.Greeter/SayHello",
+			ProtoFile:   "./protos/helloworld.proto",
+			ImportPaths: []string{"./protos"},
+		},
+		{
+			Method: "grpc.health.v1.Health/Check",
+		},
+	}
+	expectedProtocols := []string{types.ProtocolGRPC, types.ProtocolGRPCS}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerGrpc error occurred: %v", err)
+	}
+
+	if err = h.Validate(); err != nil {
+		t.Errorf("TestCreateHammerGrpc validation error occurred: %v", err)
+	}
+
+	for i, step := range h.Scenario.Steps {
+		if !reflect.DeepEqual(step.Grpc, expectedConfs[i]) {
+			t.Errorf("Expected: %v, Found: %v", expectedConfs[i], step.Grpc)
+		}
+		if step.Protocol() != expectedProtocols[i] {
+			t.Errorf("Expected: %v, Found: %v", expectedProtocols[i], step.Protocol())
+		}
+	}
+}
+
+func TestCreateHammerWs(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_ws.json"), ConfigTypeJson)
+	expectedConf := types.WsConf{
+		Actions: []types.WsAction{
+			{Type: types.WsActionSend, Payload: "{\"subscribe\": \"{{channel}}\"}"},
+			{Type: types.WsActionExpect, JsonPath: "type", Equals: "subscribed", Timeout: 5},
+			{Type: types.WsActionExpect, Regex: "notification"},
+			{Type: types.WsActionClose},
+		},
+	}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerWs error occurred: %v", err)
+	}
+
+	if err = h.Validate(); err != nil {
+		t.Errorf("TestCreateHammerWs validation error occurred: %v", err)
+	}
+
+	step := h.Scenario.Steps[0]
+	if !reflect.DeepEqual(step.Ws, expectedConf) {
+		t.Errorf("Expected: %v, Found: %v", expectedConf, step.Ws)
+	}
+	if step.Protocol() != types.ProtocolWSS {
+		t.Errorf("Expected: %v, Found: %v", types.ProtocolWSS, step.Protocol())
+	}
+}
+
+func TestCreateHammerRaw(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_raw.json"), ConfigTypeJson)
+	expectedConfs := []types.RawConf{
+		{Delimiter: "\r\n"},
+		{Format: types.RawFormatHex, ReadBytes: 12, ReadTimeout: 2},
+	}
+	expectedProtocols := []string{types.ProtocolTCP, types.ProtocolUDP}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerRaw error occurred: %v", err)
+	}
+
+	if err = h.Validate(); err != nil {
+		t.Errorf("TestCreateHammerRaw validation error occurred: %v", err)
+	}
+
+	for i, step := range h.Scenario.Steps {
+		if !reflect.DeepEqual(step.Raw, expectedConfs[i]) {
+			t.Errorf("Expected: %v, Found: %v", expectedConfs[i], step.Raw)
+		}
+		if step.Protocol() != expectedProtocols[i] {
+			t.Errorf("Expected: %v, Found: %v", expectedProtocols[i], step.Protocol())
+		}
+	}
+}
+
+func TestCreateHammerGraphql(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_graphql.json"), ConfigTypeJson)
+	expectedPayload := `{"query":"query User($id: Int!) { user(id: $id) { name } }",` +
+		`"variables":{"id":"{{user_id}}"},"operationName":"User"}`
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerGraphql error occurred: %v", err)
+	}
+
+	if err = h.Validate(); err != nil {
+		t.Errorf("TestCreateHammerGraphql validation error occurred: %v", err)
+	}
+
+	step := h.Scenario.Steps[0]
+	if !step.IsGraphql() {
+		t.Errorf("Step should be a graphql step")
+	}
+	if step.Payload != expectedPayload {
+		t.Errorf("Expected: %v, Found: %v", expectedPayload, step.Payload)
+	}
+	if step.Method != http.MethodPost {
+		t.Errorf("Expected: %v, Found: %v", http.MethodPost, step.Method)
+	}
+	if step.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Expected: %v, Found: %v", "application/json", step.Headers["Content-Type"])
+	}
+}
+
+func TestCreateHammerGraphqlWithPayload(t *testing.T) {
+	t.Parallel()
+	config := `{"steps": [{"id": 1, "url": "https://test.com/graphql", "payload": "{}", "graphql": {"query": "{ me }"}}]}`
+	jsonReader, _ := NewConfigReader([]byte(config), ConfigTypeJson)
+
+	if _, err := jsonReader.CreateHammer(); err == nil {
+		t.Errorf("TestCreateHammerGraphqlWithPayload should be errored")
+	}
+}
+
+func TestCreateHammerSse(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_sse.json"), ConfigTypeJson)
+	expectedSse := types.SseConf{Duration: 30, EventCount: 100}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerSse error occurred: %v", err)
+	}
+
+	if err = h.Validate(); err != nil {
+		t.Errorf("TestCreateHammerSse validation error occurred: %v", err)
+	}
+
+	step := h.Scenario.Steps[0]
+	if !step.IsSse() {
+		t.Errorf("Step should be a sse step")
+	}
+	if !reflect.DeepEqual(step.Sse, expectedSse) {
+		t.Errorf("Expected: %v, Found: %v", expectedSse, step.Sse)
+	}
+	if step.Headers["Accept"] != "text/event-stream" {
+		t.Errorf("Expected: %v, Found: %v", "text/event-stream", step.Headers["Accept"])
+	}
+	if step.EnvsToCapture[0].Event != "token" {
+		t.Errorf("Expected: %v, Found: %v", "token", step.EnvsToCapture[0].Event)
+	}
+}
+
+func TestCreateHammerPrometheus(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_prometheus.json"), ConfigTypeJson)
+	expectedConf := types.PrometheusConf{
+		ListenAddr:     ":9901",
+		RemoteWriteURL: "http://localhost:9090/api/v1/write",
+		PushInterval:   5,
+	}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerPrometheus error occurred: %v", err)
+	}
+
+	if err = h.Validate(); err != nil {
+		t.Errorf("TestCreateHammerPrometheus validation error occurred: %v", err)
+	}
+
+	if h.ReportDestination != "prometheus" {
+		t.Errorf("Expected: %v, Found: %v", "prometheus", h.ReportDestination)
+	}
+	if !reflect.DeepEqual(h.Prometheus, expectedConf) {
+		t.Errorf("Expected: %v, Found: %v", expectedConf, h.Prometheus)
+	}
+}
+
+func TestCreateHammerInfluxdb(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_influxdb.json"), ConfigTypeJson)
+	expectedConf := types.InfluxdbConf{
+		URL:           "http://localhost:9086",
+		Org:           "ddosify",
+		Bucket:        "hammerBucket",
+		Token:         "token",
+		Mode:          types.InfluxdbModeAggregate,
+		FlushInterval: 2,
+	}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerInfluxdb error occurred: %v", err)
+	}
+
+	if err = h.Validate(); err != nil {
+		t.Errorf("TestCreateHammerInfluxdb validation error occurred: %v", err)
+	}
+
+	if h.ReportDestination != "influxdb" {
+		t.Errorf("Expected: %v, Found: %v", "influxdb", h.ReportDestination)
+	}
+	if !reflect.DeepEqual(h.Influxdb, expectedConf) {
+		t.Errorf("Expected: %v, Found: %v", expectedConf, h.Influxdb)
+	}
+}
+
+func TestCreateHammerHtml(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_html.json"), ConfigTypeJson)
+	expectedConf := types.HtmlConf{Path: "reports/release.html"}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerHtml error occurred: %v", err)
+	}
+
+	if h.ReportDestination != "html" {
+		t.Errorf("Expected: %v, Found: %v", "html", h.ReportDestination)
+	}
+	if h.Html != expectedConf {
+		t.Errorf("Expected: %v, Found: %v", expectedConf, h.Html)
+	}
+}
+
+func TestCreateHammerJunit(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_junit.json"), ConfigTypeJson)
+	expectedConf := types.JunitConf{Path: "reports/junit.xml"}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerJunit error occurred: %v", err)
+	}
+
+	if h.ReportDestination != "junit" {
+		t.Errorf("Expected: %v, Found: %v", "junit", h.ReportDestination)
+	}
+	if h.Junit != expectedConf {
+		t.Errorf("Expected: %v, Found: %v", expectedConf, h.Junit)
+	}
+}
+
+func TestCreateHammerHistogramPrecision(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_histogram_precision.json"), ConfigTypeJson)
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerHistogramPrecision error occurred: %v", err)
+	}
+
+	if h.HistogramPrecision != 4 {
+		t.Errorf("Expected: %v, Found: %v", 4, h.HistogramPrecision)
+	}
+}
+
+func TestCreateHammerStdoutJsonBuckets(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_stdout_json.json"), ConfigTypeJson)
+	expectedConf := types.StdoutJsonConf{BucketInterval: 10}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerStdoutJsonBuckets error occurred: %v", err)
+	}
+
+	if h.ReportDestination != "stdout-json" {
+		t.Errorf("Expected: %v, Found: %v", "stdout-json", h.ReportDestination)
+	}
+	if h.StdoutJson != expectedConf {
+		t.Errorf("Expected: %v, Found: %v", expectedConf, h.StdoutJson)
+	}
+}
+
+func TestCreateHammerExport(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_export.json"), ConfigTypeJson)
+	expectedConf := types.ExportConf{Path: "results/run.out", Format: types.ExportFormatCsv, CaptureBody: true}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerExport error occurred: %v", err)
+	}
+
+	if h.ReportDestination != "export" {
+		t.Errorf("Expected: %v, Found: %v", "export", h.ReportDestination)
+	}
+	if h.Export != expectedConf {
+		t.Errorf("Expected: %v, Found: %v", expectedConf, h.Export)
+	}
+}
+
+func TestCreateHammerTracing(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_tracing.json"), ConfigTypeJson)
+	expectedConf := types.TracingConf{
+		Endpoint:    "http://localhost:4318",
+		ServiceName: "checkout-load-test",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+	}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerTracing error occurred: %v", err)
+	}
+
+	if !reflect.DeepEqual(h.Tracing, expectedConf) {
+		t.Errorf("Expected: %v, Found: %v", expectedConf, h.Tracing)
+	}
+}
+
+func TestCreateHammerSourceAddress(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_source_address.json"), ConfigTypeJson)
+	expectedConf := types.SourceAddrConf{
+		IPs:       []string{"10.0.0.1", "10.0.0.2"},
+		Interface: "eth1",
+		Rotation:  types.SourceIPRotationIteration,
+	}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerSourceAddress error occurred: %v", err)
+	}
+
+	if !reflect.DeepEqual(h.SourceAddr, expectedConf) {
+		t.Errorf("Expected: %v, Found: %v", expectedConf, h.SourceAddr)
+	}
+}
+
+func TestCreateHammerProxyPool(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_proxy_pool.json"), ConfigTypeJson)
+	addr1, _ := url.Parse("http://10.0.0.1:3128")
+	addr2, _ := url.Parse("socks5://10.0.0.2:1080")
+	expectedProxy := proxy.Proxy{
+		Strategy: proxy.ProxyTypePool,
+		Others: map[string]interface{}{
+			proxy.PoolConfKey: proxy.PoolConf{
+				Rotation: proxy.PoolRotationWeighted,
+				File:     "config_testdata/proxies.txt",
+				Proxies: []proxy.PoolProxy{
+					{Addr: addr1, Weight: 3, Country: "DE"},
+					{Addr: addr2},
+				},
+				MaxFails:    5,
+				FailTimeout: 30 * time.Second,
+			},
+		},
+	}
+
+	h, err := jsonReader.CreateHammer()
+	if err != nil {
+		t.Errorf("TestCreateHammerProxyPool error occurred: %v", err)
+	}
+
+	if !reflect.DeepEqual(h.Proxy, expectedProxy) {
+		t.Errorf("Expected: %v, Found: %v", expectedProxy, h.Proxy)
+	}
+
+	ps, _ := proxy.NewProxyService(h.Proxy.Strategy)
+	if err = ps.Init(h.Proxy); err != nil {
+		t.Errorf("TestCreateHammerProxyPool proxy pool init error occurred: %v", err)
+	}
+	if len(ps.GetAll()) != 4 {
+		t.Errorf("Expected 4 proxies in the pool, Found: %d", len(ps.GetAll()))
+	}
+}
+
+func TestCreateHammerProxyPoolWithProxy(t *testing.T) {
+	t.Parallel()
+	jsonReader, _ := NewConfigReader([]byte(`{
+		"proxy": "http://10.0.0.1:3128",
+		"proxy_pool": {"proxies": [{"addr": "http://10.0.0.2:3128"}]},
+		"steps": [{"id": 1, "url": "https://test.com"}]
+	}`), ConfigTypeJson)
+	if _, err := jsonReader.CreateHammer(); err == nil {
+		t.Errorf("TestCreateHammerProxyPoolWithProxy should be errored")
+	}
+
+	jsonReader, _ = NewConfigReader([]byte(`{
+		"proxy_pool": {"proxies": [{"addr": "10.0.0.2"}]},
+		"steps": [{"id": 1, "url": "https://test.com"}]
+	}`), ConfigTypeJson)
+	if _, err := jsonReader.CreateHammer(); err == nil {
+		t.Errorf("TestCreateHammerProxyPoolWithProxy invalid pool proxy address should be errored")
+	}
+}
+
 func TestCreateHammerGlobalEnvs(t *testing.T) {
 	t.Parallel()
 	jsonReader, _ := NewConfigReader(readConfigFile("config_testdata/config_global_envs.json"), ConfigTypeJson)
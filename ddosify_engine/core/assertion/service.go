@@ -1,14 +1,13 @@
 package assertion
 
 import (
-	"sort"
 	"sync"
 	"time"
 
 	"go.ddosify.com/ddosify/core/scenario/scripting/assertion"
 	"go.ddosify.com/ddosify/core/scenario/scripting/assertion/evaluator"
 	"go.ddosify.com/ddosify/core/types"
-	"golang.org/x/exp/slices"
+	"go.ddosify.com/ddosify/core/util"
 )
 
 var tickerInterval = 100 // interval in millisecond
@@ -21,6 +20,7 @@ type DefaultAssertionService struct {
 	assertEnv  *evaluator.AssertEnv
 	abortTick  map[string]int // rule -> tickIndex
 	iterCount  int
+	precision  int // significant digits of the iteration duration histogram
 	mu         sync.Mutex
 }
 
@@ -39,19 +39,21 @@ func NewDefaultAssertionService() (service *DefaultAssertionService) {
 	return &DefaultAssertionService{}
 }
 
-func (as *DefaultAssertionService) Init(assertions map[string]types.TestAssertionOpt) chan struct{} {
+// Init prepares the service for the given test-wide assertions. Iteration durations are kept
+// in a histogram with the given number of significant digits.
+func (as *DefaultAssertionService) Init(assertions map[string]types.TestAssertionOpt, histogramPrecision int) chan struct{} {
 	as.assertions = assertions
 	as.abortChan = make(chan struct{})
 	as.doneChan = make(chan struct{})
 	as.resChan = make(chan TestAssertionResult, 1)
-	totalTime := make([]int64, 0)
-	as.assertEnv = &evaluator.AssertEnv{TotalTime: totalTime}
+	as.precision = histogramPrecision
+	as.assertEnv = &evaluator.AssertEnv{TotalTime: util.NewHistogram(histogramPrecision)}
 	as.abortTick = make(map[string]int)
 	as.mu = sync.Mutex{}
 	return as.abortChan
 }
 
-func (as *DefaultAssertionService) GetTotalTimes() []int64 {
+func (as *DefaultAssertionService) GetTotalTimes() *util.Histogram {
 	return as.assertEnv.TotalTime
 }
 func (as *DefaultAssertionService) GetFailCount() int {
@@ -90,8 +92,7 @@ func (as *DefaultAssertionService) aggregate(r *types.ScenarioResult) {
 		as.assertEnv.FailCount++
 	}
 
-	// keep totalTime array sorted
-	as.insertSorted(iterationTime)
+	as.assertEnv.TotalTime.Record(iterationTime)
 
 	as.assertEnv.FailCountPerc = float64(as.assertEnv.FailCount) / float64(as.iterCount)
 }
@@ -108,8 +109,8 @@ func (as *DefaultAssertionService) applyAssertions() {
 	}
 	for range ticker.C {
 		as.mu.Lock()
-		var totalTime []int64
-		totalTime = append(totalTime, as.assertEnv.TotalTime...)
+		totalTime := util.NewHistogram(as.precision)
+		totalTime.Merge(as.assertEnv.TotalTime)
 		assertEnv := evaluator.AssertEnv{
 			TotalTime: totalTime,
 			FailCount: as.assertEnv.FailCount,
@@ -171,8 +172,3 @@ func (as *DefaultAssertionService) AbortChan() <-chan struct{} {
 func (as *DefaultAssertionService) DoneChan() <-chan struct{} {
 	return as.doneChan
 }
-
-func (as *DefaultAssertionService) insertSorted(v int64) {
-	index := sort.Search(len(as.assertEnv.TotalTime), func(i int) bool { return as.assertEnv.TotalTime[i] >= v })
-	as.assertEnv.TotalTime = slices.Insert(as.assertEnv.TotalTime, index, v)
-}
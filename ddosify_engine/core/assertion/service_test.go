@@ -1,13 +1,13 @@
 package assertion
 
 import (
-	"reflect"
 	"sort"
 	"sync"
 	"testing"
 	"time"
 
 	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/util"
 )
 
 func TestApplyAssertionsAbortsCorrectly(t *testing.T) {
@@ -19,7 +19,7 @@ func TestApplyAssertionsAbortsCorrectly(t *testing.T) {
 		Abort: true,
 		Delay: delay,
 	}
-	abortChan := service.Init(assertions)
+	abortChan := service.Init(assertions, util.DefaultHistogramPrecision)
 
 	inputChan := make(chan *types.ScenarioResult)
 	go service.Start(inputChan)
@@ -50,7 +50,7 @@ func TestServiceKeepsIterationTimes(t *testing.T) {
 		Abort: false,
 		Delay: delay,
 	}
-	_ = service.Init(assertions)
+	_ = service.Init(assertions, util.DefaultHistogramPrecision)
 
 	inputChan := make(chan *types.ScenarioResult)
 	go service.Start(inputChan)
@@ -82,15 +82,22 @@ func TestServiceKeepsIterationTimes(t *testing.T) {
 
 	iterationTimes := service.GetTotalTimes()
 
-	if !reflect.DeepEqual(iterationTimes, []int64(expectedIterationTimes)) {
-		t.Errorf("TestServiceKeepsIterationTimes, cumulative data store failed")
+	if iterationTimes.Count() != int64(len(expectedIterationTimes)) {
+		t.Errorf("TestServiceKeepsIterationTimes, expected count %d, got %d",
+			len(expectedIterationTimes), iterationTimes.Count())
+	}
+	for p := 10; p <= 100; p += 10 {
+		expected := expectedIterationTimes[p*len(expectedIterationTimes)/100-1]
+		if got := iterationTimes.ValueAtPercentile(float64(p)); got != expected {
+			t.Errorf("TestServiceKeepsIterationTimes, p%d expected %d, got %d", p, expected, got)
+		}
 	}
 }
 
 func TestServiceKeepsFailCount(t *testing.T) {
 	service := NewDefaultAssertionService()
 	assertions := make(map[string]types.TestAssertionOpt)
-	_ = service.Init(assertions)
+	_ = service.Init(assertions, util.DefaultHistogramPrecision)
 
 	inputChan := make(chan *types.ScenarioResult)
 	go service.Start(inputChan)
@@ -0,0 +1,208 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package distributed
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"go.ddosify.com/ddosify/config"
+	"go.ddosify.com/ddosify/core"
+	"go.ddosify.com/ddosify/core/assertion"
+	"go.ddosify.com/ddosify/core/proxy"
+	"go.ddosify.com/ddosify/core/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// results are sent to the controller in batches
+	flushInterval = 100 * time.Millisecond
+	maxBatchSize  = 500
+)
+
+// Agent runs its share of the test with a local engine and streams the results to the controller.
+// An agent runs one test at a time.
+type Agent struct {
+	server *grpc.Server
+	mu     sync.Mutex
+}
+
+// NewAgent is the constructor of the Agent.
+func NewAgent() *Agent {
+	a := &Agent{server: grpc.NewServer()}
+	a.server.RegisterService(&agentServiceDesc, a)
+	return a
+}
+
+// Serve accepts the controller connections on the given listener. It blocks until Stop is called.
+func (a *Agent) Serve(lis net.Listener) error {
+	return a.server.Serve(lis)
+}
+
+// Stop closes the listeners and stops the running test.
+func (a *Agent) Stop() {
+	a.server.Stop()
+}
+
+// Run is the handler of the Run method. The test is stopped when the controller cancels the stream.
+func (a *Agent) Run(req *RunRequest, stream grpc.ServerStream) error {
+	if !a.mu.TryLock() {
+		return status.Error(codes.Unavailable, "agent is already running a test")
+	}
+	defer a.mu.Unlock()
+
+	h, err := createAgentHammer(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	es, err := initAgentServices(h, stream)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx := stream.Context()
+	engine, err := core.NewEngine(ctx, h, es)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if err = engine.Init(); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	select {
+	case <-time.After(time.Until(req.StartAt)):
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	engine.Start()
+	return nil
+}
+
+// createAgentHammer creates the hammer of the agent's share. Test-wide assertions are applied by the controller.
+func createAgentHammer(req *RunRequest) (h types.Hammer, err error) {
+	c, err := config.NewConfigReader(req.Config, config.ConfigTypeJson)
+	if err != nil {
+		return
+	}
+
+	h, err = c.CreateHammer()
+	if err != nil {
+		return
+	}
+
+	h.Debug = false
+	h.SingleMode = false
+	h.Assertions = nil
+	h.ReqCountArr = req.ReqCountArr
+	h.VUCountArr = req.VUCountArr
+	h.MaxVUs = req.MaxVUs
+
+	if len(h.ReqCountArr) == 0 && len(h.VUCountArr) == 0 {
+		err = fmt.Errorf("load plan of the agent is empty")
+		return
+	}
+
+	err = h.Validate()
+	return
+}
+
+func initAgentServices(h types.Hammer, stream grpc.ServerStream) (*core.EngineServices, error) {
+	as := assertion.NewDefaultAssertionService()
+	as.Init(h.Assertions, h.HistogramPrecision)
+
+	ps, err := proxy.NewProxyService(h.Proxy.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	if err = ps.Init(h.Proxy); err != nil {
+		return nil, err
+	}
+
+	rs := &streamReporter{stream: stream}
+	if err = rs.Init(h.Debug, h.SamplingRate); err != nil {
+		return nil, err
+	}
+
+	return &core.EngineServices{
+		Aborter:     as,
+		Asserter:    as,
+		ResListener: as,
+
+		ProxyServ:  ps,
+		ReportServ: rs,
+	}, nil
+}
+
+// streamReporter is the report service of the agents, it sends the results to the controller in batches.
+type streamReporter struct {
+	stream   grpc.ServerStream
+	doneChan chan bool
+}
+
+func (s *streamReporter) Init(debug bool, samplingRate int) error {
+	s.doneChan = make(chan bool, 1)
+	return nil
+}
+
+func (s *streamReporter) Start(input chan *types.ScenarioResult, _ <-chan assertion.TestAssertionResult) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var sendErr error
+	batch := make([]*RunResult, 0, maxBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		// keep draining the input even if the controller is gone, so the engine can stop
+		if sendErr == nil {
+			sendErr = s.stream.SendMsg(&RunEvent{Results: batch})
+		}
+		batch = make([]*RunResult, 0, maxBatchSize)
+	}
+
+	for {
+		select {
+		case r, ok := <-input:
+			if !ok {
+				flush()
+				s.doneChan <- sendErr == nil
+				return
+			}
+			batch = append(batch, newRunResult(r))
+			if len(batch) >= maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (s *streamReporter) DoneChan() <-chan bool {
+	return s.doneChan
+}
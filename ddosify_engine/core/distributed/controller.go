@@ -0,0 +1,269 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.ddosify.com/ddosify/core"
+	"go.ddosify.com/ddosify/core/assertion"
+	"go.ddosify.com/ddosify/core/report"
+	"go.ddosify.com/ddosify/core/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	// test result status, same with the engine
+	resultDone    = "done"
+	resultStopped = "stopped"
+	resultAborted = "aborted"
+
+	// time given to the agents to initialize their engines before the test starts
+	defaultStartDelay = time.Second
+)
+
+// Controller splits the load of the test across the agents and merges the results streamed back by them.
+// Results are reported and test-wide assertions are applied globally on the controller.
+type Controller struct {
+	hammer types.Hammer
+	config []byte
+	agents []string
+	conns  []*grpc.ClientConn
+
+	reportService report.ReportService
+
+	// for assertion
+	aborter     assertion.Aborter
+	asserter    assertion.Asserter
+	resListener assertion.ResultListener
+
+	resultReportChan chan *types.ScenarioResult
+	resultAssertChan chan *types.ScenarioResult
+
+	startDelay  time.Duration
+	testSuccess bool
+	ctx         context.Context
+}
+
+// NewController is the constructor of the Controller.
+// Config is the json config that the hammer is created from, it is sent to the agents as is.
+// Controller can be stopped by canceling the given ctx.
+func NewController(ctx context.Context, h types.Hammer, config []byte, agents []string,
+	services *core.EngineServices) (*Controller, error) {
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("at least one agent is required")
+	}
+	if h.Debug {
+		return nil, fmt.Errorf("debug mode is not supported in distributed mode")
+	}
+
+	return &Controller{
+		hammer:        h,
+		config:        config,
+		agents:        agents,
+		ctx:           ctx,
+		reportService: services.ReportServ,
+		startDelay:    defaultStartDelay,
+
+		// for assertion
+		aborter:     services.Aborter,
+		asserter:    services.Asserter,
+		resListener: services.ResListener,
+	}, nil
+}
+
+func (c *Controller) IsTestFailed() bool {
+	return !c.testSuccess
+}
+
+// Init connects to the agents.
+func (c *Controller) Init() error {
+	for _, addr := range c.agents {
+		conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			c.closeConns()
+			return fmt.Errorf("agent %s: %v", addr, err)
+		}
+		c.conns = append(c.conns, conn)
+	}
+	return nil
+}
+
+// Start runs the test on all of the agents and blocks until all of them finish. If an agent fails,
+// the test is stopped on the others too and the error is returned.
+func (c *Controller) Start() (string, error) {
+	c.resultReportChan = make(chan *types.ScenarioResult, c.hammer.IterationCount)
+	c.resultAssertChan = make(chan *types.ScenarioResult, c.hammer.IterationCount)
+
+	var testResultChan <-chan assertion.TestAssertionResult
+	if len(c.hammer.Assertions) > 0 {
+		testResultChan = c.asserter.ResultChan()
+		go c.resListener.Start(c.resultAssertChan)
+	}
+	go c.reportService.Start(c.resultReportChan, testResultChan)
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer c.closeConns()
+
+	reqs := c.splitLoad(time.Now().Add(c.startDelay))
+	errChan := make(chan error, len(c.agents))
+	wg := sync.WaitGroup{}
+	for i := range c.agents {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := c.runAgent(ctx, i, reqs[i]); err != nil {
+				errChan <- err
+			}
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	result := resultDone
+	select {
+	case <-done:
+	case err = <-errChan:
+		result = resultStopped
+	case <-c.ctx.Done():
+		result = resultStopped
+	case <-c.aborter.AbortChan():
+		result = resultAborted
+	}
+
+	cancel()
+	<-done
+	c.stop()
+
+	if err == nil {
+		select {
+		case err = <-errChan:
+		default:
+		}
+	}
+	return result, err
+}
+
+// splitLoad creates the run requests of the agents.
+func (c *Controller) splitLoad(startAt time.Time) []*RunRequest {
+	reqCountArr, vuCountArr := core.TickPlan(c.hammer)
+	reqCountArrs := splitCounts(reqCountArr, len(c.agents))
+	vuCountArrs := splitCounts(vuCountArr, len(c.agents))
+	maxVUs := splitCounts([]int{c.hammer.MaxVUs}, len(c.agents))
+
+	reqs := make([]*RunRequest, len(c.agents))
+	for i := range c.agents {
+		reqs[i] = &RunRequest{
+			Config:      c.config,
+			ReqCountArr: reqCountArrs[i],
+			VUCountArr:  vuCountArrs[i],
+			MaxVUs:      maxVUs[i][0],
+			StartAt:     startAt,
+		}
+
+		// zero means unlimited for max_vus
+		if c.hammer.MaxVUs > 0 && reqs[i].MaxVUs == 0 {
+			reqs[i].MaxVUs = 1
+		}
+	}
+	return reqs
+}
+
+func (c *Controller) runAgent(ctx context.Context, i int, req *RunRequest) error {
+	stream, err := startRun(ctx, c.conns[i], req)
+	if err != nil {
+		return fmt.Errorf("agent %s: %v", c.agents[i], err)
+	}
+
+	for {
+		ev := &RunEvent{}
+		err := stream.RecvMsg(ev)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil { // stopped by the controller
+				return nil
+			}
+			return fmt.Errorf("agent %s: %v", c.agents[i], err)
+		}
+
+		for _, rr := range ev.Results {
+			r := rr.scenarioResult()
+			c.resultReportChan <- r
+			if len(c.hammer.Assertions) > 0 {
+				c.resultAssertChan <- r
+			}
+		}
+	}
+}
+
+func (c *Controller) stop() {
+	close(c.resultReportChan)
+	close(c.resultAssertChan)
+
+	if len(c.hammer.Assertions) > 0 { // if results are listened, wait
+		<-c.resListener.DoneChan()
+	}
+
+	c.testSuccess = <-c.reportService.DoneChan()
+}
+
+func (c *Controller) closeConns() {
+	for _, conn := range c.conns {
+		conn.Close()
+	}
+	c.conns = nil
+}
+
+// splitCounts distributes the count of each tick across n agents as evenly as possible.
+// Remainders are given to the agents in turn, so no agent gets more load than the others in the long run.
+func splitCounts(counts []int, n int) [][]int {
+	shares := make([][]int, n)
+	if len(counts) == 0 {
+		return shares
+	}
+	for i := range shares {
+		shares[i] = make([]int, len(counts))
+	}
+
+	next := 0
+	for t, count := range counts {
+		for i := range shares {
+			shares[i][t] = count / n
+		}
+		for r := 0; r < count%n; r++ {
+			shares[next][t]++
+			next = (next + 1) % n
+		}
+	}
+	return shares
+}
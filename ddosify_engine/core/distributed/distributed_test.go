@@ -0,0 +1,258 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.ddosify.com/ddosify/config"
+	"go.ddosify.com/ddosify/core"
+	"go.ddosify.com/ddosify/core/assertion"
+	"go.ddosify.com/ddosify/core/types"
+)
+
+type countingReport struct {
+	doneChan chan bool
+	runs     int
+	durs     int
+}
+
+func (r *countingReport) Init(debug bool, samplingRate int) error {
+	r.doneChan = make(chan bool, 1)
+	return nil
+}
+
+func (r *countingReport) DoneChan() <-chan bool {
+	return r.doneChan
+}
+
+func (r *countingReport) Start(input chan *types.ScenarioResult,
+	assertionResultChan <-chan assertion.TestAssertionResult) {
+	for res := range input {
+		r.runs++
+		for _, sr := range res.StepResults {
+			if _, ok := sr.Custom["dnsDuration"].(time.Duration); ok {
+				r.durs++
+			}
+		}
+	}
+	r.doneChan <- true
+}
+
+func startAgents(t *testing.T, n int) (agents []string) {
+	for i := 0; i < n; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen error: %v", err)
+		}
+		a := NewAgent()
+		go a.Serve(lis)
+		t.Cleanup(a.Stop)
+		agents = append(agents, lis.Addr().String())
+	}
+	return
+}
+
+func newController(t *testing.T, cfg string, agents []string) (*Controller, *countingReport) {
+	c, err := config.NewConfigReader([]byte(cfg), config.ConfigTypeJson)
+	if err != nil {
+		t.Fatalf("config error: %v", err)
+	}
+	h, err := c.CreateHammer()
+	if err != nil {
+		t.Fatalf("hammer error: %v", err)
+	}
+	if err = h.Validate(); err != nil {
+		t.Fatalf("validation error: %v", err)
+	}
+
+	es, err := core.InitEngineServices(h)
+	if err != nil {
+		t.Fatalf("services error: %v", err)
+	}
+	rs := &countingReport{}
+	rs.Init(false, 0)
+	es.ReportServ = rs
+
+	controller, err := NewController(context.TODO(), h, []byte(cfg), agents, es)
+	if err != nil {
+		t.Fatalf("controller error: %v", err)
+	}
+	controller.startDelay = 100 * time.Millisecond
+	if err = controller.Init(); err != nil {
+		t.Fatalf("controller init error: %v", err)
+	}
+	return controller, rs
+}
+
+func TestSplitCounts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		counts   []int
+		n        int
+		expected [][]int
+	}{
+		{"Even", []int{4, 6}, 2, [][]int{{2, 3}, {2, 3}}},
+		{"RotatedRemainders", []int{1, 1, 1, 2}, 3, [][]int{{1, 0, 0, 1}, {0, 1, 0, 1}, {0, 0, 1, 0}}},
+		{"SingleAgent", []int{5, 7}, 1, [][]int{{5, 7}}},
+		{"Empty", nil, 2, [][]int{nil, nil}},
+	}
+
+	for _, test := range tests {
+		tf := func(t *testing.T) {
+			shares := splitCounts(test.counts, test.n)
+			if !reflect.DeepEqual(test.expected, shares) {
+				t.Errorf("Expected %v, Found %v", test.expected, shares)
+			}
+		}
+		t.Run(test.name, tf)
+	}
+}
+
+func TestRunResultKeepsMetricTypes(t *testing.T) {
+	custom := map[string]interface{}{
+		"dnsDuration":     5 * time.Millisecond,
+		"sentFrameCount":  int64(3),
+		"eventsPerSecond": 2.5,
+		"grpcStatus":      "OK",
+	}
+	r := &types.ScenarioResult{
+		StepResults: []*types.ScenarioStepResult{
+			{StepID: 1, RespBody: []byte("body"), Custom: custom},
+		},
+	}
+
+	codec := jsonCodec{}
+	data, err := codec.Marshal(&RunEvent{Results: []*RunResult{newRunResult(r)}})
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	ev := &RunEvent{}
+	if err = codec.Unmarshal(data, ev); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	sr := ev.Results[0].scenarioResult().StepResults[0]
+	if !reflect.DeepEqual(sr.Custom, custom) {
+		t.Errorf("Expected: %#v, Found: %#v", custom, sr.Custom)
+	}
+	if sr.RespBody != nil {
+		t.Errorf("Response body should not be sent")
+	}
+	if r.StepResults[0].RespBody == nil || r.StepResults[0].Custom == nil {
+		t.Errorf("Result of the agent should not be changed")
+	}
+}
+
+func TestDistributedRun(t *testing.T) {
+	t.Parallel()
+
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+	}))
+	defer server.Close()
+
+	agents := startAgents(t, 3)
+	cfg := fmt.Sprintf(`{
+		"iteration_count": 50,
+		"duration": 2,
+		"success_criterias": [{"rule": "fail_count == 0"}],
+		"steps": [{"id": 1, "url": "%s"}]
+	}`, server.URL)
+
+	controller, rs := newController(t, cfg, agents)
+	result, err := controller.Start()
+	if err != nil {
+		t.Fatalf("TestDistributedRun error occurred %v", err)
+	}
+
+	if result != resultDone {
+		t.Errorf("Expected result: %s, Found: %s", resultDone, result)
+	}
+	if rs.runs != 50 || atomic.LoadInt32(&received) != 50 {
+		t.Errorf("Expected 50 iterations, Found reported: %d, received: %d", rs.runs, received)
+	}
+	if rs.durs != 50 {
+		t.Errorf("Expected duration metrics to be restored, Found: %d", rs.durs)
+	}
+	if controller.IsTestFailed() {
+		t.Errorf("Expected test to succeed")
+	}
+}
+
+func TestDistributedAbort(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	agents := startAgents(t, 2)
+	cfg := fmt.Sprintf(`{
+		"iteration_count": 500,
+		"duration": 10,
+		"success_criterias": [{"rule": "fail_count < 5", "abort": true}],
+		"steps": [{"id": 1, "url": "%s", "assertion": ["equals(status_code, 200)"]}]
+	}`, server.URL)
+
+	controller, rs := newController(t, cfg, agents)
+	start := time.Now()
+	result, err := controller.Start()
+	if err != nil {
+		t.Fatalf("TestDistributedAbort error occurred %v", err)
+	}
+
+	if result != resultAborted {
+		t.Errorf("Expected result: %s, Found: %s", resultAborted, result)
+	}
+	if time.Since(start) > 5*time.Second || rs.runs >= 500 {
+		t.Errorf("Expected agents to be stopped, took: %v, iterations: %d", time.Since(start), rs.runs)
+	}
+}
+
+func TestDistributedAgentError(t *testing.T) {
+	t.Parallel()
+
+	// nothing listens on the reserved address
+	lis, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := lis.Addr().String()
+	lis.Close()
+
+	agents := append(startAgents(t, 1), addr)
+	cfg := `{"iteration_count": 10, "duration": 1, "steps": [{"id": 1, "url": "http://127.0.0.1:1"}]}`
+
+	controller, _ := newController(t, cfg, agents)
+	if _, err := controller.Start(); err == nil {
+		t.Errorf("Expected error for the unreachable agent")
+	}
+}
@@ -0,0 +1,211 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+// Messages are encoded as JSON, so the controller and the agents don't need generated protobuf code.
+const codecName = "json"
+
+const (
+	agentServiceName = "ddosify.distributed.Agent"
+	runMethod        = "/" + agentServiceName + "/Run"
+)
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
+
+// RunRequest is sent by the controller to each agent to start its share of the test.
+type RunRequest struct {
+	// Json config of the test. Agents create their own hammer from it.
+	Config []byte
+
+	// Share of the agent from the iteration count per tick.
+	ReqCountArr []int
+
+	// Share of the agent from the virtual user count per tick for the virtual-user executor.
+	VUCountArr []int
+
+	// Share of the agent from the max_vus of the arrival-rate executor.
+	MaxVUs int
+
+	// All of the agents start ticking at this time.
+	StartAt time.Time
+}
+
+// RunEvent is streamed by the agents to the controller while the test is running.
+type RunEvent struct {
+	Results []*RunResult
+}
+
+// RunResult is a result of an iteration on the wire. The custom metrics of the step results are sent in typed maps
+// instead of their Custom maps, json would decode the numbers of an untyped map as float64.
+type RunResult struct {
+	Result *types.ScenarioResult
+
+	// Custom metrics of the step results, by the index of the step result.
+	Metrics []StepMetrics
+}
+
+// StepMetrics are the custom metrics of a step result by their types.
+type StepMetrics struct {
+	Durations map[string]time.Duration `json:",omitempty"` // in nanoseconds
+	Counts    map[string]int64         `json:",omitempty"`
+	Rates     map[string]float64       `json:",omitempty"`
+	Labels    map[string]string        `json:",omitempty"`
+}
+
+// agentServer is the server API of the Agent service.
+type agentServer interface {
+	Run(req *RunRequest, stream grpc.ServerStream) error
+}
+
+var agentServiceDesc = grpc.ServiceDesc{
+	ServiceName: agentServiceName,
+	HandlerType: (*agentServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Run",
+			Handler:       runHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+func runHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(RunRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(agentServer).Run(req, stream)
+}
+
+// startRun calls the Run method of the agent on the given connection. Stream is closed by canceling the ctx.
+func startRun(ctx context.Context, conn *grpc.ClientConn, req *RunRequest) (grpc.ClientStream, error) {
+	stream, err := conn.NewStream(ctx, &agentServiceDesc.Streams[0], runMethod, grpc.CallContentSubtype(codecName))
+	if err != nil {
+		return nil, err
+	}
+	if err = stream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err = stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// newRunResult copies the result to send it to the controller. The request/response details are dropped
+// since only the debug mode uses them. The result itself is not changed, the other services of the agent use it.
+func newRunResult(r *types.ScenarioResult) *RunResult {
+	compact := *r
+	compact.StepResults = make([]*types.ScenarioStepResult, len(r.StepResults))
+	rr := &RunResult{Result: &compact, Metrics: make([]StepMetrics, len(r.StepResults))}
+	for i, sr := range r.StepResults {
+		c := *sr
+		c.ReqHeaders = nil
+		c.ReqBody = nil
+		c.RespHeaders = nil
+		c.RespBody = nil
+		c.UsableEnvs = nil
+		c.ExtractedEnvs = nil
+		c.Custom = nil
+		compact.StepResults[i] = &c
+		rr.Metrics[i] = newStepMetrics(sr.Custom)
+	}
+	return rr
+}
+
+func newStepMetrics(custom map[string]interface{}) (m StepMetrics) {
+	for k, v := range custom {
+		switch v := v.(type) {
+		case time.Duration:
+			if m.Durations == nil {
+				m.Durations = map[string]time.Duration{}
+			}
+			m.Durations[k] = v
+		case int64:
+			if m.Counts == nil {
+				m.Counts = map[string]int64{}
+			}
+			m.Counts[k] = v
+		case float64:
+			if m.Rates == nil {
+				m.Rates = map[string]float64{}
+			}
+			m.Rates[k] = v
+		case string:
+			if m.Labels == nil {
+				m.Labels = map[string]string{}
+			}
+			m.Labels[k] = v
+		}
+	}
+	return
+}
+
+// scenarioResult returns the result with the custom metrics put back to the Custom maps of the step results.
+func (rr *RunResult) scenarioResult() *types.ScenarioResult {
+	for i, sr := range rr.Result.StepResults {
+		if i >= len(rr.Metrics) {
+			break
+		}
+		m := rr.Metrics[i]
+		sr.Custom = make(map[string]interface{}, len(m.Durations)+len(m.Counts)+len(m.Rates)+len(m.Labels))
+		for k, v := range m.Durations {
+			sr.Custom[k] = v
+		}
+		for k, v := range m.Counts {
+			sr.Custom[k] = v
+		}
+		for k, v := range m.Rates {
+			sr.Custom[k] = v
+		}
+		for k, v := range m.Labels {
+			sr.Custom[k] = v
+		}
+	}
+	return rr.Result
+}
@@ -24,7 +24,9 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/rand"
 	"net/http"
+	"net/url"
 	"reflect"
 	"sync"
 	"time"
@@ -35,6 +37,7 @@ import (
 	"go.ddosify.com/ddosify/core/report"
 	"go.ddosify.com/ddosify/core/scenario"
 	"go.ddosify.com/ddosify/core/scenario/data"
+	"go.ddosify.com/ddosify/core/tracing"
 	"go.ddosify.com/ddosify/core/types"
 )
 
@@ -60,16 +63,27 @@ type engine struct {
 	asserter    assertion.Asserter
 	resListener assertion.ResultListener
 
+	// exports the spans of the iterations, nil if tracing is disabled
+	tracer *tracing.Tracer
+
 	tickCounter int
 	reqCountArr []int
 	wg          sync.WaitGroup
 
+	// free virtual user slots for the arrival-rate executor, nil means unlimited
+	vuSlots chan struct{}
+
+	// virtual user count per tick and stop channels of the running virtual users for the virtual-user executor
+	vuCountArr []int
+	vus        []chan struct{}
+
 	resultReportChan chan *types.ScenarioResult
 	resultAssertChan chan *types.ScenarioResult
 
 	abortChan   <-chan struct{}
 	testSuccess bool
 	ctx         context.Context
+	cancel      context.CancelFunc
 }
 
 type EngineServices struct {
@@ -85,7 +99,7 @@ var InitEngineServices = func(h types.Hammer) (*EngineServices, error) {
 	// Initialize things here and pass interfaces to NewEngine which it depends ?
 	// this piece can change between implementations
 	as := assertion.NewDefaultAssertionService()
-	as.Init(h.Assertions)
+	as.Init(h.Assertions, h.HistogramPrecision)
 
 	// TODO: remove reflection ?
 	ps, err := proxy.NewProxyService(h.Proxy.Strategy)
@@ -102,6 +116,11 @@ var InitEngineServices = func(h types.Hammer) (*EngineServices, error) {
 	if err != nil {
 		return nil, err
 	}
+	if c, ok := rs.(report.Configurable); ok {
+		if err = c.Configure(h); err != nil {
+			return nil, err
+		}
+	}
 	if err = rs.Init(h.Debug, h.SamplingRate); err != nil {
 		return nil, err
 	}
@@ -124,9 +143,12 @@ func NewEngine(ctx context.Context, h types.Hammer,
 	services *EngineServices) (e *engine, err error) {
 	ss := scenario.NewScenarioService()
 
+	// the report service can stop the test interactively as well
+	ctx, cancel := context.WithCancel(ctx)
 	e = &engine{
 		hammer:          h,
 		ctx:             ctx,
+		cancel:          cancel,
 		proxyService:    services.ProxyServ,
 		scenarioService: ss,
 		reportService:   services.ReportServ,
@@ -154,6 +176,10 @@ func (e *engine) Init() (err error) {
 
 	e.initReqCountArr()
 
+	if e.executor() == types.ExecutorArrivalRate && e.hammer.MaxVUs > 0 {
+		e.vuSlots = make(chan struct{}, e.hammer.MaxVUs)
+	}
+
 	var initialCookies []*http.Cookie
 	if e.hammer.CookiesEnabled && len(e.hammer.Cookies) > 0 {
 		initialCookies, err = createInitialCookies(e.hammer.Cookies)
@@ -162,12 +188,26 @@ func (e *engine) Init() (err error) {
 		}
 	}
 
+	if e.hammer.Tracing.Endpoint != "" {
+		e.tracer = tracing.NewTracer(e.hammer.Tracing)
+	}
+
+	sourceIPs, err := e.hammer.SourceAddr.LocalIPs()
+	if err != nil {
+		return
+	}
+
 	if err = e.scenarioService.Init(e.ctx, e.hammer.Scenario, e.proxyService.GetAll(), scenario.ScenarioOpts{
 		Debug:                  e.hammer.Debug,
 		IterationCount:         e.hammer.IterationCount,
 		MaxConcurrentIterCount: e.getMaxConcurrentIterCount(),
 		EngineMode:             e.hammer.EngineMode,
 		InitialCookies:         initialCookies,
+		ThinkTime:              e.executor() == types.ExecutorVirtualUser,
+		CaptureBody:            e.hammer.ReportDestination == report.OutputTypeExport && e.hammer.Export.CaptureBody,
+		Tracing:                e.tracer != nil,
+		SourceIPs:              sourceIPs,
+		SourceIPRotation:       e.hammer.SourceAddr.Rotation,
 	}); err != nil {
 		return
 	}
@@ -193,17 +233,42 @@ func (e *engine) Start() string {
 	}
 
 	go e.reportService.Start(e.resultReportChan, testResultChan)
+	if e.tracer != nil {
+		e.tracer.Start()
+	}
+
+	var pauseChan <-chan bool
+	finished := make(chan struct{})
+	if c, ok := e.reportService.(report.Controller); ok {
+		pauseChan = c.PauseChan()
+		go func() {
+			select {
+			case <-c.AbortChan():
+				e.cancel()
+			case <-finished:
+			}
+		}()
+	}
 
 	defer func() {
+		close(finished)
 		ticker.Stop()
+		e.scaleVUs(0)
 		e.stop()
+		e.cancel()
 	}()
 
+	tickCount := len(e.reqCountArr)
+	if e.executor() == types.ExecutorVirtualUser {
+		tickCount = len(e.vuCountArr)
+	}
+
 	e.tickCounter = 0
 	e.wg = sync.WaitGroup{}
 	var mutex = &sync.Mutex{}
-	for range ticker.C {
-		if e.tickCounter >= len(e.reqCountArr) {
+	paused := false
+	for tickTime := range ticker.C {
+		if e.tickCounter >= tickCount {
 			return resultDone
 		}
 
@@ -213,10 +278,27 @@ func (e *engine) Start() string {
 		case <-e.abortChan:
 			e.testSuccess = false
 			return resultAborted
+		case paused = <-pauseChan:
+			// ticks are not played while paused, running iterations are completed
+			if paused && e.executor() == types.ExecutorVirtualUser {
+				e.scaleVUs(0)
+			}
 		default:
+			if paused {
+				break
+			}
 			mutex.Lock()
-			e.wg.Add(e.reqCountArr[e.tickCounter])
-			go e.runWorkers(e.tickCounter)
+			switch e.executor() {
+			case types.ExecutorVirtualUser:
+				e.scaleVUs(e.vuCountArr[e.tickCounter])
+			case types.ExecutorArrivalRate:
+				offsets := arrivalOffsets(e.reqCountArr[e.tickCounter], e.hammer.ArrivalDistribution)
+				e.wg.Add(len(offsets))
+				go e.runArrivals(offsets, tickTime)
+			default:
+				e.wg.Add(e.reqCountArr[e.tickCounter])
+				go e.runWorkers(e.tickCounter)
+			}
 			e.tickCounter++
 			mutex.Unlock()
 		}
@@ -224,6 +306,14 @@ func (e *engine) Start() string {
 	return resultDone
 }
 
+// executor returns the iteration scheduling model of the test. Debug mode always runs in burst mode.
+func (e *engine) executor() string {
+	if e.hammer.Debug || e.hammer.Executor == "" {
+		return types.ExecutorBurst
+	}
+	return e.hammer.Executor
+}
+
 func (e *engine) runWorkers(c int) {
 	for i := 1; i <= e.reqCountArr[c]; i++ {
 		scenarioStartTime := time.Now()
@@ -234,14 +324,127 @@ func (e *engine) runWorkers(c int) {
 	}
 }
 
+// runArrivals spreads the iterations of the given tick over the tick interval instead of firing them at once.
+// Start times do not depend on the response times of the previous iterations. If all of the virtual users
+// are busy at the scheduled time, the iteration is dropped and reported as dropped.
+func (e *engine) runArrivals(offsets []time.Duration, tickStart time.Time) {
+	for _, offset := range offsets {
+		scheduledAt := tickStart.Add(offset)
+		time.Sleep(time.Until(scheduledAt))
+
+		if !e.acquireVU() {
+			e.resultReportChan <- &types.ScenarioResult{StartTime: scheduledAt, Dropped: true}
+			e.wg.Done()
+			continue
+		}
+
+		go func(t time.Time) {
+			e.runWorker(t)
+			e.releaseVU()
+			e.wg.Done()
+		}(scheduledAt)
+	}
+}
+
+func (e *engine) acquireVU() bool {
+	if e.vuSlots == nil {
+		return true
+	}
+
+	select {
+	case e.vuSlots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e *engine) releaseVU() {
+	if e.vuSlots != nil {
+		<-e.vuSlots
+	}
+}
+
+// arrivalOffsets returns the start offsets of the iterations in a tick for the given iteration count of the tick.
+// For the poisson distribution the count is the mean count of the tick, and the gaps between the arrivals are
+// exponentially distributed. As the exponential distribution is memoryless, drawing the gaps from the start of
+// each tick makes a poisson process whose rate changes at the tick boundaries.
+func arrivalOffsets(count int, distribution string) []time.Duration {
+	interval := time.Duration(tickerInterval) * time.Millisecond
+
+	if distribution == types.ArrivalDistributionPoisson {
+		var offsets []time.Duration
+		if count == 0 {
+			return offsets
+		}
+		meanGap := float64(interval) / float64(count)
+		for at := rand.ExpFloat64() * meanGap; at < float64(interval); at += rand.ExpFloat64() * meanGap {
+			offsets = append(offsets, time.Duration(at))
+		}
+		return offsets
+	}
+
+	offsets := make([]time.Duration, count)
+	for i := range offsets {
+		offsets[i] = interval * time.Duration(i) / time.Duration(count)
+	}
+	return offsets
+}
+
+// scaleVUs starts or stops virtual users until the given count of them are running.
+// Stopped virtual users finish their ongoing iterations first.
+func (e *engine) scaleVUs(count int) {
+	for len(e.vus) < count {
+		stop := make(chan struct{})
+		e.vus = append(e.vus, stop)
+		e.wg.Add(1)
+		go e.runVU(stop)
+	}
+
+	for len(e.vus) > count {
+		last := len(e.vus) - 1
+		close(e.vus[last])
+		e.vus = e.vus[:last]
+	}
+}
+
+// runVU iterates the scenario back to back until the virtual user is stopped.
+// The virtual user keeps the same client throughout its iterations.
+func (e *engine) runVU(stop <-chan struct{}) {
+	defer e.wg.Done()
+
+	client := e.scenarioService.AcquireClient()
+	defer e.scenarioService.ReleaseClient(client)
+
+	do := func(p *url.URL, startTime time.Time) (*types.ScenarioResult, *types.RequestError) {
+		return e.scenarioService.DoWithClient(p, startTime, client)
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-e.ctx.Done():
+			return
+		default:
+			e.runScenario(time.Now(), do)
+		}
+	}
+}
+
 func (e *engine) runWorker(scenarioStartTime time.Time) {
+	e.runScenario(scenarioStartTime, e.scenarioService.Do)
+}
+
+func (e *engine) runScenario(scenarioStartTime time.Time,
+	do func(*url.URL, time.Time) (*types.ScenarioResult, *types.RequestError)) {
 	var res *types.ScenarioResult
 	var err *types.RequestError
 
 	p := e.proxyService.GetProxy()
 	retryCount := 3
 	for i := 1; i <= retryCount; i++ {
-		res, err = e.scenarioService.Do(p, scenarioStartTime)
+		res, err = do(p, scenarioStartTime)
 
 		if err != nil && err.Type == types.ErrorProxy {
 			p = e.proxyService.ReportProxy(p, err.Reason)
@@ -258,6 +461,9 @@ func (e *engine) runWorker(scenarioStartTime time.Time) {
 	res.Others = make(map[string]interface{})
 	res.Others["hammerOthers"] = e.hammer.Others
 	res.Others["proxyCountry"] = e.proxyService.GetProxyCountry(p)
+	if e.tracer != nil {
+		e.tracer.Export(res)
+	}
 	e.resultReportChan <- res
 
 	if len(e.hammer.Assertions) > 0 {
@@ -271,6 +477,9 @@ func (e *engine) runAssertionsInEngine() bool {
 
 func (e *engine) stop() {
 	e.wg.Wait()
+	if e.tracer != nil {
+		e.tracer.Shutdown()
+	}
 	close(e.resultReportChan)
 	close(e.resultAssertChan)
 	e.proxyService.Done()
@@ -285,8 +494,21 @@ func (e *engine) stop() {
 }
 
 func (e *engine) getMaxConcurrentIterCount() int {
+	if e.vuSlots != nil {
+		// max_vus may leave more room than the iterations of the test can use
+		if cap(e.vuSlots) > e.hammer.IterationCount {
+			return e.hammer.IterationCount
+		}
+		return cap(e.vuSlots)
+	}
+
+	countArr := e.reqCountArr
+	if e.executor() == types.ExecutorVirtualUser {
+		countArr = e.vuCountArr
+	}
+
 	max := 0
-	for _, v := range e.reqCountArr {
+	for _, v := range countArr {
 		if v > max {
 			max = v
 		}
@@ -294,15 +516,36 @@ func (e *engine) getMaxConcurrentIterCount() int {
 	return max
 }
 
+// TickPlan returns the iteration count per tick and, for the virtual-user executor, the virtual user count
+// per tick of the given hammer. The distributed controller splits them across its agents.
+func TickPlan(h types.Hammer) (reqCountArr []int, vuCountArr []int) {
+	e := &engine{hammer: h}
+	e.initReqCountArr()
+	return e.reqCountArr, e.vuCountArr
+}
+
 func (e *engine) initReqCountArr() {
 	if e.hammer.Debug {
 		e.reqCountArr = []int{1}
 		return
 	}
+	if len(e.hammer.ReqCountArr) > 0 || len(e.hammer.VUCountArr) > 0 {
+		// already planned by the distributed controller
+		e.reqCountArr = e.hammer.ReqCountArr
+		e.vuCountArr = e.hammer.VUCountArr
+		e.hammer.IterationCount = arraySum(e.reqCountArr)
+		return
+	}
 	length := int(e.hammer.TestDuration * int(time.Second/(tickerInterval*time.Millisecond)))
+	if e.executor() == types.ExecutorVirtualUser {
+		e.createVUCountArr(length)
+		return
+	}
 	e.reqCountArr = make([]int, length)
 
-	if e.hammer.TimeRunCountMap != nil {
+	if len(e.hammer.LoadStages) > 0 {
+		e.createStagedReqCountArr()
+	} else if e.hammer.TimeRunCountMap != nil {
 		e.createManualReqCountArr()
 	} else {
 		switch e.hammer.LoadType {
@@ -357,32 +600,67 @@ func (e *engine) createIncrementalReqCountArr() {
 }
 
 func (e *engine) createWavedReqCountArr() {
+	steps := createWavedDistArr(e.hammer.IterationCount, e.hammer.TestDuration)
 	tickPerSecond := int(time.Second / (tickerInterval * time.Millisecond))
-	quarterWaveCount := int((math.Log2(float64(e.hammer.TestDuration))))
-	if quarterWaveCount == 0 {
-		quarterWaveCount = 1
+	for i := range steps {
+		tickArrStartIndex := i * tickPerSecond
+		tickArrEndIndex := tickArrStartIndex + tickPerSecond
+		segment := e.reqCountArr[tickArrStartIndex:tickArrEndIndex]
+		createLinearDistArr(steps[i], segment)
 	}
-	qWaveDuration := int(e.hammer.TestDuration / quarterWaveCount)
-	reqCountPerQWave := int(e.hammer.IterationCount / quarterWaveCount)
-	tickArrStartIndex := 0
+}
 
-	for i := 0; i < quarterWaveCount; i++ {
-		if i == quarterWaveCount-1 {
-			// Add remaining req count to the last wave
-			reqCountPerQWave += e.hammer.IterationCount - (reqCountPerQWave * quarterWaveCount)
+// createStagedReqCountArr concatenates the load stages into one request count array.
+// A linear stage holds its rate, an incremental stage ramps from the previous stage's
+// last rate to its rate, and a waved stage waves between zero and its rate.
+func (e *engine) createStagedReqCountArr() {
+	steps := make([]int, 0)
+	prevRate := 0
+	for _, stage := range e.hammer.LoadStages {
+		switch stage.LoadType {
+		case types.LoadTypeIncremental:
+			steps = append(steps, createRampDistArr(prevRate, stage.Rate, stage.Duration)...)
+		case types.LoadTypeWaved:
+			steps = append(steps, createStagedWaveArr(stage.Rate, stage.Duration)...)
+		default:
+			steps = append(steps, createRampDistArr(stage.Rate, stage.Rate, stage.Duration)...)
 		}
+		prevRate = steps[len(steps)-1]
+	}
 
-		steps := createIncrementalDistArr(reqCountPerQWave, qWaveDuration)
-		if i%2 == 1 {
-			reverse(steps)
+	tickPerSecond := int(time.Second / (tickerInterval * time.Millisecond))
+	e.reqCountArr = make([]int, len(steps)*tickPerSecond)
+	for i := range steps {
+		tickArrStartIndex := i * tickPerSecond
+		tickArrEndIndex := tickArrStartIndex + tickPerSecond
+		segment := e.reqCountArr[tickArrStartIndex:tickArrEndIndex]
+		createLinearDistArr(steps[i], segment)
+	}
+
+	e.hammer.TestDuration = len(steps)
+	e.hammer.IterationCount = arraySum(e.reqCountArr)
+}
+
+// createVUCountArr fills the virtual user count for each tick. Without stages, the count is constant.
+// Otherwise, each stage linearly ramps the count from the previous target to its own target.
+func (e *engine) createVUCountArr(length int) {
+	if len(e.hammer.VUStages) == 0 {
+		e.vuCountArr = make([]int, length)
+		for i := range e.vuCountArr {
+			e.vuCountArr[i] = e.hammer.VUs
 		}
+		return
+	}
 
-		for j := range steps {
-			tickArrEndIndex := tickArrStartIndex + tickPerSecond
-			segment := e.reqCountArr[tickArrStartIndex:tickArrEndIndex]
-			createLinearDistArr(steps[j], segment)
-			tickArrStartIndex += tickPerSecond
+	tickPerSecond := int(time.Second / (tickerInterval * time.Millisecond))
+	e.vuCountArr = make([]int, 0, length)
+	prev := e.hammer.VUs
+	for _, stage := range e.hammer.VUStages {
+		stageTicks := stage.Duration * tickPerSecond
+		for i := 1; i <= stageTicks; i++ {
+			e.vuCountArr = append(e.vuCountArr, prev+(stage.Target-prev)*i/stageTicks)
 		}
+		prev = stage.Target
 	}
 }
 
@@ -400,6 +678,72 @@ func createLinearDistArr(count int, arr []int) {
 	}
 }
 
+// createWavedDistArr distributes the count over the seconds of the given duration as quarter waves.
+func createWavedDistArr(count int, duration int) []int {
+	if count == 0 {
+		return make([]int, duration)
+	}
+
+	steps := make([]int, 0, duration)
+	quarterWaveCount, qWaveDuration := quarterWaves(duration)
+	reqCountPerQWave := int(count / quarterWaveCount)
+
+	for i := 0; i < quarterWaveCount; i++ {
+		if i == quarterWaveCount-1 {
+			// Add remaining req count to the last wave
+			reqCountPerQWave += count - (reqCountPerQWave * quarterWaveCount)
+		}
+
+		qWave := createIncrementalDistArr(reqCountPerQWave, qWaveDuration)
+		if i%2 == 1 {
+			reverse(qWave)
+		}
+		steps = append(steps, qWave...)
+	}
+
+	// remaining seconds that do not fit into a quarter wave
+	for len(steps) < duration {
+		steps = append(steps, 0)
+	}
+	return steps
+}
+
+// createStagedWaveArr ramps the per second count between zero and the rate as quarter waves in the given
+// duration. The seconds that do not fit into a quarter wave hold the level of the last quarter wave.
+func createStagedWaveArr(rate int, duration int) []int {
+	steps := make([]int, 0, duration)
+	quarterWaveCount, qWaveDuration := quarterWaves(duration)
+	for i := 0; i < quarterWaveCount; i++ {
+		qWave := createRampDistArr(0, rate, qWaveDuration)
+		if i%2 == 1 {
+			reverse(qWave)
+		}
+		steps = append(steps, qWave...)
+	}
+	for len(steps) < duration {
+		steps = append(steps, steps[len(steps)-1])
+	}
+	return steps
+}
+
+// quarterWaves returns the count and the duration of the quarter waves that fit into the given duration.
+func quarterWaves(duration int) (count int, qWaveDuration int) {
+	count = int(math.Log2(float64(duration)))
+	if count == 0 {
+		count = 1
+	}
+	return count, duration / count
+}
+
+// createRampDistArr linearly changes the per second count from "from" to "to" in the given duration.
+func createRampDistArr(from int, to int, duration int) []int {
+	steps := make([]int, duration)
+	for i := range steps {
+		steps[i] = from + (to-from)*(i+1)/duration
+	}
+	return steps
+}
+
 func createIncrementalDistArr(count int, len int) []int {
 	steps := make([]int, len)
 	sum := (len * (len + 1)) / 2
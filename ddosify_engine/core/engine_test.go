@@ -38,11 +38,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/ddosify/go-faker/faker"
 	"go.ddosify.com/ddosify/config"
+	"go.ddosify.com/ddosify/core/assertion"
 	"go.ddosify.com/ddosify/core/proxy"
 	"go.ddosify.com/ddosify/core/report"
 	"go.ddosify.com/ddosify/core/types"
@@ -574,6 +576,407 @@ func TestEngineResult(t *testing.T) {
 	}
 }
 
+func TestArrivalOffsets(t *testing.T) {
+	t.Parallel()
+
+	interval := time.Duration(tickerInterval) * time.Millisecond
+
+	uniform := arrivalOffsets(4, types.ArrivalDistributionUniform)
+	expected := []time.Duration{0, interval / 4, interval / 2, 3 * interval / 4}
+	if !reflect.DeepEqual(uniform, expected) {
+		t.Errorf("Expected: %v, Found: %v", expected, uniform)
+	}
+
+	ticks, total, varied := 200, 0, false
+	for tick := 0; tick < ticks; tick++ {
+		poisson := arrivalOffsets(100, types.ArrivalDistributionPoisson)
+		total += len(poisson)
+		varied = varied || len(poisson) != 100
+		for i, o := range poisson {
+			if o < 0 || o >= interval {
+				t.Errorf("Offset should be in the tick interval, Found: %v", o)
+			}
+			if i > 0 && o < poisson[i-1] {
+				t.Errorf("Offsets should be sorted, Found: %v", poisson)
+			}
+		}
+	}
+	// the count of a tick is poisson distributed with the mean 100, the total is 20000 +/- 7 standard deviations
+	if total < 19000 || total > 21000 {
+		t.Errorf("Expected about %d arrivals, Found: %d", ticks*100, total)
+	}
+	if !varied {
+		t.Errorf("Arrival counts of the ticks should vary")
+	}
+	if len(arrivalOffsets(0, types.ArrivalDistributionPoisson)) != 0 {
+		t.Errorf("Expected no poisson offsets for zero iterations")
+	}
+
+	if len(arrivalOffsets(0, types.ArrivalDistributionUniform)) != 0 {
+		t.Errorf("Expected no offsets for zero iterations")
+	}
+}
+
+type droppedCountingReport struct {
+	doneChan chan bool
+	dropped  int
+	runs     int
+}
+
+func (r *droppedCountingReport) Init(debug bool, samplingRate int) error {
+	r.doneChan = make(chan bool, 1)
+	return nil
+}
+
+func (r *droppedCountingReport) DoneChan() <-chan bool {
+	return r.doneChan
+}
+
+func (r *droppedCountingReport) Start(input chan *types.ScenarioResult,
+	assertionResultChan <-chan assertion.TestAssertionResult) {
+	for res := range input {
+		if res.Dropped {
+			r.dropped++
+		} else {
+			r.runs++
+		}
+	}
+	r.doneChan <- true
+}
+
+func TestArrivalRateDropsIterations(t *testing.T) {
+	t.Parallel()
+
+	var received int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+		time.Sleep(time.Duration(600) * time.Millisecond)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	h := newDummyHammer()
+	h.Executor = types.ExecutorArrivalRate
+	h.MaxVUs = 2
+	h.IterationCount = 20
+	h.TestDuration = 1
+	h.Scenario.Steps[0].URL = server.URL
+
+	es, err := InitEngineServices(h)
+	if err != nil {
+		t.Errorf("TestArrivalRateDropsIterations error occurred %v", err)
+	}
+	rs := &droppedCountingReport{}
+	rs.Init(false, 0)
+	es.ReportServ = rs
+
+	e, err := NewEngine(context.TODO(), h, es)
+	if err != nil {
+		t.Errorf("TestArrivalRateDropsIterations error occurred %v", err)
+	}
+	if err = e.Init(); err != nil {
+		t.Errorf("TestArrivalRateDropsIterations error occurred %v", err)
+	}
+
+	e.Start()
+
+	if rs.dropped+rs.runs != h.IterationCount {
+		t.Errorf("Expected %d iterations in total, Found dropped: %d, run: %d", h.IterationCount, rs.dropped, rs.runs)
+	}
+	if rs.dropped == 0 {
+		t.Errorf("Expected dropped iterations since max_vus is reached")
+	}
+	if int(atomic.LoadInt32(&received)) != rs.runs {
+		t.Errorf("Expected %d requests, Found: %d", rs.runs, received)
+	}
+}
+
+func TestArrivalRateDistinctUserMaxVUsAboveIterationCount(t *testing.T) {
+	t.Parallel()
+
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+	}))
+	defer server.Close()
+
+	h := newDummyHammer()
+	h.Executor = types.ExecutorArrivalRate
+	h.EngineMode = types.EngineModeDistinctUser
+	h.MaxVUs = 200
+	h.IterationCount = 100
+	h.TestDuration = 1
+	h.Scenario.Steps[0].URL = server.URL
+
+	es, err := InitEngineServices(h)
+	if err != nil {
+		t.Fatalf("TestArrivalRateDistinctUserMaxVUsAboveIterationCount error occurred %v", err)
+	}
+	rs := &droppedCountingReport{}
+	rs.Init(false, 0)
+	es.ReportServ = rs
+
+	e, err := NewEngine(context.TODO(), h, es)
+	if err != nil {
+		t.Fatalf("TestArrivalRateDistinctUserMaxVUsAboveIterationCount error occurred %v", err)
+	}
+	if err = e.Init(); err != nil {
+		t.Fatalf("TestArrivalRateDistinctUserMaxVUsAboveIterationCount error occurred %v", err)
+	}
+
+	e.Start()
+
+	if rs.runs != h.IterationCount || rs.dropped != 0 {
+		t.Errorf("Expected %d iterations to run, Found run: %d, dropped: %d", h.IterationCount, rs.runs, rs.dropped)
+	}
+	if int(atomic.LoadInt32(&received)) != h.IterationCount {
+		t.Errorf("Expected %d requests, Found: %d", h.IterationCount, received)
+	}
+}
+
+func TestStagedReqCountArr(t *testing.T) {
+	t.Parallel()
+
+	h := newDummyHammer()
+	h.LoadStages = types.LoadStages{
+		{Duration: 2, LoadType: types.LoadTypeIncremental, Rate: 20},
+		{Duration: 1, LoadType: types.LoadTypeLinear, Rate: 20},
+		{Duration: 1, LoadType: types.LoadTypeLinear, Rate: 50},
+		{Duration: 1, LoadType: types.LoadTypeIncremental, Rate: 0},
+	}
+
+	e := &engine{hammer: h}
+	e.initReqCountArr()
+
+	expected := []int{
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, // ramp 0 -> 20, 10 per second
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 2, // ramp 0 -> 20, 20 per second
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 2, // hold 20 per second
+		5, 5, 5, 5, 5, 5, 5, 5, 5, 5, // spike to 50 per second
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // ramp down to 0
+	}
+	if !reflect.DeepEqual(e.reqCountArr, expected) {
+		t.Errorf("Expected: %v, Found: %v", expected, e.reqCountArr)
+	}
+	if e.hammer.IterationCount != 100 || e.hammer.TestDuration != 5 {
+		t.Errorf("Expected iteration count: 100, duration: 5, Found: %d, %d",
+			e.hammer.IterationCount, e.hammer.TestDuration)
+	}
+
+	// waved stage peaks at its rate, the next stage ramps from the level of the last quarter wave
+	h.LoadStages = types.LoadStages{
+		{Duration: 5, LoadType: types.LoadTypeWaved, Rate: 20},
+		{Duration: 2, LoadType: types.LoadTypeIncremental, Rate: 30},
+	}
+	e = &engine{hammer: h}
+	e.initReqCountArr()
+
+	perSecond := make([]int, 0)
+	for i := 0; i < len(e.reqCountArr); i += 10 {
+		perSecond = append(perSecond, arraySum(e.reqCountArr[i:i+10]))
+	}
+	expectedPerSecond := []int{
+		10, 20, // quarter wave up to the peak
+		20, 10, // quarter wave down
+		10,     // tail of the waves holds the last level
+		20, 30, // ramp 10 -> 30
+	}
+	if !reflect.DeepEqual(perSecond, expectedPerSecond) {
+		t.Errorf("Expected per second: %v, Found: %v", expectedPerSecond, perSecond)
+	}
+	if e.hammer.IterationCount != 120 || e.hammer.TestDuration != 7 {
+		t.Errorf("Expected iteration count: 120, duration: 7, Found: %d, %d",
+			e.hammer.IterationCount, e.hammer.TestDuration)
+	}
+}
+
+func TestPlannedReqCountArr(t *testing.T) {
+	t.Parallel()
+
+	h := newDummyHammer()
+	h.IterationCount = 1000
+	h.TestDuration = 10
+	h.ReqCountArr = []int{1, 0, 2, 3}
+
+	reqCountArr, _ := TickPlan(h)
+	if !reflect.DeepEqual(reqCountArr, h.ReqCountArr) {
+		t.Errorf("Expected: %v, Found: %v", h.ReqCountArr, reqCountArr)
+	}
+
+	e := &engine{hammer: h}
+	e.initReqCountArr()
+	if e.hammer.IterationCount != 6 {
+		t.Errorf("Expected iteration count: 6, Found: %d", e.hammer.IterationCount)
+	}
+}
+
+func TestVUCountArr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		duration int
+		vus      int
+		stages   types.VUStages
+		expected []int
+	}{
+		{"Fixed", 1, 3, nil, []int{3, 3, 3, 3, 3, 3, 3, 3, 3, 3}},
+		{"RampUpDown", 3, 0, types.VUStages{{Duration: 1, Target: 10}, {Duration: 1, Target: 10}, {Duration: 1, Target: 0}},
+			[]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0}},
+		{"StartVUs", 1, 5, types.VUStages{{Duration: 1, Target: 15}},
+			[]int{6, 7, 8, 9, 10, 11, 12, 13, 14, 15}},
+	}
+
+	for _, tc := range tests {
+		test := tc
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := newDummyHammer()
+			h.Executor = types.ExecutorVirtualUser
+			h.TestDuration = test.duration
+			h.VUs = test.vus
+			h.VUStages = test.stages
+
+			e := &engine{hammer: h}
+			e.initReqCountArr()
+
+			if !reflect.DeepEqual(e.vuCountArr, test.expected) {
+				t.Errorf("Expected: %v, Found: %v", test.expected, e.vuCountArr)
+			}
+		})
+	}
+}
+
+func TestVirtualUserConcurrency(t *testing.T) {
+	t.Parallel()
+
+	var m sync.Mutex
+	var inFlight, maxInFlight, received int
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		inFlight++
+		received++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		m.Unlock()
+
+		time.Sleep(time.Duration(100) * time.Millisecond)
+
+		m.Lock()
+		inFlight--
+		m.Unlock()
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	h := newDummyHammer()
+	h.Executor = types.ExecutorVirtualUser
+	h.EngineMode = types.EngineModeRepeatedUser
+	h.VUs = 3
+	h.TestDuration = 1
+	h.Scenario.Steps[0].URL = server.URL
+	h.Scenario.Steps[0].Sleep = "100"
+
+	es, err := InitEngineServices(h)
+	if err != nil {
+		t.Errorf("TestVirtualUserConcurrency error occurred %v", err)
+	}
+	e, err := NewEngine(context.TODO(), h, es)
+	if err != nil {
+		t.Errorf("TestVirtualUserConcurrency error occurred %v", err)
+	}
+	if err = e.Init(); err != nil {
+		t.Errorf("TestVirtualUserConcurrency error occurred %v", err)
+	}
+
+	e.Start()
+
+	m.Lock()
+	defer m.Unlock()
+	if maxInFlight != h.VUs {
+		t.Errorf("Expected max concurrency: %d, Found: %d", h.VUs, maxInFlight)
+	}
+	// each virtual user runs an iteration in ~200ms (100ms response + 100ms think time) in a second
+	if received < h.VUs*3 || received > h.VUs*6 {
+		t.Errorf("Unexpected iteration count for %d virtual users, Found: %d", h.VUs, received)
+	}
+}
+
+// controlledReport is a report service that pauses and aborts the test like the tui output.
+type controlledReport struct {
+	report.ReportService
+	pause chan bool
+	abort chan struct{}
+}
+
+func (c *controlledReport) PauseChan() <-chan bool {
+	return c.pause
+}
+
+func (c *controlledReport) AbortChan() <-chan struct{} {
+	return c.abort
+}
+
+func TestReportServiceControlsTheRun(t *testing.T) {
+	t.Parallel()
+
+	var received int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&received, 1)
+	}))
+	defer server.Close()
+
+	run := func(h types.Hammer, control func(c *controlledReport)) string {
+		es, err := InitEngineServices(h)
+		if err != nil {
+			t.Fatalf("TestReportServiceControlsTheRun error occurred %v", err)
+		}
+		c := &controlledReport{ReportService: es.ReportServ, pause: make(chan bool, 1), abort: make(chan struct{})}
+		es.ReportServ = c
+		e, err := NewEngine(context.TODO(), h, es)
+		if err != nil {
+			t.Fatalf("TestReportServiceControlsTheRun error occurred %v", err)
+		}
+		if err = e.Init(); err != nil {
+			t.Fatalf("TestReportServiceControlsTheRun error occurred %v", err)
+		}
+
+		result := make(chan string, 1)
+		go func() { result <- e.Start() }()
+		control(c)
+		return <-result
+	}
+
+	h := newDummyHammer()
+	h.IterationCount = 10
+	h.Scenario.Steps[0].URL = server.URL
+
+	// paused before the first tick, no iteration is started until resumed
+	res := run(h, func(c *controlledReport) {
+		c.pause <- true
+		time.Sleep(500 * time.Millisecond)
+		if r := atomic.LoadInt64(&received); r != 0 {
+			t.Errorf("Expected no request while paused, Found: %d", r)
+		}
+		c.pause <- false
+	})
+	if res != resultDone || atomic.LoadInt64(&received) != 10 {
+		t.Errorf("Expected %s with 10 requests after resume, Found: %s with %d", resultDone, res, received)
+	}
+
+	h.TestDuration = 30
+	start := time.Now()
+	res = run(h, func(c *controlledReport) {
+		time.Sleep(300 * time.Millisecond)
+		close(c.abort)
+	})
+	if res != resultStopped || time.Since(start) > 5*time.Second {
+		t.Errorf("Expected the test to be stopped by the abort, Found: %s in %v", res, time.Since(start))
+	}
+}
+
 func TestDynamicData(t *testing.T) {
 	t.Parallel()
 
@@ -23,11 +23,27 @@ package proxy
 import (
 	"fmt"
 	"net/url"
+	"os"
 	"reflect"
 )
 
 var AvailableProxyServices = make(map[string]ProxyService)
 
+// Env vars of the proxy credentials. They are used for the proxies that have no credentials in their addresses,
+// so that the credentials are not given on the command line.
+const (
+	ProxyUsernameEnv = "DDOSIFY_PROXY_USERNAME"
+	ProxyPasswordEnv = "DDOSIFY_PROXY_PASSWORD"
+)
+
+// Supported schemes of the proxy addresses. Credentials are sent as Proxy-Authorization to the http(s) proxies,
+// and with the username/password authentication of socks5. Target hostnames are resolved by the proxy for both
+// socks5 and socks5h.
+var supportedSchemes = map[string]bool{"http": true, "https": true, "socks5": true, "socks5h": true}
+
+// Schemes of the proxy addresses that were accepted before, and the schemes to use instead of them.
+var replacedSchemes = map[string]string{"socks": "socks5"}
+
 // Proxy struct is used for initializing the ProxyService implementations.
 type Proxy struct {
 	// Stragy of the proxy usage.
@@ -62,3 +78,31 @@ func NewProxyService(s string) (service ProxyService, err error) {
 
 	return
 }
+
+// prepareAddr validates the proxy address. It returns the address itself, or a copy of it with the credentials
+// in the env vars if the address has no credentials.
+func prepareAddr(addr *url.URL) (*url.URL, error) {
+	if scheme, ok := replacedSchemes[addr.Scheme]; ok {
+		return nil, fmt.Errorf("proxy scheme %s is not supported anymore, use %s://%s instead",
+			addr.Scheme, scheme, addr.Host)
+	}
+	if !supportedSchemes[addr.Scheme] {
+		return nil, fmt.Errorf("unsupported proxy scheme: %s, supported schemes are http, https, socks5 and socks5h",
+			addr.Scheme)
+	}
+	if addr.Host == "" {
+		return nil, fmt.Errorf("invalid proxy address: %s", addr.Redacted())
+	}
+
+	username, ok := os.LookupEnv(ProxyUsernameEnv)
+	if !ok || addr.User != nil {
+		return addr, nil
+	}
+	withCreds := *addr
+	if password, ok := os.LookupEnv(ProxyPasswordEnv); ok {
+		withCreds.User = url.UserPassword(username, password)
+	} else {
+		withCreds.User = url.User(username)
+	}
+	return &withCreds, nil
+}
@@ -0,0 +1,295 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ProxyTypePool = "pool"
+
+// PoolConfKey is the key of the PoolConf in the Others field of the Proxy.
+const PoolConfKey = "pool"
+
+// Rotations of the proxy pool.
+const (
+	PoolRotationRoundRobin = "round_robin"
+	PoolRotationWeighted   = "weighted"
+	PoolRotationRandom     = "random"
+)
+
+const (
+	DefaultPoolMaxFails    = 3
+	DefaultPoolFailTimeout = 10 * time.Second
+)
+
+func init() {
+	AvailableProxyServices[ProxyTypePool] = &poolProxyStrategy{}
+}
+
+// PoolConf is the configuration of the pool strategy.
+type PoolConf struct {
+	// Rotation of the proxies, round_robin if it is empty.
+	Rotation string
+
+	// Proxies of the pool, the proxies in the File are appended to them.
+	Proxies []PoolProxy
+
+	// File that has a proxy address on each line. An address can be followed by a weight and a country,
+	// e.g. "http://10.0.0.1:3128 3 DE". Empty lines and the lines starting with # are ignored.
+	File string
+
+	// A proxy is quarantined for FailTimeout after MaxFails proxy errors in FailTimeout.
+	MaxFails    int
+	FailTimeout time.Duration
+}
+
+// PoolProxy is a proxy of the pool.
+type PoolProxy struct {
+	Addr    *url.URL
+	Weight  int // 1 if it is zero
+	Country string
+}
+
+type pooledProxy struct {
+	PoolProxy
+
+	// current weight of the smooth weighted round robin
+	currentWeight int
+
+	failCount        int
+	firstFailAt      time.Time
+	quarantinedUntil time.Time
+}
+
+// poolProxyStrategy rotates the proxies of a pool and quarantines the failing ones.
+type poolProxyStrategy struct {
+	mu          sync.Mutex
+	rotation    string
+	proxies     []*pooledProxy
+	byAddr      map[*url.URL]*pooledProxy
+	next        int
+	maxFails    int
+	failTimeout time.Duration
+	rand        *rand.Rand
+	now         func() time.Time
+}
+
+func (pp *poolProxyStrategy) Init(p Proxy) error {
+	conf, ok := p.Others[PoolConfKey].(PoolConf)
+	if !ok {
+		return fmt.Errorf("proxy pool is not configured")
+	}
+
+	pp.rotation = conf.Rotation
+	if pp.rotation == "" {
+		pp.rotation = PoolRotationRoundRobin
+	}
+	if pp.rotation != PoolRotationRoundRobin && pp.rotation != PoolRotationWeighted && pp.rotation != PoolRotationRandom {
+		return fmt.Errorf("unsupported rotation of proxy pool: %s", pp.rotation)
+	}
+	pp.maxFails = conf.MaxFails
+	if pp.maxFails <= 0 {
+		pp.maxFails = DefaultPoolMaxFails
+	}
+	pp.failTimeout = conf.FailTimeout
+	if pp.failTimeout <= 0 {
+		pp.failTimeout = DefaultPoolFailTimeout
+	}
+
+	proxies := conf.Proxies
+	if conf.File != "" {
+		fileProxies, err := readPoolFile(conf.File)
+		if err != nil {
+			return err
+		}
+		proxies = append(proxies, fileProxies...)
+	}
+	if len(proxies) == 0 {
+		return fmt.Errorf("proxy pool is empty")
+	}
+
+	pp.byAddr = make(map[*url.URL]*pooledProxy, len(proxies))
+	for _, p := range proxies {
+		if p.Addr == nil {
+			return fmt.Errorf("address of pool proxy is missing")
+		}
+		addr, err := prepareAddr(p.Addr)
+		if err != nil {
+			return err
+		}
+		p.Addr = addr
+		if p.Weight < 0 {
+			return fmt.Errorf("weight of pool proxy %s is negative", p.Addr.Redacted())
+		}
+		if p.Weight == 0 {
+			p.Weight = 1
+		}
+		pooled := &pooledProxy{PoolProxy: p}
+		pp.proxies = append(pp.proxies, pooled)
+		pp.byAddr[p.Addr] = pooled
+	}
+
+	pp.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	pp.now = time.Now
+	return nil
+}
+
+// readPoolFile reads the proxies of the pool from the file.
+func readPoolFile(path string) ([]PoolProxy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("proxy pool file could not be read: %v", err)
+	}
+	defer f.Close()
+
+	var proxies []PoolProxy
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) > 3 {
+			return nil, fmt.Errorf("invalid proxy at line %d of %s", line, path)
+		}
+
+		addr, err := url.Parse(fields[0])
+		if err != nil || addr.Host == "" {
+			return nil, fmt.Errorf("invalid proxy address at line %d of %s", line, path)
+		}
+		p := PoolProxy{Addr: addr}
+		for _, f := range fields[1:] {
+			if w, err := strconv.Atoi(f); err == nil {
+				p.Weight = w
+			} else {
+				p.Country = f
+			}
+		}
+		proxies = append(proxies, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("proxy pool file could not be read: %v", err)
+	}
+	return proxies, nil
+}
+
+func (pp *poolProxyStrategy) GetAll() []*url.URL {
+	addrs := make([]*url.URL, 0, len(pp.proxies))
+	for _, p := range pp.proxies {
+		addrs = append(addrs, p.Addr)
+	}
+	return addrs
+}
+
+// GetProxy returns the next healthy proxy of the rotation.
+// If all the proxies are quarantined, the proxy that leaves the quarantine first is returned.
+func (pp *poolProxyStrategy) GetProxy() *url.URL {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	return pp.pick().Addr
+}
+
+// ReportProxy counts the proxy error of the proxy and returns the proxy to retry with.
+func (pp *poolProxyStrategy) ReportProxy(addr *url.URL, reason string) *url.URL {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	if p, ok := pp.byAddr[addr]; ok {
+		now := pp.now()
+		if p.failCount == 0 || now.Sub(p.firstFailAt) > pp.failTimeout {
+			p.failCount = 0
+			p.firstFailAt = now
+		}
+		p.failCount++
+		if p.failCount >= pp.maxFails {
+			p.quarantinedUntil = now.Add(pp.failTimeout)
+			p.failCount = 0
+		}
+	}
+	return pp.pick().Addr
+}
+
+func (pp *poolProxyStrategy) GetProxyCountry(addr *url.URL) string {
+	if p, ok := pp.byAddr[addr]; ok && p.Country != "" {
+		return p.Country
+	}
+	return "unknown"
+}
+
+func (pp *poolProxyStrategy) Done() error {
+	return nil
+}
+
+// pick returns the next proxy of the rotation, mu must be held.
+func (pp *poolProxyStrategy) pick() *pooledProxy {
+	now := pp.now()
+	healthy := make([]*pooledProxy, 0, len(pp.proxies))
+	for _, p := range pp.proxies {
+		if !now.Before(p.quarantinedUntil) {
+			healthy = append(healthy, p)
+		}
+	}
+	if len(healthy) == 0 {
+		first := pp.proxies[0]
+		for _, p := range pp.proxies[1:] {
+			if p.quarantinedUntil.Before(first.quarantinedUntil) {
+				first = p
+			}
+		}
+		return first
+	}
+
+	switch pp.rotation {
+	case PoolRotationWeighted:
+		// smooth weighted round robin, proxies are picked in proportion to their weights without bursts
+		var best *pooledProxy
+		total := 0
+		for _, p := range healthy {
+			p.currentWeight += p.Weight
+			total += p.Weight
+			if best == nil || p.currentWeight > best.currentWeight {
+				best = p
+			}
+		}
+		best.currentWeight -= total
+		return best
+	case PoolRotationRandom:
+		return healthy[pp.rand.Intn(len(healthy))]
+	default:
+		for i := 0; i < len(pp.proxies); i++ {
+			p := pp.proxies[(pp.next+i)%len(pp.proxies)]
+			if !now.Before(p.quarantinedUntil) {
+				pp.next = (pp.next + i + 1) % len(pp.proxies)
+				return p
+			}
+		}
+		return healthy[0]
+	}
+}
@@ -0,0 +1,178 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package proxy
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestPool(t *testing.T, rotation string, weights ...int) (*poolProxyStrategy, []*url.URL, *time.Time) {
+	t.Helper()
+	conf := PoolConf{Rotation: rotation, MaxFails: 2, FailTimeout: 10 * time.Second}
+	addrs := make([]*url.URL, 0, len(weights))
+	for i, w := range weights {
+		addr, _ := url.Parse("http://10.0.0." + string(rune('1'+i)) + ":3128")
+		addrs = append(addrs, addr)
+		conf.Proxies = append(conf.Proxies, PoolProxy{Addr: addr, Weight: w})
+	}
+
+	pp := &poolProxyStrategy{}
+	if err := pp.Init(Proxy{Strategy: ProxyTypePool, Others: map[string]interface{}{PoolConfKey: conf}}); err != nil {
+		t.Fatalf("Init errored %v", err)
+	}
+	now := time.Now()
+	pp.now = func() time.Time { return now }
+	return pp, addrs, &now
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	pp, addrs, _ := newTestPool(t, "", 1, 1, 1)
+
+	for i := 0; i < 6; i++ {
+		if p := pp.GetProxy(); p != addrs[i%3] {
+			t.Errorf("Expected %s at %d, Found: %s", addrs[i%3], i, p)
+		}
+	}
+}
+
+func TestPoolWeighted(t *testing.T) {
+	pp, addrs, _ := newTestPool(t, PoolRotationWeighted, 3, 1, 0)
+
+	counts := map[*url.URL]int{}
+	for i := 0; i < 50; i++ {
+		counts[pp.GetProxy()]++
+	}
+	// zero weight is counted as 1
+	if counts[addrs[0]] != 30 || counts[addrs[1]] != 10 || counts[addrs[2]] != 10 {
+		t.Errorf("Proxies should be picked in proportion to their weights, Found: %v", counts)
+	}
+}
+
+func TestPoolRandom(t *testing.T) {
+	pp, addrs, _ := newTestPool(t, PoolRotationRandom, 1, 1)
+
+	counts := map[*url.URL]int{}
+	for i := 0; i < 100; i++ {
+		counts[pp.GetProxy()]++
+	}
+	if counts[addrs[0]] == 0 || counts[addrs[1]] == 0 || counts[addrs[0]]+counts[addrs[1]] != 100 {
+		t.Errorf("Both proxies should be picked, Found: %v", counts)
+	}
+}
+
+func TestPoolQuarantine(t *testing.T) {
+	pp, addrs, now := newTestPool(t, PoolRotationRoundRobin, 1, 1)
+
+	// failures out of the fail timeout are not counted together
+	pp.ReportProxy(addrs[0], "proxy error")
+	*now = now.Add(11 * time.Second)
+	if p := pp.ReportProxy(addrs[0], "proxy error"); p == nil {
+		t.Fatalf("ReportProxy should return a proxy to retry")
+	}
+	if pp.proxies[0].quarantinedUntil.After(*now) {
+		t.Errorf("Proxy should not be quarantined before max fails")
+	}
+
+	if p := pp.ReportProxy(addrs[0], "proxy error"); p != addrs[1] {
+		t.Errorf("Retry proxy should be the healthy one, Found: %s", p)
+	}
+	for i := 0; i < 4; i++ {
+		if p := pp.GetProxy(); p != addrs[1] {
+			t.Errorf("Quarantined proxy should be skipped, Found: %s", p)
+		}
+	}
+
+	// all quarantined, the one leaving the quarantine first is used
+	*now = now.Add(time.Second)
+	pp.ReportProxy(addrs[1], "proxy error")
+	pp.ReportProxy(addrs[1], "proxy error")
+	if p := pp.GetProxy(); p != addrs[0] {
+		t.Errorf("Expected %s when all proxies are quarantined, Found: %s", addrs[0], p)
+	}
+
+	*now = now.Add(9500 * time.Millisecond)
+	seen := map[*url.URL]bool{}
+	for i := 0; i < 2; i++ {
+		seen[pp.GetProxy()] = true
+	}
+	if !seen[addrs[0]] || seen[addrs[1]] {
+		t.Errorf("Only the first proxy should leave the quarantine, Found: %v", seen)
+	}
+}
+
+func TestPoolFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proxies.txt")
+	content := "# egress proxies\n\nhttp://10.0.1.1:3128 2 US\nsocks5://10.0.1.2:1080 JP\nhttp://10.0.1.3:3128\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	addr, _ := url.Parse("http://10.0.0.1:3128")
+
+	pp := &poolProxyStrategy{}
+	err := pp.Init(Proxy{Strategy: ProxyTypePool, Others: map[string]interface{}{
+		PoolConfKey: PoolConf{File: path, Proxies: []PoolProxy{{Addr: addr, Country: "DE"}}},
+	}})
+	if err != nil {
+		t.Fatalf("Init errored %v", err)
+	}
+
+	all := pp.GetAll()
+	if len(all) != 4 || all[0] != addr || all[1].String() != "http://10.0.1.1:3128" {
+		t.Fatalf("Unexpected proxies: %v", all)
+	}
+	if pp.proxies[1].Weight != 2 || pp.proxies[3].Weight != 1 {
+		t.Errorf("Unexpected weights: %d, %d", pp.proxies[1].Weight, pp.proxies[3].Weight)
+	}
+	for i, c := range []string{"DE", "US", "JP", "unknown"} {
+		if country := pp.GetProxyCountry(all[i]); country != c {
+			t.Errorf("Expected country %s of %s, Found: %s", c, all[i], country)
+		}
+	}
+	if country := pp.GetProxyCountry(nil); country != "unknown" {
+		t.Errorf("Expected unknown country, Found: %s", country)
+	}
+}
+
+func TestPoolInitErrors(t *testing.T) {
+	addr, _ := url.Parse("http://10.0.0.1:3128")
+	invalidFile := filepath.Join(t.TempDir(), "proxies.txt")
+	_ = os.WriteFile(invalidFile, []byte("10.0.0.1:3128\n"), 0644)
+
+	tests := map[string]map[string]interface{}{
+		"NotConfigured":   nil,
+		"Empty":           {PoolConfKey: PoolConf{}},
+		"InvalidRotation": {PoolConfKey: PoolConf{Rotation: "sticky", Proxies: []PoolProxy{{Addr: addr}}}},
+		"NegativeWeight":  {PoolConfKey: PoolConf{Proxies: []PoolProxy{{Addr: addr, Weight: -1}}}},
+		"MissingFile":     {PoolConfKey: PoolConf{File: "not_found.txt"}},
+		"InvalidFile":     {PoolConfKey: PoolConf{File: invalidFile}},
+	}
+
+	for name, others := range tests {
+		pp := &poolProxyStrategy{}
+		if err := pp.Init(Proxy{Strategy: ProxyTypePool, Others: others}); err == nil {
+			t.Errorf("%s should be errored", name)
+		}
+	}
+}
@@ -34,9 +34,11 @@ type singleProxyStrategy struct {
 	proxyAddr *url.URL
 }
 
-func (sp *singleProxyStrategy) Init(p Proxy) error {
-	sp.proxyAddr = p.Addr
-	return nil
+func (sp *singleProxyStrategy) Init(p Proxy) (err error) {
+	if p.Addr != nil {
+		sp.proxyAddr, err = prepareAddr(p.Addr)
+	}
+	return
 }
 
 // Since there is a 1 proxy, return that always
@@ -26,9 +26,15 @@ import (
 
 	"go.ddosify.com/ddosify/core/assertion"
 	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/util"
 )
 
 func aggregate(result *Result, scr *types.ScenarioResult, samplingCount map[uint16]map[string]int, samplingRate int) {
+	if scr.Dropped {
+		result.DroppedCount++
+		return
+	}
+
 	var scenarioDuration float32
 	errOccured := false
 	assertionFail := false
@@ -50,12 +56,14 @@ func aggregate(result *Result, scr *types.ScenarioResult, samplingCount map[uint
 		}
 		stepResult := result.StepResults[sr.StepID]
 
+		stepResult.addCounts(sr.Custom)
+
 		if len(sr.FailedAssertions) > 0 { // assertion error
 			errOccured = true
 			assertionFail = true
 			stepResult.Fail.Count++
 			stepResult.Fail.AssertionErrorDist.Count++
-			stepResult.StatusCodeDist[sr.StatusCode]++
+			stepResult.addStatus(sr)
 			for _, fa := range sr.FailedAssertions {
 				if aed, ok := stepResult.Fail.AssertionErrorDist.Conditions[fa.Rule]; !ok {
 					samplingCount[sr.StepID] = make(map[string]int)
@@ -93,10 +101,17 @@ func aggregate(result *Result, scr *types.ScenarioResult, samplingCount map[uint
 			}
 			totalDur := float32(stepResult.SuccessCount+stepResult.Fail.Count-1)*stepResult.Durations["duration"] + float32(sr.Duration.Seconds())
 			stepResult.Durations["duration"] = totalDur / float32(stepResult.SuccessCount+stepResult.Fail.Count)
+			stepResult.recordDuration("duration", sr.Duration, result.histogramPrecision)
 			for k, v := range sr.Custom {
-				if strings.Contains(k, "Duration") {
-					totalDur := float32(stepResult.SuccessCount+stepResult.Fail.Count-1)*stepResult.Durations[k] + float32(v.(time.Duration).Seconds())
+				d, isDur := v.(time.Duration)
+				if perFetchDurations[k] && isDur {
+					stepResult.recordFetchDuration(k, d, result.histogramPrecision)
+				} else if strings.Contains(k, "Duration") && isDur {
+					totalDur := float32(stepResult.SuccessCount+stepResult.Fail.Count-1)*stepResult.Durations[k] + float32(d.Seconds())
 					stepResult.Durations[k] = float32(totalDur / float32(stepResult.SuccessCount+stepResult.Fail.Count))
+					stepResult.recordDuration(k, d, result.histogramPrecision)
+				} else if r, ok := v.(float64); ok && strings.HasSuffix(k, "PerSecond") {
+					stepResult.addRate(k, r, stepResult.SuccessCount+stepResult.Fail.Count)
 				}
 			}
 		} else if sr.Err.Type != "" { // server error
@@ -105,21 +120,30 @@ func aggregate(result *Result, scr *types.ScenarioResult, samplingCount map[uint
 			stepResult.Fail.ServerErrorDist.Count++
 			stepResult.Fail.ServerErrorDist.Reasons[sr.Err.Reason]++
 		} else { // success
-			stepResult.StatusCodeDist[sr.StatusCode]++
+			stepResult.addStatus(sr)
 			stepResult.SuccessCount++
 
 			totalDur := float32(stepResult.SuccessCount+stepResult.Fail.Count-1)*stepResult.Durations["duration"] + float32(sr.Duration.Seconds())
 			stepResult.Durations["duration"] = totalDur / float32(stepResult.SuccessCount+stepResult.Fail.Count)
+			stepResult.recordDuration("duration", sr.Duration, result.histogramPrecision)
 			for k, v := range sr.Custom {
-				if strings.Contains(k, "Duration") {
-					totalDur := float32(stepResult.SuccessCount-1)*stepResult.Durations[k] + float32(v.(time.Duration).Seconds())
+				d, isDur := v.(time.Duration)
+				if perFetchDurations[k] && isDur {
+					stepResult.recordFetchDuration(k, d, result.histogramPrecision)
+				} else if strings.Contains(k, "Duration") && isDur {
+					totalDur := float32(stepResult.SuccessCount-1)*stepResult.Durations[k] + float32(d.Seconds())
 					stepResult.Durations[k] = float32(totalDur / float32(stepResult.SuccessCount+stepResult.Fail.Count))
+					stepResult.recordDuration(k, d, result.histogramPrecision)
+				} else if r, ok := v.(float64); ok && strings.HasSuffix(k, "PerSecond") {
+					stepResult.addRate(k, r, stepResult.SuccessCount+stepResult.Fail.Count)
 				}
 			}
 		}
 
 	}
 
+	result.aggregateProxy(scr, errOccured, scenarioDuration)
+
 	// Don't change avg duration if there is a error
 	if !errOccured {
 		totalDuration := float32(result.SuccessCount)*result.AvgDuration + scenarioDuration
@@ -142,7 +166,63 @@ type Result struct {
 	ServerFailedCount    int64                                 `json:"server_fail_count"`
 	AssertionFailCount   int64                                 `json:"assertion_fail_count"`
 	AvgDuration          float32                               `json:"avg_duration"`
+	DroppedCount         int64                                 `json:"dropped_iteration_count,omitempty"`
 	StepResults          map[uint16]*ScenarioStepResultSummary `json:"steps"`
+	Buckets              []*ResultBucket                       `json:"buckets,omitempty"`
+	ProxyResults         map[string]*ProxyResultSummary        `json:"proxies,omitempty"`
+	CountryResults       map[string]*ProxyResultSummary        `json:"proxy_countries,omitempty"`
+
+	// significant digits of the duration histograms of the steps
+	histogramPrecision int
+}
+
+// computePercentiles sets the percentiles of the steps, the proxies and the proxy countries from their duration histograms.
+func (r *Result) computePercentiles() {
+	for _, s := range r.StepResults {
+		s.Percentiles = s.percentiles()
+	}
+	for _, p := range r.ProxyResults {
+		p.Percentiles = p.percentiles()
+	}
+	for _, c := range r.CountryResults {
+		c.Percentiles = c.percentiles()
+	}
+}
+
+// aggregateProxy adds the iteration to the results of the proxy it is sent through and of the country of the proxy.
+func (r *Result) aggregateProxy(scr *types.ScenarioResult, errOccured bool, scenarioDuration float32) {
+	country, _ := scr.Others["proxyCountry"].(string)
+
+	if scr.ProxyAddr != nil {
+		if r.ProxyResults == nil {
+			r.ProxyResults = map[string]*ProxyResultSummary{}
+		}
+		addr := scr.ProxyAddr.Redacted()
+		p, ok := r.ProxyResults[addr]
+		if !ok {
+			p = &ProxyResultSummary{Country: country}
+			r.ProxyResults[addr] = p
+		}
+		p.add(scr, errOccured, scenarioDuration, r.histogramPrecision)
+	}
+
+	if country != "" {
+		if r.CountryResults == nil {
+			r.CountryResults = map[string]*ProxyResultSummary{}
+		}
+		c, ok := r.CountryResults[country]
+		if !ok {
+			c = &ProxyResultSummary{}
+			r.CountryResults[country] = c
+		}
+		c.add(scr, errOccured, scenarioDuration, r.histogramPrecision)
+	}
+}
+
+// multipleCountries returns whether the iterations are sent through the proxies of more than one country.
+// The results of the countries are only reported in this case.
+func (r *Result) multipleCountries() bool {
+	return len(r.CountryResults) > 1
 }
 
 func (r *Result) successPercentage() int {
@@ -179,9 +259,124 @@ type FailVerbose struct {
 type ScenarioStepResultSummary struct {
 	Name           string             `json:"name"`
 	StatusCodeDist map[int]int        `json:"status_code_dist"`
+	GrpcStatusDist map[string]int     `json:"grpc_status_dist,omitempty"`
 	Fail           FailVerbose        `json:"fail"`
 	Durations      map[string]float32 `json:"durations"`
+	Frames         map[string]int64   `json:"frames,omitempty"`
+	Counts         map[string]int64   `json:"counts,omitempty"`
+	Rates          map[string]float32 `json:"rates,omitempty"`
 	SuccessCount   int64              `json:"success_count"`
+
+	// Percentiles of the durations in seconds, duration key -> percentile key -> value.
+	Percentiles map[string]map[string]float32 `json:"percentiles,omitempty"`
+
+	// duration key -> histogram of the durations in microseconds
+	histograms map[string]*util.Histogram
+}
+
+// percentileKeys are the reported percentiles of the duration histograms.
+var percentileKeys = []struct {
+	key        string
+	percentile float64
+}{
+	{"p50", 50}, {"p90", 90}, {"p95", 95}, {"p99", 99}, {"p99.9", 99.9}, {"max", 100},
+}
+
+// grpcStatus returns the status name of the result of a gRPC step. The StatusCode of the result is the
+// gRPC status code then, so it is not counted as an HTTP status code.
+func grpcStatus(sr *types.ScenarioStepResult) (string, bool) {
+	status, ok := sr.Custom["grpcStatus"].(string)
+	return status, ok
+}
+
+// addStatus counts the HTTP status code or the gRPC status of the step result.
+func (s *ScenarioStepResultSummary) addStatus(sr *types.ScenarioStepResult) {
+	if status, ok := grpcStatus(sr); ok {
+		if s.GrpcStatusDist == nil {
+			s.GrpcStatusDist = map[string]int{}
+		}
+		s.GrpcStatusDist[status]++
+		return
+	}
+	s.StatusCodeDist[sr.StatusCode]++
+}
+
+// histogramPercentiles returns the reported percentiles of the histogram in seconds.
+func histogramPercentiles(h *util.Histogram) map[string]float32 {
+	percentiles := make(map[string]float32, len(percentileKeys))
+	for _, pk := range percentileKeys {
+		percentiles[pk.key] = float32(float64(h.ValueAtPercentile(pk.percentile)) / 1e6)
+	}
+	return percentiles
+}
+
+// frameCountKeys are the frame counts of the websocket steps, countKeys are the other count metrics of the step
+// results. They are summed up in the Frames and Counts of the step.
+var frameCountKeys = map[string]bool{"sentFrameCount": true, "receivedFrameCount": true}
+var countKeys = map[string]bool{"eventCount": true, "tokenFetchCount": true}
+
+func (s *ScenarioStepResultSummary) addCounts(custom map[string]interface{}) {
+	for k, v := range custom {
+		c, ok := v.(int64)
+		if !ok {
+			continue
+		}
+		if frameCountKeys[k] {
+			if s.Frames == nil {
+				s.Frames = map[string]int64{}
+			}
+			s.Frames[k] += c
+		} else if countKeys[k] {
+			if s.Counts == nil {
+				s.Counts = map[string]int64{}
+			}
+			s.Counts[k] += c
+		}
+	}
+}
+
+// perFetchDurations are the durations that the step results have only when something is fetched before the request,
+// like the oauth2 token. Averaging them over all the requests would hide their latency.
+var perFetchDurations = map[string]bool{"tokenFetchDuration": true}
+
+// recordDuration records the duration in the histogram of the given duration key.
+func (s *ScenarioStepResultSummary) recordDuration(k string, d time.Duration, precision int) {
+	if s.histograms == nil {
+		s.histograms = map[string]*util.Histogram{}
+	}
+	h, ok := s.histograms[k]
+	if !ok {
+		h = util.NewHistogram(precision)
+		s.histograms[k] = h
+	}
+	h.Record(d.Microseconds())
+}
+
+// recordFetchDuration records a duration of perFetchDurations, its average is taken over the recorded durations.
+func (s *ScenarioStepResultSummary) recordFetchDuration(k string, d time.Duration, precision int) {
+	s.recordDuration(k, d, precision)
+	n := float32(s.histograms[k].Count())
+	s.Durations[k] = ((n-1)*s.Durations[k] + float32(d.Seconds())) / n
+}
+
+// percentiles returns the percentiles of the duration histograms in seconds.
+func (s *ScenarioStepResultSummary) percentiles() map[string]map[string]float32 {
+	if len(s.histograms) == 0 {
+		return nil
+	}
+	percentiles := make(map[string]map[string]float32, len(s.histograms))
+	for k, h := range s.histograms {
+		percentiles[k] = histogramPercentiles(h)
+	}
+	return percentiles
+}
+
+// addRate adds the rate of the nth result to the average of the rate.
+func (s *ScenarioStepResultSummary) addRate(k string, v float64, n int64) {
+	if s.Rates == nil {
+		s.Rates = map[string]float32{}
+	}
+	s.Rates[k] = (float32(n-1)*s.Rates[k] + float32(v)) / float32(n)
 }
 
 func (s *ScenarioStepResultSummary) successPercentage() int {
@@ -199,6 +394,69 @@ func (s *ScenarioStepResultSummary) failedPercentage() int {
 	return 100 - s.successPercentage()
 }
 
+// ProxyResultSummary is the result of the iterations sent through a proxy, or through the proxies of a country.
+// Durations are the total durations of the successful iterations.
+type ProxyResultSummary struct {
+	Country         string             `json:"country,omitempty"`
+	SuccessCount    int64              `json:"success_count"`
+	FailCount       int64              `json:"fail_count"`
+	ServerErrorDist map[string]int     `json:"server_errors,omitempty"`
+	ErrorTypeDist   map[string]int     `json:"error_types,omitempty"`
+	AvgDuration     float32            `json:"avg_duration"`
+	Percentiles     map[string]float32 `json:"percentiles,omitempty"`
+
+	// histogram of the iteration durations in microseconds
+	histogram *util.Histogram
+}
+
+// add adds the iteration to the result. Failed steps are counted by their error types and reasons,
+// assertion failures are counted as the "assertion" error type.
+func (p *ProxyResultSummary) add(scr *types.ScenarioResult, errOccured bool, scenarioDuration float32, precision int) {
+	if errOccured {
+		p.FailCount++
+		for _, sr := range scr.StepResults {
+			errType := sr.Err.Type
+			if len(sr.FailedAssertions) > 0 {
+				errType = "assertion"
+			} else if sr.Err.Type != "" {
+				if p.ServerErrorDist == nil {
+					p.ServerErrorDist = map[string]int{}
+				}
+				p.ServerErrorDist[sr.Err.Reason]++
+			}
+			if errType != "" {
+				if p.ErrorTypeDist == nil {
+					p.ErrorTypeDist = map[string]int{}
+				}
+				p.ErrorTypeDist[errType]++
+			}
+		}
+		return
+	}
+
+	p.AvgDuration = (float32(p.SuccessCount)*p.AvgDuration + scenarioDuration) / float32(p.SuccessCount+1)
+	p.SuccessCount++
+	if p.histogram == nil {
+		p.histogram = util.NewHistogram(precision)
+	}
+	p.histogram.Record(int64(float64(scenarioDuration) * 1e6))
+}
+
+// percentiles returns the percentiles of the iteration durations in seconds.
+func (p *ProxyResultSummary) percentiles() map[string]float32 {
+	if p.histogram == nil {
+		return nil
+	}
+	return histogramPercentiles(p.histogram)
+}
+
+func (p *ProxyResultSummary) successPercentage() int {
+	if p.SuccessCount+p.FailCount == 0 {
+		return 0
+	}
+	return int(float32(p.SuccessCount) / float32(p.SuccessCount+p.FailCount) * 100)
+}
+
 type AssertInfo struct {
 	Count    int
 	Received map[string][]interface{}
@@ -37,6 +37,20 @@ type ReportService interface {
 	Start(input chan *types.ScenarioResult, assertionResultChan <-chan assertion.TestAssertionResult)
 }
 
+// Configurable is implemented by the report services that have their own options in the Hammer.
+// Configure is called before Init.
+type Configurable interface {
+	Configure(h types.Hammer) error
+}
+
+// Controller is implemented by the report services that control the run interactively.
+// The engine pauses the load while the last value received from PauseChan is true,
+// and cancels its context to stop the test when AbortChan is closed.
+type Controller interface {
+	PauseChan() <-chan bool
+	AbortChan() <-chan struct{}
+}
+
 // NewReportService is the factory method of the ReportService.
 func NewReportService(s string) (service ReportService, err error) {
 	if val, ok := AvailableOutputServices[s]; ok {
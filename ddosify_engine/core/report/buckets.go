@@ -0,0 +1,140 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/util"
+)
+
+// ResultBucket is the results of the iterations completed in an interval of the test.
+type ResultBucket struct {
+	Start        time.Time                     `json:"start"`
+	Offset       int                           `json:"offset"` // seconds since the start of the test
+	DroppedCount int64                         `json:"dropped_iteration_count,omitempty"`
+	Steps        map[uint16]*BucketStepSummary `json:"steps"`
+}
+
+// BucketStepSummary is the results of a step in a bucket.
+type BucketStepSummary struct {
+	Count          int64          `json:"count"`
+	SuccessCount   int64          `json:"success_count"`
+	FailCount      int64          `json:"fail_count"`
+	StatusCodeDist map[int]int    `json:"status_code_dist"`
+	GrpcStatusDist map[string]int `json:"grpc_status_dist,omitempty"`
+
+	// Percentiles of the step durations in seconds.
+	Percentiles map[string]float32 `json:"percentiles,omitempty"`
+
+	histogram *util.Histogram // in microseconds, released when the bucket is closed
+}
+
+// bucketAggregator aggregates the results into buckets of fixed intervals.
+// A result is put into the bucket of the interval it is received in, which is when its iteration is completed.
+// Only the last bucket is open, the percentiles of a bucket are calculated when the next one starts.
+type bucketAggregator struct {
+	interval  time.Duration
+	precision int
+	start     time.Time
+	buckets   []*ResultBucket
+}
+
+func newBucketAggregator(start time.Time, interval time.Duration, precision int) *bucketAggregator {
+	return &bucketAggregator{
+		interval:  interval,
+		precision: precision,
+		start:     start,
+		buckets:   make([]*ResultBucket, 0),
+	}
+}
+
+// add aggregates the result received at t into its bucket.
+func (b *bucketAggregator) add(scr *types.ScenarioResult, t time.Time) {
+	i := int(t.Sub(b.start) / b.interval)
+	if i < 0 {
+		i = 0
+	}
+	// intervals with no results have empty buckets
+	for len(b.buckets) <= i {
+		b.closeLast()
+		offset := time.Duration(len(b.buckets)) * b.interval
+		b.buckets = append(b.buckets, &ResultBucket{
+			Start:  b.start.Add(offset),
+			Offset: int(offset / time.Second),
+			Steps:  make(map[uint16]*BucketStepSummary),
+		})
+	}
+	bucket := b.buckets[len(b.buckets)-1]
+
+	if scr.Dropped {
+		bucket.DroppedCount++
+		return
+	}
+
+	for _, sr := range scr.StepResults {
+		step, ok := bucket.Steps[sr.StepID]
+		if !ok {
+			step = &BucketStepSummary{
+				StatusCodeDist: make(map[int]int),
+				histogram:      util.NewHistogram(b.precision),
+			}
+			bucket.Steps[sr.StepID] = step
+		}
+
+		step.Count++
+		if sr.Err.Type != "" && len(sr.FailedAssertions) == 0 { // server error
+			step.FailCount++
+			continue
+		}
+		if len(sr.FailedAssertions) > 0 {
+			step.FailCount++
+		} else {
+			step.SuccessCount++
+		}
+		if status, ok := grpcStatus(sr); ok {
+			if step.GrpcStatusDist == nil {
+				step.GrpcStatusDist = map[string]int{}
+			}
+			step.GrpcStatusDist[status]++
+		} else {
+			step.StatusCodeDist[sr.StatusCode]++
+		}
+		step.histogram.Record(sr.Duration.Microseconds())
+	}
+}
+
+// closeLast calculates the percentiles of the last bucket and releases its histograms.
+func (b *bucketAggregator) closeLast() {
+	if len(b.buckets) == 0 {
+		return
+	}
+	for _, step := range b.buckets[len(b.buckets)-1].Steps {
+		if step.histogram == nil {
+			continue
+		}
+		if step.histogram.Count() > 0 {
+			step.Percentiles = histogramPercentiles(step.histogram)
+		}
+		step.histogram = nil
+	}
+}
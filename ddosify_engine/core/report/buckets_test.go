@@ -0,0 +1,129 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+func bucketTestResult(statusCode int, d time.Duration, errType string, assertionFail bool) *types.ScenarioResult {
+	sr := &types.ScenarioStepResult{
+		StepID:     1,
+		StatusCode: statusCode,
+		Duration:   d,
+		Err:        types.RequestError{Type: errType},
+	}
+	if assertionFail {
+		sr.FailedAssertions = []types.FailedAssertion{{Rule: "equals(status_code, 200)"}}
+	}
+	return &types.ScenarioResult{StepResults: []*types.ScenarioStepResult{sr}}
+}
+
+func TestBucketAggregator(t *testing.T) {
+	start := time.Now()
+	b := newBucketAggregator(start, 10*time.Second, 3)
+
+	b.add(bucketTestResult(200, 100*time.Millisecond, "", false), start.Add(time.Second))
+	b.add(bucketTestResult(200, 300*time.Millisecond, "", false), start.Add(9*time.Second))
+	// no results in the second interval
+	b.add(bucketTestResult(500, 200*time.Millisecond, "", true), start.Add(25*time.Second))
+	b.add(bucketTestResult(0, time.Second, types.ErrorConn, false), start.Add(29*time.Second))
+	b.add(&types.ScenarioResult{Dropped: true}, start.Add(29*time.Second))
+	b.closeLast()
+
+	if len(b.buckets) != 3 {
+		t.Fatalf("Expected 3 buckets, Found: %d", len(b.buckets))
+	}
+	for i, bucket := range b.buckets {
+		if bucket.Offset != i*10 || !bucket.Start.Equal(start.Add(time.Duration(i)*10*time.Second)) {
+			t.Errorf("Unexpected start of bucket %d: %v, %d", i, bucket.Start, bucket.Offset)
+		}
+	}
+
+	first := b.buckets[0].Steps[1]
+	if first.Count != 2 || first.SuccessCount != 2 || first.FailCount != 0 {
+		t.Errorf("Unexpected counts of the first bucket: %+v", first)
+	}
+	if !reflect.DeepEqual(first.StatusCodeDist, map[int]int{200: 2}) {
+		t.Errorf("Unexpected status codes of the first bucket: %v", first.StatusCodeDist)
+	}
+	if first.Percentiles["max"] != 0.3 || first.Percentiles["p50"] < 0.1 || first.Percentiles["p50"] > 0.1001 {
+		t.Errorf("Unexpected percentiles of the first bucket: %v", first.Percentiles)
+	}
+	if first.histogram != nil {
+		t.Errorf("Histograms of the closed buckets should be released")
+	}
+
+	if len(b.buckets[1].Steps) != 0 {
+		t.Errorf("Second bucket should be empty, Found: %v", b.buckets[1].Steps)
+	}
+
+	last := b.buckets[2]
+	if last.DroppedCount != 1 {
+		t.Errorf("Expected 1 dropped iteration, Found: %d", last.DroppedCount)
+	}
+	if s := last.Steps[1]; s.Count != 2 || s.SuccessCount != 0 || s.FailCount != 2 ||
+		!reflect.DeepEqual(s.StatusCodeDist, map[int]int{500: 1}) {
+		t.Errorf("Unexpected step summary of the last bucket: %+v", s)
+	}
+	// durations of the server errors are not in the percentiles
+	if p := last.Steps[1].Percentiles["max"]; p != 0.2 {
+		t.Errorf("Expected max 0.2, Found: %v", p)
+	}
+}
+
+func TestStdoutJsonBuckets(t *testing.T) {
+	s := &stdoutJson{}
+	s.Configure(types.Hammer{StdoutJson: types.StdoutJsonConf{BucketInterval: 1}})
+	s.Init(false, 1)
+
+	input := make(chan *types.ScenarioResult, 2)
+	input <- bucketTestResult(200, 100*time.Millisecond, "", false)
+	input <- bucketTestResult(200, 200*time.Millisecond, "", false)
+	close(input)
+	s.listenAndAggregate(input, nil)
+
+	if len(s.result.Buckets) != 1 {
+		t.Fatalf("Expected 1 bucket, Found: %d", len(s.result.Buckets))
+	}
+	j, _ := json.Marshal(s.result)
+	for _, e := range []string{`"buckets":[{"start":`, `"offset":0`, `"count":2`, `"status_code_dist":{"200":2}`, `"p99.9":`} {
+		if !strings.Contains(string(j), e) {
+			t.Errorf("Expected %s in %s", e, j)
+		}
+	}
+
+	s = &stdoutJson{}
+	s.Init(false, 1)
+	input = make(chan *types.ScenarioResult, 1)
+	input <- bucketTestResult(200, 100*time.Millisecond, "", false)
+	close(input)
+	s.listenAndAggregate(input, nil)
+	if j, _ := json.Marshal(s.result); strings.Contains(string(j), "buckets") {
+		t.Errorf("Buckets should not be reported when bucket_interval is not set, Found: %s", j)
+	}
+}
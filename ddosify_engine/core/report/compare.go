@@ -0,0 +1,238 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"text/tabwriter"
+)
+
+// CompareOpts are the regression thresholds of the comparison of two results.
+type CompareOpts struct {
+	// Latency increase in percent to count a step as regressed.
+	LatencyThreshold float64
+
+	// Success rate decrease in percentage points to count a step as regressed.
+	SuccessThreshold float64
+}
+
+// Comparison is the step by step difference of a result from a base result.
+type Comparison struct {
+	Steps       []StepComparison
+	Regressions []string
+}
+
+// StepComparison is the difference of a step of the compared results.
+// Base or New is nil if the step is only in one of the results.
+type StepComparison struct {
+	ID          uint16
+	Name        string
+	Base        *ScenarioStepResultSummary
+	New         *ScenarioStepResultSummary
+	Latencies   []LatencyComparison
+	StatusCodes []int
+
+	// Status names of the gRPC steps.
+	GrpcStatuses []string
+}
+
+// LatencyComparison is the difference of an average or percentile total duration of a step.
+type LatencyComparison struct {
+	Name      string
+	Base      float32
+	New       float32
+	Change    float64 // in percent
+	Regressed bool
+}
+
+// comparedLatencies are the compared total duration statistics, regressions are checked for the gated ones.
+var comparedLatencies = []struct {
+	name  string
+	gated bool
+}{
+	{"avg", true}, {"p50", true}, {"p90", true}, {"p95", true}, {"p99", true}, {"p99.9", false}, {"max", false},
+}
+
+// ParseResult parses a result printed by the stdout-json output.
+func ParseResult(b []byte) (*Result, error) {
+	r := &Result{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("invalid stdout-json report: %v", err)
+	}
+	if r.StepResults == nil {
+		return nil, fmt.Errorf("invalid stdout-json report: steps not found")
+	}
+	return r, nil
+}
+
+// Compare compares the steps of the new result with the steps of the base result that have the same ID.
+func Compare(base, new *Result, opts CompareOpts) *Comparison {
+	ids := make([]int, 0)
+	for id := range base.StepResults {
+		ids = append(ids, int(id))
+	}
+	for id := range new.StepResults {
+		if _, ok := base.StepResults[id]; !ok {
+			ids = append(ids, int(id))
+		}
+	}
+	sort.Ints(ids)
+
+	c := &Comparison{}
+	for _, id := range ids {
+		sc := StepComparison{
+			ID:   uint16(id),
+			Base: base.StepResults[uint16(id)],
+			New:  new.StepResults[uint16(id)],
+		}
+		if sc.New != nil {
+			sc.Name = sc.New.Name
+		} else {
+			sc.Name = sc.Base.Name
+		}
+		if sc.Base == nil || sc.New == nil {
+			c.Steps = append(c.Steps, sc)
+			continue
+		}
+
+		stepName := fmt.Sprintf("%d. %s", id, sc.Name)
+		if drop := sc.Base.successRate() - sc.New.successRate(); drop > opts.SuccessThreshold {
+			c.Regressions = append(c.Regressions, fmt.Sprintf("%s success rate dropped by %.1f points", stepName, drop))
+		}
+
+		for _, l := range comparedLatencies {
+			b, okBase := sc.Base.latency(l.name)
+			n, okNew := sc.New.latency(l.name)
+			if !okBase || !okNew {
+				continue
+			}
+			lc := LatencyComparison{Name: l.name, Base: b, New: n}
+			if b > 0 {
+				lc.Change = float64(n-b) / float64(b) * 100
+			}
+			lc.Regressed = l.gated && lc.Change > opts.LatencyThreshold
+			if lc.Regressed {
+				c.Regressions = append(c.Regressions, fmt.Sprintf("%s is %.1f%% slower on %s latency", stepName, lc.Change, l.name))
+			}
+			sc.Latencies = append(sc.Latencies, lc)
+		}
+
+		codes := map[int]bool{}
+		for code := range sc.Base.StatusCodeDist {
+			codes[code] = true
+		}
+		for code := range sc.New.StatusCodeDist {
+			codes[code] = true
+		}
+		for code := range codes {
+			sc.StatusCodes = append(sc.StatusCodes, code)
+		}
+		sort.Ints(sc.StatusCodes)
+
+		statuses := map[string]bool{}
+		for s := range sc.Base.GrpcStatusDist {
+			statuses[s] = true
+		}
+		for s := range sc.New.GrpcStatusDist {
+			statuses[s] = true
+		}
+		sc.GrpcStatuses = sortedKeys(statuses)
+
+		c.Steps = append(c.Steps, sc)
+	}
+	return c
+}
+
+// Print writes the comparison as a table for each step, followed by the regressions.
+func (c *Comparison) Print(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 0, 4, ' ', 0)
+
+	for _, s := range c.Steps {
+		fmt.Fprintf(w, "\n%d. %s\n", s.ID, s.Name)
+		fmt.Fprintln(w, "---------------------------------")
+		if s.Base == nil {
+			fmt.Fprintln(w, "Step is not in the base report")
+			continue
+		}
+		if s.New == nil {
+			fmt.Fprintln(w, "Step is not in the new report")
+			continue
+		}
+
+		fmt.Fprintln(w, "\t Base\t New\t Change")
+		fmt.Fprintf(w, "Success Rate\t %.1f%%\t %.1f%%\t %+.1f pts\n",
+			s.Base.successRate(), s.New.successRate(), s.New.successRate()-s.Base.successRate())
+		for _, l := range s.Latencies {
+			mark := ""
+			if l.Regressed {
+				mark = "\t REGRESSION"
+			}
+			fmt.Fprintf(w, "Latency %s\t %.4fs\t %.4fs\t %+.1f%%%s\n", l.Name, l.Base, l.New, l.Change, mark)
+		}
+
+		if len(s.StatusCodes) > 0 {
+			fmt.Fprintln(w, "\nStatus Code (Message)\t Base\t New\t Change")
+			for _, code := range s.StatusCodes {
+				b, n := s.Base.StatusCodeDist[code], s.New.StatusCodeDist[code]
+				fmt.Fprintf(w, "  %3d (%s)\t %d\t %d\t %+d\n", code, http.StatusText(code), b, n, n-b)
+			}
+		}
+		if len(s.GrpcStatuses) > 0 {
+			fmt.Fprintln(w, "\ngRPC Status\t Base\t New\t Change")
+			for _, status := range s.GrpcStatuses {
+				b, n := s.Base.GrpcStatusDist[status], s.New.GrpcStatusDist[status]
+				fmt.Fprintf(w, "  %s\t %d\t %d\t %+d\n", status, b, n, n-b)
+			}
+		}
+	}
+
+	if len(c.Regressions) > 0 {
+		fmt.Fprintln(w, "\nRegressions:")
+		for _, r := range c.Regressions {
+			fmt.Fprintf(w, "  %s\n", r)
+		}
+	} else {
+		fmt.Fprintln(w, "\nNo regression")
+	}
+	w.Flush()
+}
+
+// successRate returns the success rate of the step in percent.
+func (s *ScenarioStepResultSummary) successRate() float64 {
+	if s.SuccessCount+s.Fail.Count == 0 {
+		return 0
+	}
+	return float64(s.SuccessCount) / float64(s.SuccessCount+s.Fail.Count) * 100
+}
+
+// latency returns the average or a percentile of the total durations of a step parsed from a stdout-json report.
+func (s *ScenarioStepResultSummary) latency(name string) (float32, bool) {
+	if name == "avg" {
+		v, ok := s.Durations[strKeyToJsonKey["duration"]]
+		return v, ok
+	}
+	v, ok := s.Percentiles[strKeyToJsonKey["duration"]][name]
+	return v, ok
+}
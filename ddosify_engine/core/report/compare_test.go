@@ -0,0 +1,122 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const compareTestBase = `{"test_status":"success","success_perc":90,"fail_perc":10,"steps":{
+"1":{"name":"login","status_code_dist":{"200":90,"500":10},"fail":{"count":10,"assertions":{"count":10},"server":{"count":0}},
+"durations":{"total":0.1,"dns":0.01},"success_count":90,
+"percentiles":{"total":{"p50":0.1,"p90":0.2,"p95":0.3,"p99":0.4,"p99.9":0.5,"max":0.6}}},
+"2":{"name":"checkout","status_code_dist":{},"grpc_status_dist":{"OK":100},"fail":{"count":0},"durations":{"total":0.2},
+"success_count":100},
+"3":{"name":"logout","status_code_dist":{"200":100},"fail":{"count":0},"durations":{"total":0.2},"success_count":100}}}`
+
+const compareTestNew = `{"test_status":"success","steps":{
+"1":{"name":"login","status_code_dist":{"200":80,"502":20},"fail":{"count":20},
+"durations":{"total":0.105,"dns":0.01},"success_count":80,
+"percentiles":{"total":{"p50":0.1,"p90":0.25,"p95":0.3,"p99":0.4,"p99.9":1,"max":2}}},
+"2":{"name":"checkout","status_code_dist":{},"grpc_status_dist":{"OK":95,"NotFound":5},"fail":{"count":0},
+"durations":{"total":0.3},"success_count":100},
+"4":{"name":"search","status_code_dist":{"200":100},"fail":{"count":0},"durations":{"total":0.2},"success_count":100}}}`
+
+func compareTestResults(t *testing.T) (*Result, *Result) {
+	base, err := ParseResult([]byte(compareTestBase))
+	if err != nil {
+		t.Fatalf("base report could not be parsed: %v", err)
+	}
+	new, err := ParseResult([]byte(compareTestNew))
+	if err != nil {
+		t.Fatalf("new report could not be parsed: %v", err)
+	}
+	return base, new
+}
+
+func TestCompare(t *testing.T) {
+	base, new := compareTestResults(t)
+	c := Compare(base, new, CompareOpts{LatencyThreshold: 10, SuccessThreshold: 5})
+
+	if len(c.Steps) != 4 {
+		t.Fatalf("Expected 4 steps, Found: %d", len(c.Steps))
+	}
+	if c.Steps[2].New != nil || c.Steps[3].Base != nil || c.Steps[3].Name != "search" {
+		t.Errorf("Steps only in one of the reports should be kept: %+v, %+v", c.Steps[2], c.Steps[3])
+	}
+
+	login := c.Steps[0]
+	if len(login.Latencies) != 7 {
+		t.Fatalf("Expected avg and 6 percentiles, Found: %v", login.Latencies)
+	}
+	for _, l := range login.Latencies {
+		// p99.9 and max changes are reported but not counted as regressions
+		expected := l.Name == "p90"
+		if l.Regressed != expected {
+			t.Errorf("Unexpected regression of %s: %+v", l.Name, l)
+		}
+	}
+	if login.Latencies[0].Change < 4.9 || login.Latencies[0].Change > 5.1 {
+		t.Errorf("Expected avg change 5%%, Found: %v", login.Latencies[0].Change)
+	}
+	if login.StatusCodes[0] != 200 || login.StatusCodes[1] != 500 || login.StatusCodes[2] != 502 {
+		t.Errorf("Unexpected status codes: %v", login.StatusCodes)
+	}
+
+	expected := []string{
+		"1. login success rate dropped by 10.0 points",
+		"1. login is 25.0% slower on p90 latency",
+		"2. checkout is 50.0% slower on avg latency",
+	}
+	if strings.Join(c.Regressions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected regressions: %v, Found: %v", expected, c.Regressions)
+	}
+
+	c = Compare(base, new, CompareOpts{LatencyThreshold: 60, SuccessThreshold: 15})
+	if len(c.Regressions) != 0 {
+		t.Errorf("Expected no regression with higher thresholds, Found: %v", c.Regressions)
+	}
+}
+
+func TestComparePrint(t *testing.T) {
+	base, new := compareTestResults(t)
+	var out bytes.Buffer
+	Compare(base, new, CompareOpts{LatencyThreshold: 10, SuccessThreshold: 5}).Print(&out)
+
+	for _, e := range []string{
+		"1. login", "Success Rate", "90.0%", "80.0%", "-10.0 pts", "Latency p90", "+25.0%", "REGRESSION",
+		"502 (Bad Gateway)", "gRPC Status", "NotFound", "Step is not in the new report", "Step is not in the base report", "Regressions:",
+	} {
+		if !strings.Contains(out.String(), e) {
+			t.Errorf("Expected %q in the output:\n%s", e, out.String())
+		}
+	}
+}
+
+func TestParseResultInvalid(t *testing.T) {
+	for _, r := range []string{"", "not json", `{"test_status":"success"}`} {
+		if _, err := ParseResult([]byte(r)); err == nil {
+			t.Errorf("ParseResult should be errored for %q", r)
+		}
+	}
+}
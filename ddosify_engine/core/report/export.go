@@ -0,0 +1,261 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"go.ddosify.com/ddosify/core/assertion"
+	"go.ddosify.com/ddosify/core/types"
+)
+
+const OutputTypeExport = "export"
+
+func init() {
+	AvailableOutputServices[OutputTypeExport] = &exportReport{}
+}
+
+// exportPhases are the custom duration keys of the step results written as columns, in the order of keyToStr.
+var exportPhases = []string{
+	"tokenFetchDuration", "dnsDuration", "connDuration", "proxyConnDuration", "tlsDuration", "wsHandshakeDuration",
+	"reqDuration", "serverProcessDuration", "firstEventDuration", "resDuration", "eventGapDuration", "wsRoundTripDuration",
+}
+
+// exportReport streams each step result to a NDJSON or CSV file while the test runs.
+type exportReport struct {
+	stdoutTee
+
+	conf     types.ExportConf
+	path     string
+	file     *os.File
+	w        *bufio.Writer
+	csv      *csv.Writer
+	columns  []string
+	writeErr error
+}
+
+// exportField is a column of a step result, nil values are written as empty cells or omitted from the json.
+type exportField struct {
+	name  string
+	value interface{}
+}
+
+// Configure sets the export options of the hammer, it should be called before Init.
+func (e *exportReport) Configure(h types.Hammer) error {
+	e.conf = h.Export
+	return e.stdout.Configure(h)
+}
+
+func (e *exportReport) Init(debug bool, samplingRate int) (err error) {
+	if err = e.stdoutTee.Init(debug, samplingRate); err != nil {
+		return
+	}
+
+	e.path = e.conf.Path
+	if e.conf.Format == "" {
+		e.conf.Format = types.ExportFormatNdjson
+		if strings.HasSuffix(strings.ToLower(e.path), ".csv") {
+			e.conf.Format = types.ExportFormatCsv
+		}
+	}
+	if e.path == "" {
+		e.path = fmt.Sprintf("ddosify_results_%s.%s", time.Now().Format("20060102_150405"), e.conf.Format)
+	}
+
+	e.columns = []string{"request_id", "step_id", "step_name", "method", "url", "start_time", "duration"}
+	for _, k := range exportPhases {
+		e.columns = append(e.columns, influxFieldName(k))
+	}
+	e.columns = append(e.columns, "status_code", "error_type", "error_reason", "failed_assertions",
+		"request_size", "response_size")
+	if e.conf.CaptureBody {
+		e.columns = append(e.columns, "request_body", "response_body")
+	}
+
+	if debug {
+		return
+	}
+	// create the file here to fail before the test starts if it is not writable
+	if e.file, err = os.Create(e.path); err != nil {
+		return fmt.Errorf("results file could not be created: %v", err)
+	}
+	e.w = bufio.NewWriter(e.file)
+	if e.conf.Format == types.ExportFormatCsv {
+		e.csv = csv.NewWriter(e.w)
+		e.writeErr = e.csv.Write(e.columns)
+	}
+	return
+}
+
+func (e *exportReport) Start(input chan *types.ScenarioResult,
+	assertionResultChan <-chan assertion.TestAssertionResult) {
+	if e.debug {
+		e.done <- e.teeStdout(input, assertionResultChan, nil)
+		return
+	}
+
+	success := e.teeStdout(input, assertionResultChan, e.write)
+
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil && e.writeErr == nil {
+			e.writeErr = err
+		}
+	}
+	if err := e.w.Flush(); err != nil && e.writeErr == nil {
+		e.writeErr = err
+	}
+	if err := e.file.Close(); err != nil && e.writeErr == nil {
+		e.writeErr = err
+	}
+
+	if e.writeErr != nil {
+		color.Red("Results could not be written to %s: %v\n", e.path, e.writeErr)
+	} else {
+		color.Cyan("Results are written to %s\n", e.path)
+	}
+
+	e.done <- success
+}
+
+// write writes the step results of the iteration, the first error is kept to be reported at the end.
+func (e *exportReport) write(scr *types.ScenarioResult) {
+	if scr.Dropped || e.writeErr != nil {
+		return
+	}
+	for _, sr := range scr.StepResults {
+		fields := e.fields(sr)
+		if e.csv != nil {
+			e.writeErr = e.csv.Write(csvRecord(fields))
+		} else {
+			e.writeErr = writeNdjson(e.w, fields)
+		}
+		if e.writeErr != nil {
+			return
+		}
+	}
+}
+
+// fields returns the columns of the step result.
+func (e *exportReport) fields(sr *types.ScenarioStepResult) []exportField {
+	var startTime interface{}
+	if !sr.RequestTime.IsZero() {
+		startTime = sr.RequestTime.Format(time.RFC3339Nano)
+	}
+	fields := []exportField{
+		{"request_id", sr.RequestID.String()},
+		{"step_id", sr.StepID},
+		{"step_name", sr.StepName},
+		{"method", sr.Method},
+		{"url", sr.Url},
+		{"start_time", startTime},
+		{"duration", sr.Duration.Seconds()},
+	}
+	for _, k := range exportPhases {
+		var v interface{}
+		if d, ok := sr.Custom[k].(time.Duration); ok {
+			v = d.Seconds()
+		}
+		fields = append(fields, exportField{influxFieldName(k), v})
+	}
+
+	var errType, errReason, failedAssertions interface{}
+	if sr.Err.Type != "" {
+		errType, errReason = sr.Err.Type, sr.Err.Reason
+	}
+	if len(sr.FailedAssertions) > 0 {
+		rules := make([]string, 0, len(sr.FailedAssertions))
+		for _, fa := range sr.FailedAssertions {
+			rules = append(rules, fa.Rule)
+		}
+		failedAssertions = strings.Join(rules, "; ")
+	}
+	responseSize := sr.ContentLength
+	if responseSize < 0 {
+		responseSize = int64(len(sr.RespBody))
+	}
+	fields = append(fields,
+		exportField{"status_code", sr.StatusCode},
+		exportField{"error_type", errType},
+		exportField{"error_reason", errReason},
+		exportField{"failed_assertions", failedAssertions},
+		exportField{"request_size", len(sr.ReqBody)},
+		exportField{"response_size", responseSize},
+	)
+
+	if e.conf.CaptureBody {
+		fields = append(fields,
+			exportField{"request_body", string(sr.ReqBody)},
+			exportField{"response_body", string(sr.RespBody)},
+		)
+	}
+	return fields
+}
+
+func csvRecord(fields []exportField) []string {
+	record := make([]string, len(fields))
+	for i, f := range fields {
+		switch v := f.value.(type) {
+		case nil:
+		case string:
+			record[i] = v
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return record
+}
+
+// writeNdjson writes the fields as a json object in a line, the order of the fields is kept.
+func writeNdjson(w *bufio.Writer, fields []exportField) error {
+	w.WriteByte('{')
+	first := true
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		v, err := json.Marshal(f.value)
+		if err != nil {
+			return err
+		}
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		w.WriteString(strconv.Quote(f.name))
+		w.WriteByte(':')
+		w.Write(v)
+	}
+	w.WriteByte('}')
+	_, err := w.WriteString("\n")
+	return err
+}
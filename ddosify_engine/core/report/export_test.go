@@ -0,0 +1,206 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.ddosify.com/ddosify/core/types"
+)
+
+var exportTestRequestID = uuid.MustParse("6f8a4f5e-6f5c-4e8f-9c6b-3e2a1d0c9b8a")
+
+func exportTestResults(start time.Time) []*types.ScenarioResult {
+	return []*types.ScenarioResult{
+		{
+			StepResults: []*types.ScenarioStepResult{
+				{
+					StepID:        1,
+					StepName:      "login",
+					RequestID:     exportTestRequestID,
+					Method:        "POST",
+					Url:           "https://test.com/login",
+					RequestTime:   start,
+					StatusCode:    200,
+					Duration:      150 * time.Millisecond,
+					ContentLength: 2,
+					ReqBody:       []byte(`{"user":"a"}`),
+					RespBody:      []byte(`ok`),
+					Custom: map[string]interface{}{
+						"dnsDuration":  10 * time.Millisecond,
+						"connDuration": 20 * time.Millisecond,
+					},
+				},
+				{
+					StepID:           2,
+					StepName:         "order",
+					RequestID:        exportTestRequestID,
+					RequestTime:      start,
+					StatusCode:       500,
+					Duration:         time.Second,
+					ContentLength:    -1,
+					RespBody:         []byte(`fail`),
+					FailedAssertions: []types.FailedAssertion{{Rule: "equals(status_code, 200)"}},
+				},
+			},
+		},
+		{
+			Dropped: true,
+		},
+		{
+			StepResults: []*types.ScenarioStepResult{
+				{
+					StepID:    1,
+					StepName:  "login",
+					RequestID: exportTestRequestID,
+					Duration:  time.Second,
+					Err:       types.RequestError{Type: types.ErrorConn, Reason: types.ReasonConnTimeout},
+				},
+			},
+		},
+	}
+}
+
+func runExportReport(t *testing.T, conf types.ExportConf) *exportReport {
+	e := &exportReport{}
+	e.Configure(types.Hammer{Export: conf})
+	if err := e.Init(false, 0); err != nil {
+		t.Fatalf("export init error: %v", err)
+	}
+
+	input := make(chan *types.ScenarioResult, 10)
+	go e.Start(input, nil)
+	for _, r := range exportTestResults(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		input <- r
+	}
+	close(input)
+	<-e.DoneChan()
+	return e
+}
+
+func TestExportNdjson(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	runExportReport(t, types.ExportConf{Path: path})
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("TestExportNdjson results could not be read: %v", err)
+	}
+	defer f.Close()
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var l map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			t.Fatalf("Invalid json line %s: %v", scanner.Text(), err)
+		}
+		lines = append(lines, l)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, Found: %d", len(lines))
+	}
+
+	expected := map[string]interface{}{
+		"request_id":          exportTestRequestID.String(),
+		"step_id":             float64(1),
+		"step_name":           "login",
+		"method":              "POST",
+		"url":                 "https://test.com/login",
+		"start_time":          "2024-01-02T03:04:05Z",
+		"duration":            0.15,
+		"dns_duration":        0.01,
+		"connection_duration": 0.02,
+		"status_code":         float64(200),
+		"request_size":        float64(12),
+		"response_size":       float64(2),
+	}
+	if !reflect.DeepEqual(lines[0], expected) {
+		t.Errorf("Expected: %v, Found: %v", expected, lines[0])
+	}
+	if lines[1]["failed_assertions"] != "equals(status_code, 200)" || lines[1]["response_size"] != float64(4) {
+		t.Errorf("Unexpected assertion failure line: %v", lines[1])
+	}
+	if lines[2]["error_type"] != types.ErrorConn || lines[2]["error_reason"] != types.ReasonConnTimeout {
+		t.Errorf("Unexpected server error line: %v", lines[2])
+	}
+	if _, ok := lines[0]["response_body"]; ok {
+		t.Errorf("Bodies should not be written unless capture_body is set")
+	}
+}
+
+func TestExportCsv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.csv")
+	e := runExportReport(t, types.ExportConf{Path: path, CaptureBody: true})
+	if e.conf.Format != types.ExportFormatCsv {
+		t.Errorf("Format should be csv for a .csv path, Found: %s", e.conf.Format)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("TestExportCsv results could not be read: %v", err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("TestExportCsv invalid csv: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("Expected header and 3 records, Found: %d", len(records))
+	}
+
+	row := map[string]string{}
+	for i, c := range records[0] {
+		row[c] = records[1][i]
+	}
+	expected := map[string]string{
+		"request_id":    exportTestRequestID.String(),
+		"step_id":       "1",
+		"duration":      "0.15",
+		"dns_duration":  "0.01",
+		"tls_duration":  "",
+		"status_code":   "200",
+		"error_type":    "",
+		"request_body":  `{"user":"a"}`,
+		"response_body": "ok",
+	}
+	for k, v := range expected {
+		if row[k] != v {
+			t.Errorf("Expected %s: %q, Found: %q", k, v, row[k])
+		}
+	}
+}
+
+func TestExportInitError(t *testing.T) {
+	e := &exportReport{}
+	e.Configure(types.Hammer{Export: types.ExportConf{Path: filepath.Join(t.TempDir(), "missing", "results.csv")}})
+	if err := e.Init(false, 0); err == nil {
+		t.Errorf("TestExportInitError should be errored for a not writable path")
+	}
+}
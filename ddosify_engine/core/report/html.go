@@ -0,0 +1,456 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+
+	"go.ddosify.com/ddosify/core/assertion"
+	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/util"
+)
+
+const OutputTypeHtml = "html"
+
+func init() {
+	AvailableOutputServices[OutputTypeHtml] = &htmlReport{}
+}
+
+// htmlReport writes the results to a single html file with no external assets at the end of the test.
+type htmlReport struct {
+	stdoutTee
+
+	conf   types.HtmlConf
+	hammer types.Hammer
+	start  time.Time
+
+	mu        sync.Mutex
+	timelines map[uint16][]*htmlSecond
+}
+
+// htmlTimelinePrecision is the precision of the per-second duration histograms, lower than the overall
+// precision to keep the memory usage low on long tests.
+const htmlTimelinePrecision = 2
+
+// htmlSecond is the data of the requests of a step started in a second of the test.
+type htmlSecond struct {
+	count     int64
+	failed    int64
+	durations *util.Histogram // in microseconds
+}
+
+// Configure keeps the hammer to be shown as the run configuration, it should be called before Init.
+func (r *htmlReport) Configure(h types.Hammer) error {
+	r.conf = h.Html
+	r.hammer = h
+	return r.stdout.Configure(h)
+}
+
+func (r *htmlReport) Init(debug bool, samplingRate int) (err error) {
+	if err = r.stdoutTee.Init(debug, samplingRate); err != nil {
+		return
+	}
+	r.timelines = make(map[uint16][]*htmlSecond)
+	return
+}
+
+func (r *htmlReport) Start(input chan *types.ScenarioResult,
+	assertionResultChan <-chan assertion.TestAssertionResult) {
+	if r.debug {
+		r.done <- r.teeStdout(input, assertionResultChan, nil)
+		return
+	}
+
+	r.start = time.Now()
+	path := r.conf.Path
+	if path == "" {
+		path = fmt.Sprintf("ddosify_report_%s.html", r.start.Format("20060102_150405"))
+	}
+
+	success := r.teeStdout(input, assertionResultChan, r.add)
+
+	if err := r.write(path); err != nil {
+		color.Red("HTML report could not be written: %v\n", err)
+	} else {
+		color.Cyan("HTML report is written to %s\n", path)
+	}
+
+	r.done <- success
+}
+
+// add adds the step results of an iteration to the second they are started in.
+func (r *htmlReport) add(scr *types.ScenarioResult) {
+	if scr.Dropped {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, sr := range scr.StepResults {
+		reqTime := sr.RequestTime
+		if reqTime.IsZero() {
+			reqTime = time.Now()
+		}
+		sec := int(reqTime.Sub(r.start) / time.Second)
+		if sec < 0 {
+			sec = 0
+		}
+
+		timeline := r.timelines[sr.StepID]
+		for len(timeline) <= sec {
+			timeline = append(timeline, &htmlSecond{durations: util.NewHistogram(htmlTimelinePrecision)})
+		}
+		r.timelines[sr.StepID] = timeline
+
+		s := timeline[sec]
+		s.count++
+		if sr.Err.Type != "" || len(sr.FailedAssertions) > 0 {
+			s.failed++
+		}
+		if sr.Err.Type == "" {
+			s.durations.Record(sr.Duration.Microseconds())
+		}
+	}
+}
+
+func (r *htmlReport) write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return htmlTemplate.Execute(f, r.view())
+}
+
+type htmlView struct {
+	GeneratedAt    string
+	Result         *Result
+	SuccessPercent int
+	FailedPercent  int
+	Config         []htmlKV
+	ScenarioSteps  []types.ScenarioStep
+	PercentileKeys []string
+	Steps          []htmlStepView
+}
+
+type htmlKV struct {
+	Key   string
+	Value string
+}
+
+type htmlStepView struct {
+	ID           uint16
+	Name         string
+	Summary      *ScenarioStepResultSummary
+	RpsChart     template.HTML
+	LatencyChart template.HTML
+	ErrorChart   template.HTML
+	StatusCodes  []htmlKV
+	Durations    []htmlKV
+	Percentiles  []htmlPercentiles
+	ServerErrors []htmlKV
+	Assertions   []htmlAssertion
+}
+
+type htmlPercentiles struct {
+	Name   string
+	Values []string
+}
+
+type htmlAssertion struct {
+	Rule     string
+	Count    int
+	Reason   string
+	Received []htmlKV
+}
+
+func (r *htmlReport) view() htmlView {
+	v := htmlView{
+		GeneratedAt:    time.Now().Format(time.RFC1123),
+		Result:         r.result,
+		SuccessPercent: r.result.successPercentage(),
+		FailedPercent:  r.result.failedPercentage(),
+		Config:         r.runConfig(),
+		ScenarioSteps:  r.hammer.Scenario.Steps,
+	}
+	for _, pk := range percentileKeys {
+		v.PercentileKeys = append(v.PercentileKeys, pk.key)
+	}
+
+	ids := make([]int, 0, len(r.result.StepResults))
+	for id := range r.result.StepResults {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		summary := r.result.StepResults[uint16(id)]
+		sv := htmlStepView{ID: uint16(id), Name: summary.Name, Summary: summary}
+		if sv.Name == "" {
+			sv.Name = fmt.Sprintf("Step %d", id)
+		}
+
+		rps, errRate := []float64{}, []float64{}
+		p50, p90, p99 := []float64{}, []float64{}, []float64{}
+		for _, s := range r.timelines[uint16(id)] {
+			rps = append(rps, float64(s.count))
+			if s.count > 0 {
+				errRate = append(errRate, float64(s.failed)*100/float64(s.count))
+			} else {
+				errRate = append(errRate, 0)
+			}
+			p50 = append(p50, float64(s.durations.ValueAtPercentile(50))/1e3)
+			p90 = append(p90, float64(s.durations.ValueAtPercentile(90))/1e3)
+			p99 = append(p99, float64(s.durations.ValueAtPercentile(99))/1e3)
+		}
+		sv.RpsChart = lineChart("Requests per second", "", []chartLine{{Name: "rps", Color: "#2b7de9", Values: rps}})
+		sv.LatencyChart = lineChart("Latency percentiles", "ms", []chartLine{
+			{Name: "p50", Color: "#2eb872", Values: p50},
+			{Name: "p90", Color: "#f0a020", Values: p90},
+			{Name: "p99", Color: "#e5484d", Values: p99},
+		})
+		sv.ErrorChart = lineChart("Error rate", "%", []chartLine{{Name: "errors", Color: "#e5484d", Values: errRate}})
+
+		codes := make([]int, 0, len(summary.StatusCodeDist))
+		for c := range summary.StatusCodeDist {
+			codes = append(codes, c)
+		}
+		sort.Ints(codes)
+		for _, c := range codes {
+			sv.StatusCodes = append(sv.StatusCodes, htmlKV{
+				Key:   fmt.Sprintf("%d %s", c, http.StatusText(c)),
+				Value: strconv.Itoa(summary.StatusCodeDist[c]),
+			})
+		}
+		for _, s := range sortedKeys(summary.GrpcStatusDist) {
+			sv.StatusCodes = append(sv.StatusCodes, htmlKV{
+				Key:   "gRPC " + s,
+				Value: strconv.Itoa(summary.GrpcStatusDist[s]),
+			})
+		}
+
+		durs := make([]duration, 0, len(summary.Durations))
+		for k, d := range summary.Durations {
+			dur := keyToStr[k]
+			if dur.name == "" {
+				dur.name = k
+			}
+			dur.duration = d
+			durs = append(durs, dur)
+		}
+		sort.Slice(durs, func(i, j int) bool { return durs[i].order < durs[j].order })
+		for _, d := range durs {
+			sv.Durations = append(sv.Durations, htmlKV{Key: d.name, Value: fmt.Sprintf("%.4fs", d.duration)})
+		}
+
+		for _, k := range sortedDurationKeys(summary.Percentiles) {
+			p := htmlPercentiles{Name: keyToStr[k].name}
+			if p.Name == "" {
+				p.Name = k
+			}
+			for _, pk := range percentileKeys {
+				p.Values = append(p.Values, fmt.Sprintf("%.4fs", summary.Percentiles[k][pk.key]))
+			}
+			sv.Percentiles = append(sv.Percentiles, p)
+		}
+
+		for _, reason := range sortedKeys(summary.Fail.ServerErrorDist.Reasons) {
+			sv.ServerErrors = append(sv.ServerErrors, htmlKV{
+				Key:   reason,
+				Value: strconv.Itoa(summary.Fail.ServerErrorDist.Reasons[reason]),
+			})
+		}
+
+		for _, rule := range sortedKeys(summary.Fail.AssertionErrorDist.Conditions) {
+			info := summary.Fail.AssertionErrorDist.Conditions[rule]
+			a := htmlAssertion{Rule: rule, Count: info.Count, Reason: info.Reason}
+			for _, ident := range sortedKeys(info.Received) {
+				a.Received = append(a.Received, htmlKV{Key: ident, Value: fmt.Sprint(info.Received[ident])})
+			}
+			sv.Assertions = append(sv.Assertions, a)
+		}
+
+		v.Steps = append(v.Steps, sv)
+	}
+	return v
+}
+
+// runConfig returns the load settings of the hammer to be shown in the report.
+func (r *htmlReport) runConfig() []htmlKV {
+	h := r.hammer
+	conf := []htmlKV{
+		{Key: "Iteration Count", Value: strconv.Itoa(h.IterationCount)},
+		{Key: "Duration", Value: fmt.Sprintf("%ds", h.TestDuration)},
+		{Key: "Load Type", Value: h.LoadType},
+	}
+	if h.Executor != "" {
+		conf = append(conf, htmlKV{Key: "Executor", Value: h.Executor})
+	}
+	if h.EngineMode != "" {
+		conf = append(conf, htmlKV{Key: "Engine Mode", Value: h.EngineMode})
+	}
+	for i, s := range h.LoadStages {
+		conf = append(conf, htmlKV{
+			Key:   fmt.Sprintf("Stage %d", i+1),
+			Value: fmt.Sprintf("%s, %d iterations/s for %ds", s.LoadType, s.Rate, s.Duration),
+		})
+	}
+	if h.VUs > 0 {
+		conf = append(conf, htmlKV{Key: "Virtual Users", Value: strconv.Itoa(h.VUs)})
+	}
+	for i, s := range h.VUStages {
+		conf = append(conf, htmlKV{
+			Key:   fmt.Sprintf("VU Stage %d", i+1),
+			Value: fmt.Sprintf("%d virtual users in %ds", s.Target, s.Duration),
+		})
+	}
+	if h.Proxy.Addr != nil {
+		conf = append(conf, htmlKV{Key: "Proxy", Value: h.Proxy.Addr.Redacted()})
+	}
+	return conf
+}
+
+type chartLine struct {
+	Name   string
+	Color  string
+	Values []float64
+}
+
+// lineChart draws the lines as an inline svg. X axis is the seconds of the test.
+func lineChart(title, unit string, lines []chartLine) template.HTML {
+	const width, height = 720.0, 220.0
+	const left, right, top, bottom = 56.0, 16.0, 32.0, 28.0
+	plotW, plotH := width-left-right, height-top-bottom
+
+	n, max := 0, 0.0
+	for _, l := range lines {
+		if len(l.Values) > n {
+			n = len(l.Values)
+		}
+		for _, v := range l.Values {
+			max = math.Max(max, v)
+		}
+	}
+	max = niceCeil(max)
+
+	x := func(i int) float64 {
+		if n < 2 {
+			return left + plotW/2
+		}
+		return left + plotW*float64(i)/float64(n-1)
+	}
+	y := func(v float64) float64 {
+		return top + plotH*(1-v/max)
+	}
+
+	b := strings.Builder{}
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %.0f %.0f" role="img" aria-label="%s">`,
+		width, height, html.EscapeString(title))
+	fmt.Fprintf(&b, `<text x="%.0f" y="16" class="title">%s</text>`, left, html.EscapeString(title))
+
+	// legend
+	lx := width - right
+	for i := len(lines) - 1; i >= 0; i-- {
+		lx -= 8 + 7*float64(len(lines[i].Name))
+		fmt.Fprintf(&b, `<text x="%.1f" y="16" fill="%s">%s</text>`, lx, lines[i].Color, html.EscapeString(lines[i].Name))
+		lx -= 16
+		fmt.Fprintf(&b, `<rect x="%.1f" y="8" width="10" height="10" fill="%s"/>`, lx, lines[i].Color)
+	}
+
+	// grid and y axis
+	for i := 0; i <= 4; i++ {
+		v := max * float64(i) / 4
+		fmt.Fprintf(&b, `<line x1="%.0f" x2="%.0f" y1="%.1f" y2="%.1f" class="grid"/>`, left, width-right, y(v), y(v))
+		fmt.Fprintf(&b, `<text x="%.0f" y="%.1f" class="axis" text-anchor="end">%s%s</text>`,
+			left-6, y(v)+4, formatChartValue(v), html.EscapeString(unit))
+	}
+
+	// x axis
+	tick := int(math.Ceil(float64(n) / 10))
+	if tick < 1 {
+		tick = 1
+	}
+	for i := 0; i < n; i += tick {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.0f" class="axis" text-anchor="middle">%ds</text>`, x(i), height-8, i)
+	}
+
+	if n == 0 {
+		fmt.Fprintf(&b, `<text x="%.0f" y="%.0f" class="axis" text-anchor="middle">no data</text>`,
+			left+plotW/2, top+plotH/2)
+	}
+
+	for _, l := range lines {
+		if len(l.Values) == 1 {
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"/>`, x(0), y(l.Values[0]), l.Color)
+			continue
+		}
+		points := make([]string, 0, len(l.Values))
+		for i, v := range l.Values {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(v)))
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`,
+			strings.Join(points, " "), l.Color)
+	}
+	b.WriteString(`</svg>`)
+
+	return template.HTML(b.String())
+}
+
+// niceCeil rounds the max value of a chart up to 1, 2 or 5 times a power of 10.
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	exp := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if v <= m*exp {
+			return m * exp
+		}
+	}
+	return 10 * exp
+}
+
+func formatChartValue(v float64) string {
+	switch {
+	case v == 0:
+		return "0"
+	case v >= 100:
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	case v >= 1:
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,158 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import "html/template"
+
+var htmlTemplate = template.Must(template.New("report").Parse(htmlReportTemplate))
+
+// htmlReportTemplate is the template of the html output. Styles are inlined and charts are svg,
+// so the report has no external assets.
+const htmlReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Ddosify Load Test Report</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; background: #f6f8fa; margin: 0; }
+  main { max-width: 960px; margin: 0 auto; padding: 24px; }
+  h1 { font-size: 24px; margin: 0 0 4px; }
+  h2 { font-size: 18px; margin: 32px 0 12px; border-bottom: 1px solid #d0d7de; padding-bottom: 6px; }
+  h3 { font-size: 15px; margin: 20px 0 8px; }
+  .muted { color: #656d76; font-size: 13px; }
+  .card { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 16px; margin-bottom: 16px; }
+  .stats { display: flex; gap: 12px; flex-wrap: wrap; }
+  .stat { flex: 1; min-width: 140px; background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 12px; }
+  .stat .value { font-size: 22px; font-weight: 600; }
+  .success { color: #1a7f37; }
+  .failed { color: #cf222e; }
+  table { border-collapse: collapse; width: 100%; font-size: 13px; }
+  th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #eaeef2; vertical-align: top; }
+  th { color: #656d76; font-weight: 600; }
+  code { font-family: SFMono-Regular, Consolas, monospace; font-size: 12px; word-break: break-all; }
+  .grid2 { display: grid; grid-template-columns: 1fr 1fr; gap: 16px; }
+  svg.chart { width: 100%; height: auto; font-size: 11px; }
+  svg.chart .title { font-size: 13px; font-weight: 600; fill: #1f2328; }
+  svg.chart .axis { fill: #656d76; }
+  svg.chart .grid { stroke: #eaeef2; }
+</style>
+</head>
+<body>
+<main>
+  <h1>Ddosify Load Test Report</h1>
+  <div class="muted">Generated at {{.GeneratedAt}}</div>
+
+  <h2>Summary</h2>
+  <div class="stats">
+    <div class="stat"><div class="muted">Test Status</div>
+      <div class="value {{if eq .Result.TestStatus "success"}}success{{else}}failed{{end}}">{{.Result.TestStatus}}</div></div>
+    <div class="stat"><div class="muted">Successful Runs</div>
+      <div class="value success">{{.Result.SuccessCount}} ({{.SuccessPercent}}%)</div></div>
+    <div class="stat"><div class="muted">Failed Runs</div>
+      <div class="value failed">{{.Result.ServerFailedCount}} + {{.Result.AssertionFailCount}} ({{.FailedPercent}}%)</div>
+      <div class="muted">server + assertion</div></div>
+    <div class="stat"><div class="muted">Avg. Duration</div>
+      <div class="value">{{printf "%.4f" .Result.AvgDuration}}s</div></div>
+  </div>
+  {{if .Result.DroppedCount}}<p class="muted">Dropped iterations: {{.Result.DroppedCount}} (no free virtual user at the scheduled time)</p>{{end}}
+  {{if .Result.TestFailedAssertions}}
+  <div class="card">
+    <h3 class="failed">Failed Success Criterias</h3>
+    <table>
+      <tr><th>Rule</th><th>Received</th></tr>
+      {{range .Result.TestFailedAssertions}}<tr><td><code>{{.Rule}}</code></td><td>{{range $k, $v := .ReceivedMap}}<div><code>{{$k}}: {{$v}}</code></div>{{end}}</td></tr>{{end}}
+    </table>
+  </div>
+  {{end}}
+
+  <h2>Run Configuration</h2>
+  <div class="grid2">
+    <div class="card">
+      <table>
+        {{range .Config}}<tr><th>{{.Key}}</th><td>{{.Value}}</td></tr>{{end}}
+      </table>
+    </div>
+    <div class="card">
+      <table>
+        <tr><th>Step</th><th>Method</th><th>Target</th></tr>
+        {{range .ScenarioSteps}}<tr><td>{{.ID}}{{if .Name}} - {{.Name}}{{end}}</td><td>{{.Method}}</td><td><code>{{.URL}}</code></td></tr>{{end}}
+      </table>
+    </div>
+  </div>
+
+  {{range .Steps}}
+  <h2>{{.ID}}. {{.Name}}</h2>
+  <div class="stats">
+    <div class="stat"><div class="muted">Success Count</div><div class="value success">{{.Summary.SuccessCount}}</div></div>
+    <div class="stat"><div class="muted">Failed Count</div><div class="value failed">{{.Summary.Fail.Count}}</div></div>
+  </div>
+  <div class="card">{{.RpsChart}}</div>
+  <div class="card">{{.LatencyChart}}</div>
+  <div class="card">{{.ErrorChart}}</div>
+  <div class="grid2">
+    <div class="card">
+      <h3>Status Codes</h3>
+      <table>
+        <tr><th>Status Code</th><th>Count</th></tr>
+        {{range .StatusCodes}}<tr><td>{{.Key}}</td><td>{{.Value}}</td></tr>{{else}}<tr><td colspan="2" class="muted">No response</td></tr>{{end}}
+      </table>
+    </div>
+    <div class="card">
+      <h3>Durations (Avg)</h3>
+      <table>
+        {{range .Durations}}<tr><td>{{.Key}}</td><td>{{.Value}}</td></tr>{{end}}
+      </table>
+    </div>
+  </div>
+  {{if .Percentiles}}
+  <div class="card">
+    <h3>Percentiles</h3>
+    <table>
+      <tr><th></th>{{range $.PercentileKeys}}<th>{{.}}</th>{{end}}</tr>
+      {{range .Percentiles}}<tr><td>{{.Name}}</td>{{range .Values}}<td>{{.}}</td>{{end}}</tr>{{end}}
+    </table>
+  </div>
+  {{end}}
+  {{if .ServerErrors}}
+  <div class="card">
+    <h3 class="failed">Server Errors</h3>
+    <table>
+      <tr><th>Reason</th><th>Count</th></tr>
+      {{range .ServerErrors}}<tr><td>{{.Key}}</td><td>{{.Value}}</td></tr>{{end}}
+    </table>
+  </div>
+  {{end}}
+  {{if .Assertions}}
+  <div class="card">
+    <h3 class="failed">Failed Assertions</h3>
+    <table>
+      <tr><th>Condition</th><th>Fail Count</th><th>Received</th><th>Reason</th></tr>
+      {{range .Assertions}}<tr><td><code>{{.Rule}}</code></td><td>{{.Count}}</td>
+        <td>{{range .Received}}<div><code>{{.Key}}: {{.Value}}</code></div>{{end}}</td><td>{{.Reason}}</td></tr>{{end}}
+    </table>
+  </div>
+  {{end}}
+  {{end}}
+</main>
+</body>
+</html>
+`
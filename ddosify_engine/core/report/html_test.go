@@ -0,0 +1,141 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+func TestHtmlReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	h := types.Hammer{
+		IterationCount: 3,
+		TestDuration:   2,
+		LoadType:       types.LoadTypeLinear,
+		Html:           types.HtmlConf{Path: path},
+		Scenario: types.Scenario{Steps: []types.ScenarioStep{
+			{ID: 1, Name: "login", Method: "POST", URL: "https://test.com/login"},
+		}},
+	}
+
+	r := &htmlReport{}
+	r.Configure(h)
+	if err := r.Init(false, 0); err != nil {
+		t.Fatalf("TestHtmlReport init error: %v", err)
+	}
+
+	input := make(chan *types.ScenarioResult, 10)
+	go r.Start(input, nil)
+
+	now := time.Now()
+	input <- &types.ScenarioResult{StepResults: []*types.ScenarioStepResult{
+		{StepID: 1, StepName: "login", StatusCode: 200, RequestTime: now, Duration: 100 * time.Millisecond},
+	}}
+	input <- &types.ScenarioResult{StepResults: []*types.ScenarioStepResult{
+		{StepID: 1, StepName: "login", StatusCode: 401, RequestTime: now.Add(2 * time.Second), Duration: 50 * time.Millisecond,
+			FailedAssertions: []types.FailedAssertion{{
+				Rule:     "equals(status_code, 200)",
+				Received: map[string]interface{}{"status_code": 401},
+				Reason:   "not equal",
+			}}},
+	}}
+	input <- &types.ScenarioResult{StepResults: []*types.ScenarioStepResult{
+		{StepID: 1, StepName: "login", RequestTime: now.Add(2 * time.Second), Duration: time.Second,
+			Err: types.RequestError{Type: types.ErrorConn, Reason: types.ReasonConnTimeout}},
+	}}
+	close(input)
+	<-r.DoneChan()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("TestHtmlReport report could not be read: %v", err)
+	}
+	report := string(b)
+
+	expected := []string{
+		"1. login",
+		"https://test.com/login",
+		"Requests per second",
+		"Latency percentiles",
+		"Error rate",
+		"<h3>Percentiles</h3>",
+		"<th>p99.9</th>",
+		"200 OK",
+		"401 Unauthorized",
+		"equals(status_code, 200)",
+		"status_code: [401]",
+		"not equal",
+		"connection timeout",
+		"<polyline",
+	}
+	for _, e := range expected {
+		if !strings.Contains(report, e) {
+			t.Errorf("TestHtmlReport report should contain %q", e)
+		}
+	}
+
+	for _, external := range []string{"<script src", "<link", "<img"} {
+		if strings.Contains(report, external) {
+			t.Errorf("TestHtmlReport report should not have external assets, found %q", external)
+		}
+	}
+}
+
+func TestHtmlTimeline(t *testing.T) {
+	r := &htmlReport{}
+	r.Init(false, 0)
+	r.start = time.Now()
+
+	for i, d := range []time.Duration{10, 20, 30, 40} {
+		r.add(&types.ScenarioResult{StepResults: []*types.ScenarioStepResult{
+			{StepID: 1, RequestTime: r.start.Add(time.Duration(i/2) * time.Second), Duration: d * time.Millisecond},
+		}})
+	}
+	r.add(&types.ScenarioResult{StepResults: []*types.ScenarioStepResult{
+		{StepID: 1, RequestTime: r.start.Add(1500 * time.Millisecond), Err: types.RequestError{Type: types.ErrorConn}},
+	}})
+
+	timeline := r.timelines[1]
+	if len(timeline) != 2 {
+		t.Fatalf("Expected 2 seconds, Found: %d", len(timeline))
+	}
+	if timeline[0].count != 2 || timeline[1].count != 3 || timeline[1].failed != 1 {
+		t.Errorf("Unexpected counts: %+v, %+v", timeline[0], timeline[1])
+	}
+	if timeline[1].durations.Count() != 2 {
+		t.Errorf("Durations of the failed requests should not be added, Found: %d", timeline[1].durations.Count())
+	}
+}
+
+func TestNiceCeil(t *testing.T) {
+	tests := map[float64]float64{0: 1, 0.3: 0.5, 1: 1, 1.2: 2, 4: 5, 7: 10, 120: 200}
+	for v, expected := range tests {
+		if c := niceCeil(v); c != expected {
+			t.Errorf("niceCeil(%v) expected %v, found %v", v, expected, c)
+		}
+	}
+}
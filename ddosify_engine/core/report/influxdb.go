@@ -0,0 +1,380 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+
+	"go.ddosify.com/ddosify/core/assertion"
+	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/util"
+)
+
+const OutputTypeInfluxdb = "influxdb"
+
+const (
+	defaultInfluxdbOrg           = "ddosify"
+	defaultInfluxdbBucket        = "hammerBucket"
+	defaultInfluxdbFlushInterval = 1 // in seconds
+	influxdbTokenEnv             = "INFLUXDB_TOKEN"
+	influxdbMaxBatchSize         = 5000 // lines
+
+	measurementStep          = "ddosify_step"
+	measurementStepAggregate = "ddosify_step_aggregate"
+)
+
+func init() {
+	AvailableOutputServices[OutputTypeInfluxdb] = &influxdbReport{}
+}
+
+// influxdbReport writes the step results to an InfluxDB v2 bucket in line protocol, either one point per step
+// result or the aggregates of the step results per flush interval.
+type influxdbReport struct {
+	stdoutTee
+
+	conf     types.InfluxdbConf
+	writeURL string
+	client   *http.Client
+
+	bufMu    sync.Mutex
+	lines    bytes.Buffer
+	lineCnt  int
+	aggs     map[influxdbTags]*influxdbAgg
+	writeMu  sync.Mutex
+	writeErr error
+}
+
+// influxdbTags are the tags of the points of a step.
+type influxdbTags struct {
+	stepID       uint16
+	stepName     string
+	statusCode   int
+	proxyCountry string
+	errorType    string
+}
+
+// influxdbAgg is the aggregate of the step results with the same tags.
+type influxdbAgg struct {
+	count            int64
+	failedAssertions int64
+	duration         *util.Histogram            // in microseconds
+	phases           map[string]*util.Histogram // in microseconds
+}
+
+// Configure sets the influxdb options of the hammer, it should be called before Init.
+func (i *influxdbReport) Configure(h types.Hammer) error {
+	i.conf = h.Influxdb
+	return i.stdout.Configure(h)
+}
+
+func (i *influxdbReport) Init(debug bool, samplingRate int) (err error) {
+	if i.conf.URL == "" {
+		return fmt.Errorf("url of influxdb is required for the %s output", OutputTypeInfluxdb)
+	}
+	if err = i.stdoutTee.Init(debug, samplingRate); err != nil {
+		return
+	}
+	i.aggs = make(map[influxdbTags]*influxdbAgg)
+	if i.conf.Org == "" {
+		i.conf.Org = defaultInfluxdbOrg
+	}
+	if i.conf.Bucket == "" {
+		i.conf.Bucket = defaultInfluxdbBucket
+	}
+	if i.conf.Token == "" {
+		i.conf.Token = os.Getenv(influxdbTokenEnv)
+	}
+	if i.conf.Mode == "" {
+		i.conf.Mode = types.InfluxdbModeRaw
+	}
+	if i.conf.FlushInterval == 0 {
+		i.conf.FlushInterval = defaultInfluxdbFlushInterval
+	}
+
+	q := url.Values{}
+	q.Set("org", i.conf.Org)
+	q.Set("bucket", i.conf.Bucket)
+	q.Set("precision", "ns")
+	i.writeURL = strings.TrimSuffix(i.conf.URL, "/") + "/api/v2/write?" + q.Encode()
+	i.client = &http.Client{Timeout: 10 * time.Second}
+	return
+}
+
+func (i *influxdbReport) Start(input chan *types.ScenarioResult,
+	assertionResultChan <-chan assertion.TestAssertionResult) {
+	if i.debug {
+		i.done <- i.teeStdout(input, assertionResultChan, nil)
+		return
+	}
+
+	stopFlush := make(chan struct{})
+	flushStopped := make(chan struct{})
+	go i.flushPeriodically(stopFlush, flushStopped)
+
+	success := i.teeStdout(input, assertionResultChan, i.add)
+
+	close(stopFlush)
+	<-flushStopped
+	if err := i.flush(); err != nil {
+		color.Yellow("InfluxDB write failed: %v\n", err)
+	}
+
+	i.done <- success
+}
+
+// add adds the step results of an iteration to the buffer that is written in the next flush.
+func (i *influxdbReport) add(scr *types.ScenarioResult) {
+	if scr.Dropped {
+		return
+	}
+
+	proxyCountry, _ := scr.Others["proxyCountry"].(string)
+
+	i.bufMu.Lock()
+	for _, sr := range scr.StepResults {
+		tags := influxdbTags{
+			stepID:       sr.StepID,
+			stepName:     sr.StepName,
+			statusCode:   sr.StatusCode,
+			proxyCountry: proxyCountry,
+			errorType:    sr.Err.Type,
+		}
+		if i.conf.Mode == types.InfluxdbModeAggregate {
+			i.aggregate(tags, sr)
+			continue
+		}
+		i.writeStepLine(tags, sr)
+		i.lineCnt++
+	}
+	full := i.lineCnt >= influxdbMaxBatchSize
+	i.bufMu.Unlock()
+
+	if full {
+		go i.flush()
+	}
+}
+
+func (i *influxdbReport) writeStepLine(tags influxdbTags, sr *types.ScenarioStepResult) {
+	fields := []string{
+		"duration=" + formatInfluxFloat(sr.Duration.Seconds()),
+		"failed_assertions=" + strconv.Itoa(len(sr.FailedAssertions)) + "i",
+	}
+	if sr.Err.Reason != "" {
+		fields = append(fields, "error_reason="+escapeInfluxString(sr.Err.Reason))
+	}
+	for _, k := range sortedKeys(sr.Custom) {
+		switch v := sr.Custom[k].(type) {
+		case time.Duration:
+			fields = append(fields, escapeInfluxKey(influxFieldName(k))+"="+formatInfluxFloat(v.Seconds()))
+		case int64:
+			fields = append(fields, escapeInfluxKey(influxFieldName(k))+"="+strconv.FormatInt(v, 10)+"i")
+		case float64:
+			fields = append(fields, escapeInfluxKey(influxFieldName(k))+"="+formatInfluxFloat(v))
+		}
+	}
+	i.writeLine(measurementStep, tags, fields, sr.RequestTime)
+}
+
+func (i *influxdbReport) aggregate(tags influxdbTags, sr *types.ScenarioStepResult) {
+	agg, ok := i.aggs[tags]
+	if !ok {
+		agg = &influxdbAgg{
+			duration: util.NewHistogram(i.histogramPrecision),
+			phases:   map[string]*util.Histogram{},
+		}
+		i.aggs[tags] = agg
+	}
+	agg.count++
+	agg.duration.Record(sr.Duration.Microseconds())
+	if len(sr.FailedAssertions) > 0 {
+		agg.failedAssertions++
+	}
+	for k, v := range sr.Custom {
+		if d, ok := v.(time.Duration); ok {
+			h, ok := agg.phases[k]
+			if !ok {
+				h = util.NewHistogram(i.histogramPrecision)
+				agg.phases[k] = h
+			}
+			h.Record(d.Microseconds())
+		}
+	}
+}
+
+// writeAggregates writes the aggregates to the buffer and resets them.
+func (i *influxdbReport) writeAggregates(t time.Time) {
+	for tags, agg := range i.aggs {
+		fields := []string{
+			"count=" + strconv.FormatInt(agg.count, 10) + "i",
+			"failed_assertions=" + strconv.FormatInt(agg.failedAssertions, 10) + "i",
+			"duration_avg=" + formatInfluxFloat(agg.duration.Mean()/1e6),
+			"duration_min=" + formatInfluxFloat(float64(agg.duration.Min())/1e6),
+			"duration_max=" + formatInfluxFloat(float64(agg.duration.Max())/1e6),
+		}
+		fields = append(fields, influxPercentileFields("duration", agg.duration)...)
+		for _, k := range sortedKeys(agg.phases) {
+			name := escapeInfluxKey(influxFieldName(k))
+			fields = append(fields, name+"_avg="+formatInfluxFloat(agg.phases[k].Mean()/1e6))
+			fields = append(fields, influxPercentileFields(name, agg.phases[k])...)
+		}
+		i.writeLine(measurementStepAggregate, tags, fields, t)
+	}
+	i.aggs = make(map[influxdbTags]*influxdbAgg)
+}
+
+// influxPercentileFields returns the percentile fields of the histogram in seconds, like duration_p99_9.
+// The max is not included as it is written with the min.
+func influxPercentileFields(name string, h *util.Histogram) []string {
+	fields := make([]string, 0, len(percentileKeys))
+	for _, pk := range percentileKeys {
+		if pk.percentile >= 100 {
+			continue
+		}
+		key := name + "_" + strings.ReplaceAll(pk.key, ".", "_")
+		fields = append(fields, key+"="+formatInfluxFloat(float64(h.ValueAtPercentile(pk.percentile))/1e6))
+	}
+	return fields
+}
+
+func (i *influxdbReport) writeLine(measurement string, tags influxdbTags, fields []string, t time.Time) {
+	i.lines.WriteString(measurement)
+	i.lines.WriteString(",step_id=")
+	i.lines.WriteString(strconv.Itoa(int(tags.stepID)))
+	if tags.stepName != "" {
+		i.lines.WriteString(",step_name=")
+		i.lines.WriteString(escapeInfluxKey(tags.stepName))
+	}
+	i.lines.WriteString(",status_code=")
+	i.lines.WriteString(strconv.Itoa(tags.statusCode))
+	if tags.proxyCountry != "" {
+		i.lines.WriteString(",proxy_country=")
+		i.lines.WriteString(escapeInfluxKey(tags.proxyCountry))
+	}
+	if tags.errorType != "" {
+		i.lines.WriteString(",error_type=")
+		i.lines.WriteString(escapeInfluxKey(tags.errorType))
+	}
+	i.lines.WriteString(" ")
+	i.lines.WriteString(strings.Join(fields, ","))
+	i.lines.WriteString(" ")
+	i.lines.WriteString(strconv.FormatInt(t.UnixNano(), 10))
+	i.lines.WriteString("\n")
+}
+
+func (i *influxdbReport) flushPeriodically(stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(time.Duration(i.conf.FlushInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := i.flush()
+			// warn once until the writes succeed again
+			if err != nil && i.writeErr == nil {
+				color.Yellow("InfluxDB write failed: %v\n", err)
+			}
+			i.writeErr = err
+		case <-stop:
+			return
+		}
+	}
+}
+
+// flush writes the buffered lines to the bucket. Lines of a failed write are dropped.
+func (i *influxdbReport) flush() error {
+	i.bufMu.Lock()
+	if i.conf.Mode == types.InfluxdbModeAggregate {
+		i.writeAggregates(time.Now())
+	}
+	body := make([]byte, i.lines.Len())
+	copy(body, i.lines.Bytes())
+	i.lines.Reset()
+	i.lineCnt = 0
+	i.bufMu.Unlock()
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	// keep the order of the batches
+	i.writeMu.Lock()
+	defer i.writeMu.Unlock()
+
+	req, err := http.NewRequest(http.MethodPost, i.writeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.conf.Token != "" {
+		req.Header.Set("Authorization", "Token "+i.conf.Token)
+	}
+
+	res, err := i.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("unexpected status code %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// influxFieldName returns the field name of a custom metric, like dns_duration for dnsDuration.
+func influxFieldName(k string) string {
+	if jk, ok := strKeyToJsonKey[k]; ok {
+		if strings.HasSuffix(k, "Duration") {
+			return jk + "_duration"
+		}
+		return jk
+	}
+	return k
+}
+
+var influxKeyEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// escapeInfluxKey escapes the tag keys, tag values and field keys.
+func escapeInfluxKey(s string) string {
+	return influxKeyEscaper.Replace(s)
+}
+
+var influxStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// escapeInfluxString quotes and escapes the string field values.
+func escapeInfluxString(s string) string {
+	return `"` + influxStringEscaper.Replace(s) + `"`
+}
+
+func formatInfluxFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
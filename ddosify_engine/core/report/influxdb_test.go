@@ -0,0 +1,197 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+// influxdbStub is a stub of the InfluxDB v2 write endpoint.
+type influxdbStub struct {
+	*httptest.Server
+	mu      sync.Mutex
+	lines   []string
+	queries []string
+	auth    string
+}
+
+func newInfluxdbStub() *influxdbStub {
+	s := &influxdbStub{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.URL.Path != "/api/v2/write" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		s.queries = append(s.queries, r.URL.RawQuery)
+		s.auth = r.Header.Get("Authorization")
+		s.lines = append(s.lines, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return s
+}
+
+func influxdbTestResults() []*types.ScenarioResult {
+	reqTime := time.Unix(1700000000, 0)
+	return []*types.ScenarioResult{
+		{
+			Others: map[string]interface{}{"proxyCountry": "unknown"},
+			StepResults: []*types.ScenarioStepResult{
+				{
+					StepID:      1,
+					StepName:    "user login",
+					StatusCode:  200,
+					RequestTime: reqTime,
+					Duration:    100 * time.Millisecond,
+					Custom: map[string]interface{}{
+						"dnsDuration":        10 * time.Millisecond,
+						"receivedFrameCount": int64(3),
+					},
+				},
+				{
+					StepID:      2,
+					StepName:    "order",
+					RequestTime: reqTime,
+					Duration:    time.Second,
+					Err:         types.RequestError{Type: types.ErrorConn, Reason: types.ReasonConnTimeout},
+				},
+			},
+		},
+		{
+			Others: map[string]interface{}{"proxyCountry": "unknown"},
+			StepResults: []*types.ScenarioStepResult{
+				{
+					StepID:           1,
+					StepName:         "user login",
+					StatusCode:       200,
+					RequestTime:      reqTime,
+					Duration:         300 * time.Millisecond,
+					Custom:           map[string]interface{}{"dnsDuration": 30 * time.Millisecond},
+					FailedAssertions: []types.FailedAssertion{{Rule: "equals(status_code, 201)"}},
+				},
+			},
+		},
+		{
+			Dropped: true,
+		},
+	}
+}
+
+func runInfluxdbReport(t *testing.T, conf types.InfluxdbConf) {
+	i := &influxdbReport{}
+	i.Configure(types.Hammer{Influxdb: conf})
+	if err := i.Init(false, 0); err != nil {
+		t.Fatalf("influxdb init error: %v", err)
+	}
+
+	input := make(chan *types.ScenarioResult, 10)
+	go i.Start(input, nil)
+	for _, r := range influxdbTestResults() {
+		input <- r
+	}
+	close(input)
+	<-i.DoneChan()
+}
+
+func TestInfluxdbInitWithoutUrl(t *testing.T) {
+	i := &influxdbReport{}
+	if err := i.Init(false, 0); err == nil {
+		t.Errorf("TestInfluxdbInitWithoutUrl should be errored")
+	}
+}
+
+func TestInfluxdbRaw(t *testing.T) {
+	stub := newInfluxdbStub()
+	defer stub.Close()
+
+	runInfluxdbReport(t, types.InfluxdbConf{URL: stub.URL, Org: "ddosify", Bucket: "test", Token: "secret"})
+
+	expectedLines := []string{
+		`ddosify_step,step_id=1,step_name=user\ login,status_code=200,proxy_country=unknown ` +
+			`duration=0.1,failed_assertions=0i,dns_duration=0.01,received_frames=3i 1700000000000000000`,
+		`ddosify_step,step_id=2,step_name=order,status_code=0,proxy_country=unknown,error_type=connectionError ` +
+			`duration=1,failed_assertions=0i,error_reason="connection timeout" 1700000000000000000`,
+		`ddosify_step,step_id=1,step_name=user\ login,status_code=200,proxy_country=unknown ` +
+			`duration=0.3,failed_assertions=1i,dns_duration=0.03 1700000000000000000`,
+	}
+	if len(stub.lines) != len(expectedLines) {
+		t.Fatalf("Expected lines: %v, Found: %v", expectedLines, stub.lines)
+	}
+	for idx, l := range expectedLines {
+		if stub.lines[idx] != l {
+			t.Errorf("Expected line: %s, Found: %s", l, stub.lines[idx])
+		}
+	}
+
+	if stub.auth != "Token secret" {
+		t.Errorf("Expected authorization: Token secret, Found: %s", stub.auth)
+	}
+	if stub.queries[0] != "bucket=test&org=ddosify&precision=ns" {
+		t.Errorf("Unexpected write query: %s", stub.queries[0])
+	}
+}
+
+func TestInfluxdbAggregate(t *testing.T) {
+	stub := newInfluxdbStub()
+	defer stub.Close()
+
+	runInfluxdbReport(t, types.InfluxdbConf{URL: stub.URL, Mode: types.InfluxdbModeAggregate})
+
+	if len(stub.lines) != 2 {
+		t.Fatalf("Expected 2 lines, Found: %v", stub.lines)
+	}
+	var login string
+	for _, l := range stub.lines {
+		if strings.HasPrefix(l, "ddosify_step_aggregate,step_id=1,") {
+			login = l
+		}
+	}
+	// percentiles are the highest values equivalent to the recorded ones in 3 significant digits
+	expectedFields := "count=2i,failed_assertions=1i,duration_avg=0.2,duration_min=0.1,duration_max=0.3," +
+		"duration_p50=0.100031,duration_p90=0.3,duration_p95=0.3,duration_p99=0.3,duration_p99_9=0.3," +
+		"dns_duration_avg=0.02,dns_duration_p50=0.010007,dns_duration_p90=0.03,dns_duration_p95=0.03," +
+		"dns_duration_p99=0.03,dns_duration_p99_9=0.03 "
+	if !strings.Contains(login, expectedFields) {
+		t.Errorf("Expected fields: %s, Found: %s", expectedFields, login)
+	}
+	if stub.queries[0] != "bucket=hammerBucket&org=ddosify&precision=ns" {
+		t.Errorf("Unexpected write query: %s", stub.queries[0])
+	}
+}
+
+func TestEscapeInflux(t *testing.T) {
+	if k := escapeInfluxKey(`a b,c=d`); k != `a\ b\,c\=d` {
+		t.Errorf("Expected: %s, Found: %s", `a\ b\,c\=d`, k)
+	}
+	if s := escapeInfluxString(`say "hi" \o/`); s != `"say \"hi\" \\o/"` {
+		t.Errorf("Expected: %s, Found: %s", `"say \"hi\" \\o/"`, s)
+	}
+}
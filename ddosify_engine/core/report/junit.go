@@ -0,0 +1,266 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"go.ddosify.com/ddosify/core/assertion"
+	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/util"
+)
+
+const OutputTypeJunit = "junit"
+
+const defaultJunitPath = "ddosify_junit.xml"
+
+func init() {
+	AvailableOutputServices[OutputTypeJunit] = &junitReport{}
+}
+
+// junitReport writes the step assertions and the success criterias as JUnit XML testcases at the end of the test.
+type junitReport struct {
+	stdoutTee
+
+	conf       types.JunitConf
+	hammer     types.Hammer
+	testResult assertion.TestAssertionResult
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// Configure keeps the hammer to get the configured assertions, it should be called before Init.
+func (j *junitReport) Configure(h types.Hammer) error {
+	j.conf = h.Junit
+	j.hammer = h
+	return j.stdout.Configure(h)
+}
+
+func (j *junitReport) Init(debug bool, samplingRate int) (err error) {
+	return j.stdoutTee.Init(debug, samplingRate)
+}
+
+func (j *junitReport) Start(input chan *types.ScenarioResult,
+	assertionResultChan <-chan assertion.TestAssertionResult) {
+	if j.debug {
+		j.done <- j.teeStdout(input, assertionResultChan, nil)
+		return
+	}
+
+	start := time.Now()
+
+	// the test assertion result is kept to report the aborted tests
+	var stdoutAssertionChan chan assertion.TestAssertionResult
+	if assertionResultChan != nil {
+		stdoutAssertionChan = make(chan assertion.TestAssertionResult, 1)
+		go func() {
+			res := <-assertionResultChan
+			j.testResult = res
+			stdoutAssertionChan <- res
+		}()
+	}
+
+	success := j.teeStdout(input, stdoutAssertionChan, nil)
+
+	path := j.conf.Path
+	if path == "" {
+		path = defaultJunitPath
+	}
+	if err := j.write(path, time.Since(start)); err != nil {
+		color.Red("JUnit report could not be written: %v\n", err)
+	} else {
+		color.Cyan("JUnit report is written to %s\n", path)
+	}
+
+	j.done <- success
+}
+
+func (j *junitReport) write(path string, elapsed time.Duration) error {
+	b, err := xml.MarshalIndent(j.testSuites(elapsed), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(b, '\n')...), 0644)
+}
+
+func (j *junitReport) testSuites(elapsed time.Duration) junitTestSuites {
+	suites := junitTestSuites{
+		Name: "ddosify",
+		Time: fmt.Sprintf("%.3f", elapsed.Seconds()),
+	}
+
+	for _, step := range j.hammer.Scenario.Steps {
+		if suite, ok := j.stepSuite(step); ok {
+			suites.Suites = append(suites.Suites, suite)
+		}
+	}
+	if len(j.hammer.Assertions) > 0 {
+		suites.Suites = append(suites.Suites, j.testSuite())
+	}
+
+	for _, s := range suites.Suites {
+		suites.Tests += s.Tests
+		suites.Failures += s.Failures
+	}
+	return suites
+}
+
+// stepSuite returns the testsuite of the assertions of the step, a testcase for each rule.
+func (j *junitReport) stepSuite(step types.ScenarioStep) (junitTestSuite, bool) {
+	summary := j.result.StepResults[step.ID]
+
+	rules := append([]string{}, step.Assertions...)
+	if summary != nil {
+		for _, rule := range sortedKeys(summary.Fail.AssertionErrorDist.Conditions) {
+			if !util.StringInSlice(rule, rules) {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	if len(rules) == 0 {
+		return junitTestSuite{}, false
+	}
+
+	name := step.Name
+	if name == "" {
+		name = fmt.Sprintf("Step %d", step.ID)
+	}
+	suite := junitTestSuite{Name: fmt.Sprintf("%d. %s", step.ID, name)}
+	className := fmt.Sprintf("ddosify.step_%d", step.ID)
+	for _, rule := range rules {
+		tc := junitTestCase{Name: rule, ClassName: className}
+		if summary == nil {
+			tc.Skipped = &junitSkipped{Message: "step is not run"}
+			suite.Skipped++
+		} else if info, ok := summary.Fail.AssertionErrorDist.Conditions[rule]; ok {
+			runs := summary.SuccessCount + summary.Fail.Count
+			message := fmt.Sprintf("failed %d of %d runs", info.Count, runs)
+			if info.Reason != "" {
+				message += ": " + info.Reason
+			}
+			tc.Failure = &junitFailure{
+				Message: message,
+				Type:    "assertion",
+				Text:    receivedText(info.Received),
+			}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Tests = len(suite.TestCases)
+	return suite, true
+}
+
+// testSuite returns the testsuite of the success criterias, a testcase for each rule.
+func (j *junitReport) testSuite() junitTestSuite {
+	suite := junitTestSuite{Name: "Success Criterias"}
+
+	failed := make(map[string]assertion.FailedRule)
+	for _, fr := range j.testResult.FailedRules {
+		failed[fr.Rule] = fr
+	}
+
+	for _, rule := range sortedKeys(j.hammer.Assertions) {
+		tc := junitTestCase{Name: rule, ClassName: "ddosify.success_criterias"}
+		if fr, ok := failed[rule]; ok {
+			message := "success criteria failed"
+			if j.testResult.Aborted && j.hammer.Assertions[rule].Abort {
+				message += ", test is aborted"
+			}
+			received := make(map[string][]interface{}, len(fr.ReceivedMap))
+			for ident, v := range fr.ReceivedMap {
+				received[ident] = []interface{}{v}
+			}
+			tc.Failure = &junitFailure{
+				Message: message,
+				Type:    "success_criteria",
+				Text:    receivedText(received),
+			}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Tests = len(suite.TestCases)
+	return suite
+}
+
+// receivedText returns the received values of a failed rule as the text of the failure.
+func receivedText(received map[string][]interface{}) string {
+	if len(received) == 0 {
+		return ""
+	}
+	idents := make([]string, 0, len(received))
+	for ident := range received {
+		idents = append(idents, ident)
+	}
+	sort.Strings(idents)
+
+	b := strings.Builder{}
+	b.WriteString("Received:\n")
+	for _, ident := range idents {
+		values := received[ident]
+		if len(values) == 1 {
+			fmt.Fprintf(&b, "  %s: %v\n", ident, values[0])
+		} else {
+			fmt.Fprintf(&b, "  %s: %v\n", ident, values)
+		}
+	}
+	return b.String()
+}
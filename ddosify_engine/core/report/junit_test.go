@@ -0,0 +1,134 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.ddosify.com/ddosify/core/assertion"
+	"go.ddosify.com/ddosify/core/types"
+)
+
+func TestJunitReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	h := types.Hammer{
+		Junit: types.JunitConf{Path: path},
+		Scenario: types.Scenario{Steps: []types.ScenarioStep{
+			{ID: 1, Name: "login", Assertions: []string{"equals(status_code, 200)", "less_than(response_time, 1000)"}},
+			{ID: 2, Name: "order"},
+			{ID: 3, Name: "logout", Assertions: []string{"equals(status_code, 204)"}},
+		}},
+		Assertions: map[string]types.TestAssertionOpt{
+			"fail_count_perc < 0.1": {Abort: true},
+			"p90 < 500":             {},
+		},
+	}
+
+	j := &junitReport{}
+	j.Configure(h)
+	if err := j.Init(false, 0); err != nil {
+		t.Fatalf("TestJunitReport init error: %v", err)
+	}
+
+	input := make(chan *types.ScenarioResult, 10)
+	assertionResultChan := make(chan assertion.TestAssertionResult, 1)
+	go j.Start(input, assertionResultChan)
+
+	input <- &types.ScenarioResult{StepResults: []*types.ScenarioStepResult{
+		{StepID: 1, StepName: "login", StatusCode: 200, Duration: 100 * time.Millisecond},
+		{StepID: 2, StepName: "order", StatusCode: 200, Duration: 100 * time.Millisecond},
+	}}
+	input <- &types.ScenarioResult{StepResults: []*types.ScenarioStepResult{
+		{StepID: 1, StepName: "login", StatusCode: 401, Duration: 100 * time.Millisecond,
+			FailedAssertions: []types.FailedAssertion{{
+				Rule:     "equals(status_code, 200)",
+				Received: map[string]interface{}{"status_code": 401},
+				Reason:   "not equal",
+			}}},
+	}}
+	close(input)
+	assertionResultChan <- assertion.TestAssertionResult{
+		Fail:    true,
+		Aborted: true,
+		FailedRules: []assertion.FailedRule{
+			{Rule: "fail_count_perc < 0.1", ReceivedMap: map[string]interface{}{"fail_count_perc": 0.5}},
+		},
+	}
+	if <-j.DoneChan() {
+		t.Errorf("TestJunitReport test should be failed")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("TestJunitReport report could not be read: %v", err)
+	}
+	if !strings.HasPrefix(string(b), xml.Header) {
+		t.Errorf("TestJunitReport report should start with the xml header")
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(b, &suites); err != nil {
+		t.Fatalf("TestJunitReport report could not be parsed: %v", err)
+	}
+
+	if suites.Tests != 5 || suites.Failures != 2 {
+		t.Errorf("Expected tests: 5, failures: 2, Found: %d, %d", suites.Tests, suites.Failures)
+	}
+	if len(suites.Suites) != 3 {
+		t.Fatalf("Expected 3 testsuites, Found: %d", len(suites.Suites))
+	}
+
+	login := suites.Suites[0]
+	if login.Name != "1. login" || login.Tests != 2 || login.Failures != 1 {
+		t.Errorf("Unexpected login suite: %+v", login)
+	}
+	failure := login.TestCases[0].Failure
+	if failure == nil || failure.Message != "failed 1 of 2 runs: not equal" ||
+		!strings.Contains(failure.Text, "status_code: 401") {
+		t.Errorf("Unexpected login failure: %+v", failure)
+	}
+	if login.TestCases[1].Failure != nil {
+		t.Errorf("%s should be passed", login.TestCases[1].Name)
+	}
+
+	logout := suites.Suites[1]
+	if logout.Skipped != 1 || logout.TestCases[0].Skipped == nil {
+		t.Errorf("Assertions of the steps that are not run should be skipped: %+v", logout)
+	}
+
+	criterias := suites.Suites[2]
+	if criterias.Name != "Success Criterias" || criterias.Tests != 2 || criterias.Failures != 1 {
+		t.Errorf("Unexpected success criterias suite: %+v", criterias)
+	}
+	failure = criterias.TestCases[0].Failure
+	if failure == nil || failure.Message != "success criteria failed, test is aborted" ||
+		!strings.Contains(failure.Text, "fail_count_perc: 0.5") {
+		t.Errorf("Unexpected success criteria failure: %+v", failure)
+	}
+	if criterias.TestCases[1].Failure != nil {
+		t.Errorf("%s should be passed", criterias.TestCases[1].Name)
+	}
+}
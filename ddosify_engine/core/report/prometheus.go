@@ -0,0 +1,354 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"go.ddosify.com/ddosify/core/assertion"
+	"go.ddosify.com/ddosify/core/types"
+)
+
+const OutputTypePrometheus = "prometheus"
+
+const (
+	defaultPrometheusAddr         = ":9901"
+	defaultPrometheusPushInterval = 10 // in seconds
+	reasonAssertionFail           = "assertion failure"
+)
+
+func init() {
+	AvailableOutputServices[OutputTypePrometheus] = &prometheusReport{}
+}
+
+// prometheusReport exposes the live metrics of the steps on a /metrics endpoint and/or pushes them to a
+// Prometheus remote-write endpoint.
+type prometheusReport struct {
+	stdoutTee
+
+	conf     types.PrometheusConf
+	listener net.Listener
+	server   *http.Server
+	pushErr  error
+
+	registry   *prometheus.Registry
+	iterations *prometheus.CounterVec
+	requests   *prometheus.CounterVec
+	failures   *prometheus.CounterVec
+	durations  *prometheus.HistogramVec
+	phases     *prometheus.HistogramVec
+}
+
+// Configure sets the prometheus options of the hammer, it should be called before Init.
+func (p *prometheusReport) Configure(h types.Hammer) error {
+	p.conf = h.Prometheus
+	return p.stdout.Configure(h)
+}
+
+func (p *prometheusReport) Init(debug bool, samplingRate int) (err error) {
+	if err = p.stdoutTee.Init(debug, samplingRate); err != nil {
+		return
+	}
+	if p.conf.ListenAddr == "" && p.conf.RemoteWriteURL == "" {
+		p.conf.ListenAddr = defaultPrometheusAddr
+	}
+	if p.conf.PushInterval == 0 {
+		p.conf.PushInterval = defaultPrometheusPushInterval
+	}
+
+	p.registry = prometheus.NewRegistry()
+	stepLabels := []string{"step_id", "step_name"}
+	p.iterations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ddosify_iterations_total",
+		Help: "Total number of the scenario iterations by status.",
+	}, []string{"status"})
+	p.requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ddosify_step_requests_total",
+		Help: "Total number of the requests sent by the step.",
+	}, stepLabels)
+	p.failures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ddosify_step_failures_total",
+		Help: "Total number of the failed requests of the step by reason.",
+	}, append(stepLabels, "reason"))
+	p.durations = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ddosify_step_duration_seconds",
+		Help:    "Total duration of the requests of the step.",
+		Buckets: prometheus.DefBuckets,
+	}, stepLabels)
+	p.phases = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ddosify_step_phase_duration_seconds",
+		Help:    "Duration of the request phases of the step, like dns, connection and tls.",
+		Buckets: prometheus.DefBuckets,
+	}, append(stepLabels, "phase"))
+	p.registry.MustRegister(p.iterations, p.requests, p.failures, p.durations, p.phases, quantileCollector{p})
+
+	if p.conf.ListenAddr != "" && !debug {
+		// listen here to fail before the test starts if the address is in use
+		if p.listener, err = net.Listen("tcp", p.conf.ListenAddr); err != nil {
+			return fmt.Errorf("prometheus metrics endpoint could not be started: %v", err)
+		}
+	}
+	return
+}
+
+func (p *prometheusReport) Start(input chan *types.ScenarioResult,
+	assertionResultChan <-chan assertion.TestAssertionResult) {
+	if p.debug {
+		p.done <- p.teeStdout(input, assertionResultChan, nil)
+		return
+	}
+
+	if p.listener != nil {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{}))
+		p.server = &http.Server{Handler: mux}
+		go p.server.Serve(p.listener)
+		color.Cyan("Prometheus metrics are served on %s/metrics\n", p.listener.Addr())
+	}
+
+	stopPush := make(chan struct{})
+	pushStopped := make(chan struct{})
+	if p.conf.RemoteWriteURL != "" {
+		go p.pushPeriodically(stopPush, pushStopped)
+	} else {
+		close(pushStopped)
+	}
+
+	success := p.teeStdout(input, assertionResultChan, p.observe)
+
+	close(stopPush)
+	<-pushStopped
+	if p.conf.RemoteWriteURL != "" {
+		if err := p.push(); err != nil {
+			color.Yellow("Prometheus remote-write failed: %v\n", err)
+		}
+	}
+	if p.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		p.server.Shutdown(ctx)
+		cancel()
+	}
+
+	p.done <- success
+}
+
+// observe updates the metrics with the result of an iteration.
+func (p *prometheusReport) observe(scr *types.ScenarioResult) {
+	if scr.Dropped {
+		p.iterations.WithLabelValues("dropped").Inc()
+		return
+	}
+
+	status := "success"
+	for _, sr := range scr.StepResults {
+		stepLabels := []string{strconv.Itoa(int(sr.StepID)), sr.StepName}
+		p.requests.WithLabelValues(stepLabels...).Inc()
+
+		if sr.Err.Type != "" && len(sr.FailedAssertions) == 0 {
+			if status == "success" {
+				status = "server_fail"
+			}
+			p.failures.WithLabelValues(append(stepLabels, sr.Err.Reason)...).Inc()
+			continue
+		}
+		if len(sr.FailedAssertions) > 0 {
+			status = "assertion_fail"
+			p.failures.WithLabelValues(append(stepLabels, reasonAssertionFail)...).Inc()
+		}
+
+		p.durations.WithLabelValues(stepLabels...).Observe(sr.Duration.Seconds())
+		for k, v := range sr.Custom {
+			if d, ok := v.(time.Duration); ok && strings.Contains(k, "Duration") {
+				phase, ok := strKeyToJsonKey[k]
+				if !ok {
+					phase = k
+				}
+				p.phases.WithLabelValues(append(stepLabels, phase)...).Observe(d.Seconds())
+			}
+		}
+	}
+	p.iterations.WithLabelValues(status).Inc()
+}
+
+var quantileDesc = prometheus.NewDesc("ddosify_step_duration_quantile_seconds",
+	"Percentiles of the durations of the step and its phases, calculated from the HDR histograms of the results.",
+	[]string{"step_id", "step_name", "phase", "quantile"}, nil)
+
+// quantileCollector collects the percentiles of the duration histograms of the aggregated result.
+type quantileCollector struct {
+	p *prometheusReport
+}
+
+func (c quantileCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- quantileDesc
+}
+
+func (c quantileCollector) Collect(ch chan<- prometheus.Metric) {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+	for id, s := range c.p.result.StepResults {
+		for k, h := range s.histograms {
+			phase, ok := strKeyToJsonKey[k]
+			if !ok {
+				phase = k
+			}
+			for _, pk := range percentileKeys {
+				quantile := strconv.FormatFloat(pk.percentile/100, 'g', -1, 64)
+				ch <- prometheus.MustNewConstMetric(quantileDesc, prometheus.GaugeValue,
+					float64(h.ValueAtPercentile(pk.percentile))/1e6, strconv.Itoa(int(id)), s.Name, phase, quantile)
+			}
+		}
+	}
+}
+
+func (p *prometheusReport) pushPeriodically(stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(time.Duration(p.conf.PushInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := p.push()
+			// warn once until the pushes succeed again
+			if err != nil && p.pushErr == nil {
+				color.Yellow("Prometheus remote-write failed: %v\n", err)
+			}
+			p.pushErr = err
+		case <-stop:
+			return
+		}
+	}
+}
+
+// push sends the current values of the metrics to the remote-write endpoint.
+func (p *prometheusReport) push() error {
+	mfs, err := p.registry.Gather()
+	if err != nil {
+		return err
+	}
+	body := snappy.Encode(nil, encodeWriteRequest(mfs, time.Now().UnixMilli()))
+
+	req, err := http.NewRequest(http.MethodPost, p.conf.RemoteWriteURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	client := &http.Client{Timeout: time.Duration(p.conf.PushInterval) * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	return nil
+}
+
+// promLabel is a label of a remote-write time series.
+type promLabel struct {
+	name  string
+	value string
+}
+
+// encodeWriteRequest encodes the metric families as a remote-write WriteRequest protobuf message.
+// Histograms are written as _bucket, _sum and _count series like in the text exposition format.
+func encodeWriteRequest(mfs []*dto.MetricFamily, timestamp int64) []byte {
+	var req []byte
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			labels := []promLabel{{name: "job", value: "ddosify"}}
+			for _, l := range m.GetLabel() {
+				labels = append(labels, promLabel{name: l.GetName(), value: l.GetValue()})
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				req = appendTimeSeries(req, mf.GetName(), labels, m.GetCounter().GetValue(), timestamp)
+			case dto.MetricType_GAUGE:
+				req = appendTimeSeries(req, mf.GetName(), labels, m.GetGauge().GetValue(), timestamp)
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					le := promLabel{name: "le", value: strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)}
+					req = appendTimeSeries(req, mf.GetName()+"_bucket", append(labels, le),
+						float64(b.GetCumulativeCount()), timestamp)
+				}
+				inf := promLabel{name: "le", value: "+Inf"}
+				req = appendTimeSeries(req, mf.GetName()+"_bucket", append(labels, inf),
+					float64(h.GetSampleCount()), timestamp)
+				req = appendTimeSeries(req, mf.GetName()+"_sum", labels, h.GetSampleSum(), timestamp)
+				req = appendTimeSeries(req, mf.GetName()+"_count", labels, float64(h.GetSampleCount()), timestamp)
+			}
+		}
+	}
+	return req
+}
+
+// appendTimeSeries appends a TimeSeries with a single sample to the WriteRequest message.
+func appendTimeSeries(req []byte, name string, labels []promLabel, value float64, timestamp int64) []byte {
+	// labels of a series should be sorted by name, __name__ comes first
+	sorted := append([]promLabel{{name: "__name__", value: name}}, labels...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+
+	var series []byte
+	for _, l := range sorted {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, l.name)
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, l.value)
+
+		series = protowire.AppendTag(series, 1, protowire.BytesType)
+		series = protowire.AppendBytes(series, label)
+	}
+
+	var sample []byte
+	sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+	sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(timestamp))
+
+	series = protowire.AppendTag(series, 2, protowire.BytesType)
+	series = protowire.AppendBytes(series, sample)
+
+	req = protowire.AppendTag(req, 1, protowire.BytesType)
+	return protowire.AppendBytes(req, series)
+}
@@ -0,0 +1,261 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+// promSeries is a decoded remote-write time series with its first sample.
+type promSeries struct {
+	labels map[string]string
+	value  float64
+}
+
+// decodeWriteRequest decodes the WriteRequest messages written by encodeWriteRequest.
+func decodeWriteRequest(t *testing.T, b []byte) []promSeries {
+	fields := func(b []byte, f func(num protowire.Number, typ protowire.Type, v []byte)) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				t.Fatalf("invalid tag")
+			}
+			b = b[n:]
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				t.Fatalf("invalid field")
+			}
+			f(num, typ, b[:n])
+			b = b[n:]
+		}
+	}
+
+	series := []promSeries{}
+	fields(b, func(_ protowire.Number, _ protowire.Type, v []byte) {
+		ts, _ := protowire.ConsumeBytes(v)
+		s := promSeries{labels: map[string]string{}}
+		fields(ts, func(num protowire.Number, _ protowire.Type, v []byte) {
+			msg, _ := protowire.ConsumeBytes(v)
+			if num == 1 { // label
+				var name, value string
+				fields(msg, func(num protowire.Number, _ protowire.Type, v []byte) {
+					str, _ := protowire.ConsumeString(v)
+					if num == 1 {
+						name = str
+					} else {
+						value = str
+					}
+				})
+				s.labels[name] = value
+			} else { // sample
+				fields(msg, func(num protowire.Number, _ protowire.Type, v []byte) {
+					if num == 1 {
+						bits, _ := protowire.ConsumeFixed64(v)
+						s.value = math.Float64frombits(bits)
+					}
+				})
+			}
+		})
+		series = append(series, s)
+	})
+	return series
+}
+
+func prometheusTestResults() []*types.ScenarioResult {
+	return []*types.ScenarioResult{
+		{
+			StepResults: []*types.ScenarioStepResult{
+				{
+					StepID:     1,
+					StepName:   "login",
+					StatusCode: 200,
+					Duration:   100 * time.Millisecond,
+					Custom: map[string]interface{}{
+						"dnsDuration":  10 * time.Millisecond,
+						"connDuration": 20 * time.Millisecond,
+					},
+				},
+				{
+					StepID:   2,
+					StepName: "order",
+					Duration: time.Second,
+					Err:      types.RequestError{Type: types.ErrorConn, Reason: types.ReasonConnTimeout},
+				},
+			},
+		},
+		{
+			StepResults: []*types.ScenarioStepResult{
+				{
+					StepID:           1,
+					StepName:         "login",
+					StatusCode:       401,
+					Duration:         200 * time.Millisecond,
+					FailedAssertions: []types.FailedAssertion{{Rule: "equals(status_code, 200)"}},
+				},
+			},
+		},
+		{
+			Dropped: true,
+		},
+	}
+}
+
+func TestPrometheusMetricsEndpoint(t *testing.T) {
+	p := &prometheusReport{}
+	p.Configure(types.Hammer{Prometheus: types.PrometheusConf{ListenAddr: "127.0.0.1:0"}})
+	if err := p.Init(false, 0); err != nil {
+		t.Fatalf("TestPrometheusMetricsEndpoint init error: %v", err)
+	}
+
+	input := make(chan *types.ScenarioResult, 10)
+	go p.Start(input, nil)
+	for _, r := range prometheusTestResults() {
+		input <- r
+	}
+
+	expected := []string{
+		`ddosify_iterations_total{status="assertion_fail"} 1`,
+		`ddosify_iterations_total{status="dropped"} 1`,
+		`ddosify_iterations_total{status="server_fail"} 1`,
+		`ddosify_step_requests_total{step_id="1",step_name="login"} 2`,
+		`ddosify_step_requests_total{step_id="2",step_name="order"} 1`,
+		`ddosify_step_failures_total{reason="assertion failure",step_id="1",step_name="login"} 1`,
+		`ddosify_step_failures_total{reason="connection timeout",step_id="2",step_name="order"} 1`,
+		`ddosify_step_duration_seconds_bucket{step_id="1",step_name="login",le="0.1"} 1`,
+		`ddosify_step_duration_seconds_count{step_id="1",step_name="login"} 2`,
+		`ddosify_step_phase_duration_seconds_count{phase="dns",step_id="1",step_name="login"} 1`,
+		`ddosify_step_phase_duration_seconds_count{phase="connection",step_id="1",step_name="login"} 1`,
+	}
+
+	var metrics string
+	for i := 0; i < 50; i++ { // results are observed asynchronously
+		res, err := http.Get("http://" + p.listener.Addr().String() + "/metrics")
+		if err != nil {
+			t.Fatalf("TestPrometheusMetricsEndpoint scrape error: %v", err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		metrics = string(body)
+		if strings.Contains(metrics, `status="dropped"`) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(metrics, e) {
+			t.Errorf("Expected metric: %s, Found: %s", e, metrics)
+		}
+	}
+
+	close(input)
+	<-p.DoneChan()
+	if _, err := http.Get("http://" + p.listener.Addr().String() + "/metrics"); err == nil {
+		t.Errorf("Metrics endpoint should be closed after the test")
+	}
+}
+
+func TestPrometheusRemoteWrite(t *testing.T) {
+	var body []byte
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, _ = snappy.Decode(nil, b)
+		headers = r.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	p := &prometheusReport{}
+	p.Configure(types.Hammer{Prometheus: types.PrometheusConf{RemoteWriteURL: server.URL}})
+	if err := p.Init(false, 0); err != nil {
+		t.Fatalf("TestPrometheusRemoteWrite init error: %v", err)
+	}
+	if p.listener != nil {
+		t.Errorf("Metrics endpoint should not be served when only remote-write is configured")
+	}
+
+	input := make(chan *types.ScenarioResult, 10)
+	go p.Start(input, nil)
+	for _, r := range prometheusTestResults() {
+		input <- r
+	}
+	close(input)
+	<-p.DoneChan()
+
+	if headers.Get("Content-Encoding") != "snappy" || headers.Get("Content-Type") != "application/x-protobuf" {
+		t.Errorf("Unexpected remote-write headers: %v", headers)
+	}
+
+	found := map[string]float64{}
+	quantiles := map[string]float64{}
+	for _, s := range decodeWriteRequest(t, body) {
+		if s.labels["job"] != "ddosify" {
+			t.Errorf("Expected job label: ddosify, Found: %v", s.labels)
+		}
+		if s.labels["__name__"] == "ddosify_step_duration_quantile_seconds" {
+			quantiles[s.labels["step_name"]+"/"+s.labels["phase"]+"/"+s.labels["quantile"]] = s.value
+			continue
+		}
+		key := s.labels["__name__"] + "/" + s.labels["step_name"] + "/" + s.labels["reason"] + s.labels["le"]
+		found[key] = s.value
+	}
+
+	expected := map[string]float64{
+		"ddosify_step_requests_total/login/":                   2,
+		"ddosify_step_failures_total/order/connection timeout": 1,
+		"ddosify_step_duration_seconds_bucket/login/0.1":       1,
+		"ddosify_step_duration_seconds_bucket/login/+Inf":      2,
+		"ddosify_step_duration_seconds_count/login/":           2,
+	}
+	for k, v := range expected {
+		if found[k] != v {
+			t.Errorf("Expected %s: %v, Found: %v", k, v, found[k])
+		}
+	}
+	if math.Abs(found["ddosify_step_duration_seconds_sum/login/"]-0.3) > 1e-9 {
+		t.Errorf("Expected duration sum: 0.3, Found: %v", found["ddosify_step_duration_seconds_sum/login/"])
+	}
+
+	expectedQuantiles := map[string]float64{
+		"login/total/0.5":  0.1,
+		"login/total/0.99": 0.2,
+		"login/total/1":    0.2,
+		"login/dns/0.5":    0.01,
+	}
+	for k, v := range expectedQuantiles {
+		// percentiles are kept in 3 significant digits
+		if math.Abs(quantiles[k]-v) > v*1e-3 {
+			t.Errorf("Expected quantile %s: %v, Found: %v", k, v, quantiles[k])
+		}
+	}
+}
@@ -54,6 +54,11 @@ type stdout struct {
 	mu           sync.Mutex
 	debug        bool
 	samplingRate int
+
+	histogramPrecision int
+
+	// live results are shown by the embedding output instead of the periodic lines
+	noLivePrint bool
 }
 
 var white = color.New(color.FgHiWhite).SprintFunc()
@@ -63,10 +68,17 @@ var yellow = color.New(color.FgHiYellow).SprintFunc()
 var red = color.New(color.FgHiRed).SprintFunc()
 var realTimePrintInterval = time.Duration(1500) * time.Millisecond
 
+// Configure sets the precision of the duration histograms, it should be called before Init.
+func (s *stdout) Configure(h types.Hammer) error {
+	s.histogramPrecision = h.HistogramPrecision
+	return nil
+}
+
 func (s *stdout) Init(debug bool, samplingRate int) (err error) {
 	s.doneChan = make(chan bool, 1)
 	s.result = &Result{
-		StepResults: make(map[uint16]*ScenarioStepResultSummary),
+		StepResults:        make(map[uint16]*ScenarioStepResultSummary),
+		histogramPrecision: s.histogramPrecision,
 	}
 	s.debug = debug
 	s.samplingRate = samplingRate
@@ -157,7 +169,7 @@ func (s *stdout) DoneChan() <-chan bool {
 }
 
 func (s *stdout) realTimePrintStart() {
-	if util.IsSystemInTestMode() {
+	if util.IsSystemInTestMode() || s.noLivePrint {
 		return
 	}
 
@@ -176,16 +188,22 @@ func (s *stdout) realTimePrintStart() {
 }
 
 func (s *stdout) liveResultPrint() {
-	fmt.Fprintf(out, "%s %s %s\n",
+	var dropped string
+	if s.result.DroppedCount > 0 {
+		dropped = yellow(fmt.Sprintf(" %s Dropped: %d", emoji.Warning, s.result.DroppedCount))
+	}
+
+	fmt.Fprintf(out, "%s %s %s%s\n",
 		green(fmt.Sprintf("%s  Successful Run: %-6d %3d%% %5s",
 			emoji.CheckMark, s.result.SuccessCount, s.result.successPercentage(), "")),
 		red(fmt.Sprintf("%s Failed Run: %-6d %3d%% %5s",
 			emoji.CrossMark, s.result.ServerFailedCount+s.result.AssertionFailCount, s.result.failedPercentage(), "")),
-		blue(fmt.Sprintf("%s  Avg. Duration: %.5fs", emoji.Stopwatch, s.result.AvgDuration)))
+		blue(fmt.Sprintf("%s  Avg. Duration: %.5fs", emoji.Stopwatch, s.result.AvgDuration)),
+		dropped)
 }
 
 func (s *stdout) realTimePrintStop() {
-	if util.IsSystemInTestMode() {
+	if util.IsSystemInTestMode() || s.noLivePrint {
 		return
 	}
 	// Last print.
@@ -371,6 +389,8 @@ func (s *stdout) printDetails() {
 	// We should sort scenarioItemIDs to traverse itemReports
 	sort.Ints(keys)
 
+	s.result.computePercentiles()
+
 	for _, k := range keys {
 		v := s.result.StepResults[uint16(k)]
 
@@ -400,6 +420,37 @@ func (s *stdout) printDetails() {
 			fmt.Fprintf(w, "  %s\t:%.4fs\n", v.name, v.duration)
 		}
 
+		if len(v.Percentiles) > 0 {
+			fmt.Fprintln(w, "\nPercentiles (p50 | p90 | p95 | p99 | p99.9 | max):")
+			for _, k := range sortedDurationKeys(v.Percentiles) {
+				values := make([]string, 0, len(percentileKeys))
+				for _, pk := range percentileKeys {
+					values = append(values, fmt.Sprintf("%.4fs", v.Percentiles[k][pk.key]))
+				}
+				fmt.Fprintf(w, "  %s\t:%s\n", keyToStr[k].name, strings.Join(values, " | "))
+			}
+		}
+
+		if len(v.Frames) > 0 {
+			fmt.Fprintln(w, "\nFrames (Total):")
+			fmt.Fprintf(w, "  %s\t:%d\n", frameKeyToStr["sentFrameCount"], v.Frames["sentFrameCount"])
+			fmt.Fprintf(w, "  %s\t:%d\n", frameKeyToStr["receivedFrameCount"], v.Frames["receivedFrameCount"])
+		}
+
+		if len(v.Counts) > 0 {
+			fmt.Fprintln(w, "\nCounts (Total):")
+			for _, k := range sortedKeys(v.Counts) {
+				fmt.Fprintf(w, "  %s\t:%d\n", countKeyToStr[k], v.Counts[k])
+			}
+		}
+
+		if len(v.Rates) > 0 {
+			fmt.Fprintln(w, "\nRates (Avg):")
+			for _, k := range sortedKeys(v.Rates) {
+				fmt.Fprintf(w, "  %s\t:%.2f\n", countKeyToStr[k], v.Rates[k])
+			}
+		}
+
 		if len(v.StatusCodeDist) > 0 {
 			fmt.Fprintln(w, "\nStatus Code (Message) :Count")
 			for s, c := range v.StatusCodeDist {
@@ -408,6 +459,13 @@ func (s *stdout) printDetails() {
 			}
 		}
 
+		if len(v.GrpcStatusDist) > 0 {
+			fmt.Fprintln(w, "\ngRPC Status :Count")
+			for _, s := range sortedKeys(v.GrpcStatusDist) {
+				fmt.Fprintf(w, "  %s\t:%d\n", s, v.GrpcStatusDist[s])
+			}
+		}
+
 		if v.Fail.AssertionErrorDist.Count > 0 {
 			fmt.Fprintln(w, "\nAssertion Error Distribution:")
 			for e, c := range v.Fail.AssertionErrorDist.Conditions {
@@ -434,6 +492,18 @@ func (s *stdout) printDetails() {
 		fmt.Fprintln(w)
 	}
 
+	if len(s.result.ProxyResults) > 0 {
+		printProxyResults(w, "Proxies", s.result.ProxyResults)
+	}
+	if s.result.multipleCountries() {
+		printProxyResults(w, "Proxy Countries", s.result.CountryResults)
+	}
+
+	if s.result.DroppedCount > 0 {
+		fmt.Fprintf(w, "%s", yellow(fmt.Sprintf("Dropped Iterations: %d (no free virtual user at the scheduled time)\n\n",
+			s.result.DroppedCount)))
+	}
+
 	if s.result.TestStatus == "success" {
 		fmt.Fprintf(w, "%s", green("Test Status : Success\n"))
 
@@ -453,6 +523,31 @@ func (s *stdout) printDetails() {
 	fmt.Fprint(out, b.String())
 }
 
+// printProxyResults prints the results of the proxies or the proxy countries, sorted by their names.
+func printProxyResults(w io.Writer, title string, results map[string]*ProxyResultSummary) {
+	fmt.Fprintf(w, "%s (Success | Failed | Avg | p50 | p95 | p99):\n", title)
+	for _, name := range sortedKeys(results) {
+		p := results[name]
+		if p.Country != "" && p.Country != "unknown" {
+			name = fmt.Sprintf("%s (%s)", name, p.Country)
+		}
+		fmt.Fprintf(w, "  %s\t:%d (%d%%) | %d | %.4fs | %.4fs | %.4fs | %.4fs\n", name,
+			p.SuccessCount, p.successPercentage(), p.FailCount,
+			p.AvgDuration, p.Percentiles["p50"], p.Percentiles["p95"], p.Percentiles["p99"])
+		if len(p.ErrorTypeDist) > 0 {
+			errTypes := make([]string, 0, len(p.ErrorTypeDist))
+			for _, t := range sortedKeys(p.ErrorTypeDist) {
+				errTypes = append(errTypes, fmt.Sprintf("%s %d", t, p.ErrorTypeDist[t]))
+			}
+			fmt.Fprintf(w, "  \t  Error Types: %s\n", strings.Join(errTypes, ", "))
+		}
+		for _, reason := range sortedKeys(p.ServerErrorDist) {
+			fmt.Fprintf(w, "  \t  %d :%s\n", p.ServerErrorDist[reason], reason)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
 func deduplicate(values []interface{}) []interface{} {
 	seen := make(map[interface{}]bool)
 	result := make([]interface{}, 0)
@@ -474,11 +569,46 @@ type duration struct {
 }
 
 var keyToStr = map[string]duration{
-	"dnsDuration":           {name: "DNS", order: 1},
-	"connDuration":          {name: "Connection", order: 2},
-	"tlsDuration":           {name: "TLS", order: 3},
-	"reqDuration":           {name: "Request Write", order: 4},
-	"serverProcessDuration": {name: "Server Processing", order: 5},
-	"resDuration":           {name: "Response Read", order: 6},
-	"duration":              {name: "Total", order: 7},
+	"tokenFetchDuration":    {name: "Token Fetch", order: 1},
+	"dnsDuration":           {name: "DNS", order: 2},
+	"connDuration":          {name: "Connection", order: 3},
+	"proxyConnDuration":     {name: "Proxy Connect", order: 4},
+	"tlsDuration":           {name: "TLS", order: 5},
+	"wsHandshakeDuration":   {name: "WebSocket Handshake", order: 6},
+	"reqDuration":           {name: "Request Write", order: 7},
+	"serverProcessDuration": {name: "Server Processing", order: 8},
+	"firstEventDuration":    {name: "First Event", order: 9},
+	"resDuration":           {name: "Response Read", order: 10},
+	"eventGapDuration":      {name: "Event Gap", order: 11},
+	"wsRoundTripDuration":   {name: "Message Round Trip", order: 12},
+	"duration":              {name: "Total", order: 13},
+}
+
+var frameKeyToStr = map[string]string{
+	"sentFrameCount":     "Sent",
+	"receivedFrameCount": "Received",
+}
+
+var countKeyToStr = map[string]string{
+	"eventCount":      "Events",
+	"tokenFetchCount": "Token Fetches",
+	"eventsPerSecond": "Events/s",
+}
+
+// sortedDurationKeys returns the duration keys of the map in the order of keyToStr.
+func sortedDurationKeys[V any](m map[string]V) []string {
+	keys := sortedKeys(m)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keyToStr[keys[i]].order < keyToStr[keys[j]].order
+	})
+	return keys
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
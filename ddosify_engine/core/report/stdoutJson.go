@@ -44,12 +44,24 @@ type stdoutJson struct {
 	debug        bool
 	samplingRate int
 	mu           sync.Mutex
+
+	histogramPrecision int
+	conf               types.StdoutJsonConf
+}
+
+// Configure sets the stdout-json options and the precision of the duration histograms of the hammer,
+// it should be called before Init.
+func (s *stdoutJson) Configure(h types.Hammer) error {
+	s.histogramPrecision = h.HistogramPrecision
+	s.conf = h.StdoutJson
+	return nil
 }
 
 func (s *stdoutJson) Init(debug bool, samplingRate int) (err error) {
 	s.doneChan = make(chan bool)
 	s.result = &Result{
-		StepResults: make(map[uint16]*ScenarioStepResultSummary),
+		StepResults:        make(map[uint16]*ScenarioStepResultSummary),
+		histogramPrecision: s.histogramPrecision,
 	}
 	s.debug = debug
 	s.samplingRate = samplingRate
@@ -89,6 +101,7 @@ func (s *stdoutJson) report() {
 
 	s.result.AvgDuration = float32(math.Round(float64(s.result.AvgDuration)*p) / p)
 
+	s.result.computePercentiles()
 	for _, itemReport := range s.result.StepResults {
 		durations := make(map[string]float32)
 		for d, s := range itemReport.Durations {
@@ -97,6 +110,48 @@ func (s *stdoutJson) report() {
 			durations[strKeyToJsonKey[d]] = float32(t)
 		}
 		itemReport.Durations = durations
+
+		if len(itemReport.Percentiles) > 0 {
+			percentiles := make(map[string]map[string]float32)
+			for d, ps := range itemReport.Percentiles {
+				percentiles[strKeyToJsonKey[d]] = ps
+			}
+			itemReport.Percentiles = percentiles
+		}
+
+		if len(itemReport.Frames) > 0 {
+			frames := make(map[string]int64)
+			for f, c := range itemReport.Frames {
+				frames[frameKeyToJsonKey[f]] = c
+			}
+			itemReport.Frames = frames
+		}
+
+		if len(itemReport.Counts) > 0 {
+			counts := make(map[string]int64)
+			for k, c := range itemReport.Counts {
+				counts[strKeyToJsonKey[k]] = c
+			}
+			itemReport.Counts = counts
+		}
+
+		if len(itemReport.Rates) > 0 {
+			rates := make(map[string]float32)
+			for k, r := range itemReport.Rates {
+				rates[strKeyToJsonKey[k]] = float32(math.Round(float64(r)*p) / p)
+			}
+			itemReport.Rates = rates
+		}
+	}
+
+	if !s.result.multipleCountries() {
+		s.result.CountryResults = nil
+	}
+	for _, proxyReport := range s.result.ProxyResults {
+		proxyReport.AvgDuration = float32(math.Round(float64(proxyReport.AvgDuration)*p) / p)
+	}
+	for _, countryReport := range s.result.CountryResults {
+		countryReport.AvgDuration = float32(math.Round(float64(countryReport.AvgDuration)*p) / p)
 	}
 
 	j, _ := json.Marshal(s.result)
@@ -111,10 +166,22 @@ func (s *stdoutJson) listenAndAggregate(input chan *types.ScenarioResult, assert
 	stopSampling := make(chan struct{})
 	samplingCount := make(map[uint16]map[string]int)
 	go s.cleanSamplingCount(samplingCount, stopSampling, s.samplingRate)
+
+	var buckets *bucketAggregator
+	if s.conf.BucketInterval > 0 {
+		buckets = newBucketAggregator(time.Now(), time.Duration(s.conf.BucketInterval)*time.Second, s.histogramPrecision)
+	}
 	for r := range input {
 		s.mu.Lock() // avoid race around samplingCount
 		aggregate(s.result, r, samplingCount, s.samplingRate)
 		s.mu.Unlock()
+		if buckets != nil {
+			buckets.add(r, time.Now())
+		}
+	}
+	if buckets != nil {
+		buckets.closeLast()
+		s.result.Buckets = buckets.buckets
 	}
 	// listen for assertion result, add to json
 	s.result.TestStatus = "success"
@@ -209,18 +276,47 @@ func (s ScenarioStepResultSummary) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// ProxyReport wraps ProxyResultSummary to add success percentage value
+type ProxyReport ProxyResultSummary
+
+func (p ProxyResultSummary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ProxyReport
+		SuccesPerc int `json:"success_perc"`
+	}{
+		ProxyReport: ProxyReport(p),
+		SuccesPerc:  p.successPercentage(),
+	})
+}
+
 var printJson = func(j []byte) {
 	fmt.Println(string(j))
 }
 
+var frameKeyToJsonKey = map[string]string{
+	"sentFrameCount":     "sent",
+	"receivedFrameCount": "received",
+}
+
 var strKeyToJsonKey = map[string]string{
+	"tokenFetchDuration":    "token_fetch",
 	"dnsDuration":           "dns",
 	"connDuration":          "connection",
+	"proxyConnDuration":     "proxy_connect",
 	"tlsDuration":           "tls",
 	"reqDuration":           "request_write",
 	"serverProcessDuration": "server_processing",
 	"resDuration":           "response_read",
+	"wsHandshakeDuration":   "ws_handshake",
+	"wsRoundTripDuration":   "ws_round_trip",
 	"duration":              "total",
+	"firstEventDuration":    "first_event",
+	"eventGapDuration":      "event_gap",
+	"sentFrameCount":        "sent_frames",
+	"tokenFetchCount":       "token_fetches",
+	"receivedFrameCount":    "received_frames",
+	"eventCount":            "events",
+	"eventsPerSecond":       "events_per_second",
 }
 
 func (v verboseHttpRequestInfo) MarshalJSON() ([]byte, error) {
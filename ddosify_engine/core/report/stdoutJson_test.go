@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -273,6 +274,46 @@ func TestStdoutJsonOutput(t *testing.T) {
 	}
 }
 
+func TestStdoutJsonOutputProxyCountries(t *testing.T) {
+	var output string
+	printJson = func(j []byte) {
+		output = string(j)
+	}
+
+	s := &stdoutJson{}
+	s.Init(false, 0)
+	for _, country := range []string{"DE", "US"} {
+		aggregate(s.result, &types.ScenarioResult{
+			StepResults: []*types.ScenarioStepResult{{StepID: 1, StatusCode: 200, Duration: 1234567 * time.Microsecond}},
+			Others:      map[string]interface{}{"proxyCountry": country},
+		}, make(map[uint16]map[string]int), 3)
+	}
+	s.report()
+
+	r := struct {
+		Countries map[string]map[string]interface{} `json:"proxy_countries"`
+	}{}
+	if err := json.Unmarshal([]byte(output), &r); err != nil {
+		t.Fatalf("Invalid json: %v", err)
+	}
+	if len(r.Countries) != 2 || r.Countries["DE"]["success_perc"] != float64(100) ||
+		r.Countries["US"]["avg_duration"] != 1.235 || r.Countries["US"]["percentiles"] == nil {
+		t.Errorf("Unexpected countries: %v", r.Countries)
+	}
+
+	// a single country is not reported
+	s = &stdoutJson{}
+	s.Init(false, 0)
+	aggregate(s.result, &types.ScenarioResult{
+		StepResults: []*types.ScenarioStepResult{{StepID: 1, StatusCode: 200}},
+		Others:      map[string]interface{}{"proxyCountry": "unknown"},
+	}, make(map[uint16]map[string]int), 3)
+	s.report()
+	if strings.Contains(output, "proxy_countries") {
+		t.Errorf("Single country should not be reported, Found: %s", output)
+	}
+}
+
 func TestStdoutJsonDebugModePrintsValidJson(t *testing.T) {
 	s := &stdoutJson{}
 	s.Init(true, 0)
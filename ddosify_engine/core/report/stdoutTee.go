@@ -0,0 +1,64 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"go.ddosify.com/ddosify/core/assertion"
+	"go.ddosify.com/ddosify/core/types"
+)
+
+// stdoutTee is embedded by the outputs that print the results like the stdout output besides writing them
+// to their own destination.
+type stdoutTee struct {
+	stdout
+	done chan bool
+}
+
+func (t *stdoutTee) Init(debug bool, samplingRate int) (err error) {
+	if err = t.stdout.Init(debug, samplingRate); err != nil {
+		return
+	}
+	t.done = make(chan bool, 1)
+	return
+}
+
+func (t *stdoutTee) DoneChan() <-chan bool {
+	return t.done
+}
+
+// teeStdout passes each result to onResult before it is aggregated by the stdout and returns whether the
+// test succeeded once the stdout is done. The results are only printed if onResult is nil.
+func (t *stdoutTee) teeStdout(input chan *types.ScenarioResult,
+	assertionResultChan <-chan assertion.TestAssertionResult, onResult func(*types.ScenarioResult)) bool {
+	stdoutInput := input
+	if onResult != nil {
+		stdoutInput = make(chan *types.ScenarioResult, cap(input))
+		go func(out chan<- *types.ScenarioResult) {
+			for r := range input {
+				onResult(r)
+				out <- r
+			}
+			close(out)
+		}(stdoutInput)
+	}
+	t.stdout.Start(stdoutInput, assertionResultChan)
+	return <-t.stdout.DoneChan()
+}
@@ -0,0 +1,248 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+
+	"go.ddosify.com/ddosify/core/assertion"
+	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/util"
+)
+
+const OutputTypeTui = "tui"
+
+const (
+	tuiRenderInterval = time.Second
+	tuiDefaultWidth   = 100
+
+	keyCtrlC = 3
+)
+
+// ANSI escape sequences of the screen
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	clearScreen    = "\x1b[H\x1b[2J"
+	clearLineRest  = "\x1b[K"
+)
+
+func init() {
+	AvailableOutputServices[OutputTypeTui] = &tuiReport{}
+}
+
+// tuiReport shows a live dashboard of the steps in the terminal while the test runs, the test can be paused,
+// resumed or aborted with the keys. The final results are printed like the stdout output.
+// It falls back to the stdout output if the standard input or output is not a terminal.
+type tuiReport struct {
+	stdout
+
+	stages []tuiStage
+	dash   *tuiDashboard
+	done   chan bool
+
+	in          io.Reader
+	screen      io.Writer
+	width       func() int
+	interactive bool
+	termState   *term.State
+	screenMu    sync.Mutex
+	closed      bool
+
+	pauseChan chan bool
+	abortChan chan struct{}
+	abortOnce sync.Once
+}
+
+// Configure sets the load profile of the hammer shown on the dashboard, it should be called before Init.
+func (t *tuiReport) Configure(h types.Hammer) error {
+	t.stages = tuiStages(h)
+	return t.stdout.Configure(h)
+}
+
+func (t *tuiReport) Init(debug bool, samplingRate int) (err error) {
+	if err = t.stdout.Init(debug, samplingRate); err != nil {
+		return
+	}
+	t.done = make(chan bool, 1)
+	t.pauseChan = make(chan bool, 1)
+	t.abortChan = make(chan struct{})
+
+	if debug || util.IsSystemInTestMode() {
+		return
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		color.Yellow("Standard input and output should be a terminal for the %s output, results are printed like stdout.\n",
+			OutputTypeTui)
+		return
+	}
+	t.interactive = true
+	t.in = os.Stdin
+	t.screen = os.Stdout
+	t.width = func() int {
+		if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+			return w
+		}
+		return tuiDefaultWidth
+	}
+	return
+}
+
+func (t *tuiReport) Start(input chan *types.ScenarioResult,
+	assertionResultChan <-chan assertion.TestAssertionResult) {
+	if t.debug || !t.interactive {
+		t.stdout.Start(input, assertionResultChan)
+		t.done <- <-t.stdout.DoneChan()
+		return
+	}
+	t.stdout.noLivePrint = true
+	t.dash = newTuiDashboard(t.stages, time.Now())
+
+	t.openScreen()
+	stopRender := make(chan struct{})
+	renderStopped := make(chan struct{})
+	go t.renderPeriodically(stopRender, renderStopped)
+	go t.listenKeys()
+
+	// the screen is closed before the results are printed by the stdout
+	stdoutInput := make(chan *types.ScenarioResult, cap(input))
+	go func() {
+		for r := range input {
+			t.dash.add(r)
+			stdoutInput <- r
+		}
+		close(stopRender)
+		<-renderStopped
+		t.closeScreen()
+		close(stdoutInput)
+	}()
+	t.stdout.Start(stdoutInput, assertionResultChan)
+	t.done <- <-t.stdout.DoneChan()
+}
+
+func (t *tuiReport) DoneChan() <-chan bool {
+	return t.done
+}
+
+func (t *tuiReport) PauseChan() <-chan bool {
+	return t.pauseChan
+}
+
+func (t *tuiReport) AbortChan() <-chan struct{} {
+	return t.abortChan
+}
+
+// openScreen switches to the alternate screen and puts the terminal into raw mode to read the keys one by one.
+func (t *tuiReport) openScreen() {
+	if f, ok := t.in.(*os.File); ok {
+		if state, err := term.MakeRaw(int(f.Fd())); err == nil {
+			t.termState = state
+		}
+	}
+	io.WriteString(t.screen, enterAltScreen)
+}
+
+func (t *tuiReport) closeScreen() {
+	t.screenMu.Lock()
+	defer t.screenMu.Unlock()
+	t.closed = true
+	io.WriteString(t.screen, exitAltScreen)
+	if t.termState != nil {
+		term.Restore(int(t.in.(*os.File).Fd()), t.termState)
+	}
+}
+
+func (t *tuiReport) renderPeriodically(stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(tuiRenderInterval)
+	defer ticker.Stop()
+
+	t.render()
+	for {
+		select {
+		case now := <-ticker.C:
+			t.dash.tick(now)
+			t.render()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// render draws the dashboard, lines end with \r\n since the terminal is in raw mode.
+func (t *tuiReport) render() {
+	t.screenMu.Lock()
+	defer t.screenMu.Unlock()
+	if t.closed {
+		return
+	}
+	b := strings.Builder{}
+	b.WriteString(clearScreen)
+	for _, l := range t.dash.render(time.Now(), t.width()) {
+		b.WriteString(l)
+		b.WriteString(clearLineRest + "\r\n")
+	}
+	io.WriteString(t.screen, b.String())
+}
+
+// listenKeys handles the keys until the input is closed. The terminal is in raw mode, so CTRL+C is read as a key.
+func (t *tuiReport) listenKeys() {
+	r := bufio.NewReader(t.in)
+	for {
+		k, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		t.handleKey(k)
+	}
+}
+
+func (t *tuiReport) handleKey(k byte) {
+	switch k {
+	case 'p', 'P':
+		t.setPaused(true)
+	case 'r', 'R':
+		t.setPaused(false)
+	case 'q', 'Q', keyCtrlC:
+		t.abortOnce.Do(func() { close(t.abortChan) })
+	default:
+		return
+	}
+	t.render()
+}
+
+// setPaused sends the pause state to the engine, replacing the state that is not received yet.
+func (t *tuiReport) setPaused(paused bool) {
+	t.dash.setPaused(paused, time.Now())
+	select {
+	case <-t.pauseChan:
+	default:
+	}
+	t.pauseChan <- paused
+}
@@ -0,0 +1,347 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/util"
+)
+
+const (
+	tuiHistorySize   = 60 // seconds shown in the sparklines
+	tuiTopErrorCount = 3
+	tuiPrecision     = 2
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// tuiStage is a stage of the load profile shown on the dashboard.
+type tuiStage struct {
+	desc     string
+	duration int // in seconds
+}
+
+// tuiStep is the live results of a step.
+type tuiStep struct {
+	name         string
+	successCount int64
+	failCount    int64
+	errors       map[string]int64 // error reason -> count
+
+	window  int64           // results of the current second
+	latency *util.Histogram // durations of the current second in microseconds
+	rps     []float64
+	p95     []float64 // in seconds
+}
+
+// tuiDashboard keeps the live results of the steps in per second windows and renders them as panels.
+type tuiDashboard struct {
+	mu sync.Mutex
+
+	start       time.Time
+	lastTick    time.Time
+	paused      bool
+	pausedAt    time.Time
+	pausedTotal time.Duration
+
+	stages  []tuiStage
+	steps   map[uint16]*tuiStep
+	dropped int64
+}
+
+func newTuiDashboard(stages []tuiStage, start time.Time) *tuiDashboard {
+	return &tuiDashboard{
+		start:    start,
+		lastTick: start,
+		stages:   stages,
+		steps:    make(map[uint16]*tuiStep),
+	}
+}
+
+// tuiStages returns the stages of the load profile of the hammer.
+func tuiStages(h types.Hammer) []tuiStage {
+	stages := make([]tuiStage, 0)
+	switch {
+	case h.Executor == types.ExecutorVirtualUser && len(h.VUStages) > 0:
+		for _, s := range h.VUStages {
+			stages = append(stages, tuiStage{desc: fmt.Sprintf("ramp to %d VUs", s.Target), duration: s.Duration})
+		}
+	case h.Executor == types.ExecutorVirtualUser:
+		stages = append(stages, tuiStage{desc: fmt.Sprintf("%d VUs", h.VUs), duration: h.TestDuration})
+	case len(h.LoadStages) > 0:
+		for _, s := range h.LoadStages {
+			stages = append(stages, tuiStage{desc: fmt.Sprintf("%s %d iter/s", s.LoadType, s.Rate), duration: s.Duration})
+		}
+	default:
+		stages = append(stages, tuiStage{
+			desc:     fmt.Sprintf("%s %d iterations", h.LoadType, h.IterationCount),
+			duration: h.TestDuration,
+		})
+	}
+	return stages
+}
+
+// add adds the step results of the iteration to the current second.
+func (d *tuiDashboard) add(scr *types.ScenarioResult) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if scr.Dropped {
+		d.dropped++
+		return
+	}
+	for _, sr := range scr.StepResults {
+		step, ok := d.steps[sr.StepID]
+		if !ok {
+			step = &tuiStep{
+				name:    sr.StepName,
+				errors:  make(map[string]int64),
+				latency: util.NewHistogram(tuiPrecision),
+			}
+			if step.name == "" {
+				step.name = fmt.Sprintf("Step %d", sr.StepID)
+			}
+			d.steps[sr.StepID] = step
+		}
+
+		step.window++
+		if sr.Err.Type != "" {
+			step.failCount++
+			step.errors[sr.Err.Reason]++
+			continue
+		}
+		if len(sr.FailedAssertions) > 0 {
+			step.failCount++
+		} else {
+			step.successCount++
+		}
+		step.latency.Record(sr.Duration.Microseconds())
+	}
+}
+
+// tick closes the current second of the steps, the rate is calculated with the time passed since the last tick.
+func (d *tuiDashboard) tick(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	elapsed := now.Sub(d.lastTick).Seconds()
+	d.lastTick = now
+	if elapsed <= 0 {
+		return
+	}
+	for _, step := range d.steps {
+		var p95 float64
+		if step.latency.Count() > 0 {
+			p95 = float64(step.latency.ValueAtPercentile(95)) / 1e6
+		}
+		step.rps = appendHistory(step.rps, float64(step.window)/elapsed)
+		step.p95 = appendHistory(step.p95, p95)
+		step.window = 0
+		step.latency = util.NewHistogram(tuiPrecision)
+	}
+}
+
+func (d *tuiDashboard) setPaused(paused bool, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if paused == d.paused {
+		return
+	}
+	d.paused = paused
+	if paused {
+		d.pausedAt = now
+	} else {
+		d.pausedTotal += now.Sub(d.pausedAt)
+	}
+}
+
+// running returns the time passed since the start of the test except the pauses.
+func (d *tuiDashboard) running(now time.Time) time.Duration {
+	r := now.Sub(d.start) - d.pausedTotal
+	if d.paused {
+		r -= now.Sub(d.pausedAt)
+	}
+	return r
+}
+
+// render returns the dashboard lines fitted in the given width.
+func (d *tuiDashboard) render(now time.Time, width int) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	running := d.running(now)
+	total := 0
+	for _, s := range d.stages {
+		total += s.duration
+	}
+
+	state := green("▶ running")
+	if d.paused {
+		state = yellow("❚❚ paused")
+	}
+	lines := []string{
+		fmt.Sprintf(" %s  %s / %s   %s", white("Ddosify"), formatClock(running), formatClock(time.Duration(total)*time.Second),
+			state),
+		" " + d.stageLine(running),
+	}
+	keys := " [p] pause  [r] resume  [q] abort"
+	if d.dropped > 0 {
+		keys += yellow(fmt.Sprintf("   Dropped: %d", d.dropped))
+	}
+	lines = append(lines, keys, "")
+
+	sparkWidth := width - 30
+	if sparkWidth > tuiHistorySize {
+		sparkWidth = tuiHistorySize
+	}
+	for _, id := range d.sortedStepIDs() {
+		step := d.steps[id]
+		header := fmt.Sprintf(" %d. %s ", id, step.name)
+		if pad := width - len([]rune(header)) - 1; pad > 0 {
+			header += strings.Repeat("─", pad)
+		}
+		lines = append(lines, blue(header))
+
+		var rps, p95 float64
+		if n := len(step.rps); n > 0 {
+			rps, p95 = step.rps[n-1], step.p95[n-1]
+		}
+		count := step.successCount + step.failCount
+		lines = append(lines,
+			fmt.Sprintf("   RPS       %-12.1f %s", rps, sparkline(step.rps, sparkWidth)),
+			fmt.Sprintf("   p95       %-12s %s", fmt.Sprintf("%.1fms", p95*1e3), sparkline(step.p95, sparkWidth)),
+			fmt.Sprintf("   %s   %s",
+				green(fmt.Sprintf("Success %-6d %3d%%", step.successCount, percentage(step.successCount, count))),
+				red(fmt.Sprintf("Failed %-6d %3d%%", step.failCount, percentage(step.failCount, count)))),
+		)
+		for i, e := range topErrors(step.errors, tuiTopErrorCount) {
+			label := "         "
+			if i == 0 {
+				label = "Errors   "
+			}
+			lines = append(lines, fmt.Sprintf("   %s %s", label, red(fmt.Sprintf("%-6d %s", e.count, e.reason))))
+		}
+		lines = append(lines, "")
+	}
+	if len(d.steps) == 0 {
+		lines = append(lines, " Waiting for the first results...")
+	}
+	return lines
+}
+
+// stageLine returns the current stage of the load profile and the time left in it.
+func (d *tuiDashboard) stageLine(running time.Duration) string {
+	passed := 0
+	for i, s := range d.stages {
+		if int(running.Seconds()) < passed+s.duration || i == len(d.stages)-1 {
+			left := time.Duration(passed+s.duration)*time.Second - running
+			if left < 0 {
+				left = 0
+			}
+			return fmt.Sprintf("Stage %d/%d: %s (%s left)", i+1, len(d.stages), s.desc, formatClock(left))
+		}
+		passed += s.duration
+	}
+	return ""
+}
+
+func (d *tuiDashboard) sortedStepIDs() []uint16 {
+	ids := make([]uint16, 0, len(d.steps))
+	for id := range d.steps {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+type tuiError struct {
+	reason string
+	count  int64
+}
+
+// topErrors returns the most frequent n error reasons.
+func topErrors(errors map[string]int64, n int) []tuiError {
+	list := make([]tuiError, 0, len(errors))
+	for r, c := range errors {
+		list = append(list, tuiError{reason: r, count: c})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].count == list[j].count {
+			return list[i].reason < list[j].reason
+		}
+		return list[i].count > list[j].count
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// sparkline returns the last width values as blocks scaled to the maximum of them.
+func sparkline(values []float64, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	var max float64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	b := strings.Builder{}
+	for _, v := range values {
+		i := 0
+		if max > 0 {
+			i = int(v / max * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+func appendHistory(history []float64, v float64) []float64 {
+	history = append(history, v)
+	if len(history) > tuiHistorySize {
+		history = history[len(history)-tuiHistorySize:]
+	}
+	return history
+}
+
+func percentage(n, total int64) int {
+	if total == 0 {
+		return 0
+	}
+	return int(n * 100 / total)
+}
+
+func formatClock(d time.Duration) string {
+	s := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
@@ -0,0 +1,206 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package report
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+func tuiTestResult(stepID uint16, d time.Duration, errReason string) *types.ScenarioResult {
+	sr := &types.ScenarioStepResult{StepID: stepID, StepName: "login", StatusCode: 200, Duration: d}
+	if errReason != "" {
+		sr.Err = types.RequestError{Type: types.ErrorConn, Reason: errReason}
+	}
+	return &types.ScenarioResult{StepResults: []*types.ScenarioStepResult{sr}}
+}
+
+func TestTuiDashboard(t *testing.T) {
+	start := time.Now()
+	d := newTuiDashboard([]tuiStage{{"linear 10 iterations", 10}, {"linear 5 iter/s", 20}}, start)
+
+	for i := 1; i <= 8; i++ {
+		d.add(tuiTestResult(1, time.Duration(i*10)*time.Millisecond, ""))
+	}
+	d.add(tuiTestResult(1, time.Second, types.ReasonConnTimeout))
+	d.add(tuiTestResult(1, time.Second, types.ReasonConnTimeout))
+	d.add(tuiTestResult(1, time.Second, types.ReasonConnRefused))
+	d.add(&types.ScenarioResult{Dropped: true})
+	d.tick(start.Add(2 * time.Second))
+
+	step := d.steps[1]
+	if step.successCount != 8 || step.failCount != 3 || d.dropped != 1 {
+		t.Errorf("Unexpected counts: %+v, dropped %d", step, d.dropped)
+	}
+	if step.rps[0] != 5.5 {
+		t.Errorf("Expected 5.5 rps, Found: %v", step.rps[0])
+	}
+	// durations of the server errors are not in the latency
+	if step.p95[0] < 0.08 || step.p95[0] > 0.0801 {
+		t.Errorf("Expected p95 0.08s, Found: %v", step.p95[0])
+	}
+	if step.window != 0 || step.latency.Count() != 0 {
+		t.Errorf("Window should be reset at every tick")
+	}
+
+	d.setPaused(true, start.Add(12*time.Second))
+	now := start.Add(15 * time.Second)
+	if r := d.running(now); r != 12*time.Second {
+		t.Errorf("Expected 12s running while paused, Found: %v", r)
+	}
+	d.setPaused(false, now)
+
+	out := strings.Join(d.render(start.Add(20*time.Second), 80), "\n")
+	for _, e := range []string{
+		"00:00:17 / 00:00:30", "running", "Stage 2/2: linear 5 iter/s (00:00:13 left)", "Dropped: 1",
+		"1. login", "5.5", "80.0ms", "Success 8", "72%", "Failed 3", "27%",
+		"2      connection timeout", "1      connection refused",
+	} {
+		if !strings.Contains(out, e) {
+			t.Errorf("Expected %q in the dashboard:\n%s", e, out)
+		}
+	}
+}
+
+func TestTuiStages(t *testing.T) {
+	h := types.Hammer{LoadType: types.LoadTypeLinear, IterationCount: 100, TestDuration: 10}
+	if s := tuiStages(h); len(s) != 1 || s[0].desc != "linear 100 iterations" || s[0].duration != 10 {
+		t.Errorf("Unexpected stages: %v", s)
+	}
+
+	h.LoadStages = types.LoadStages{{Duration: 60, LoadType: types.LoadTypeIncremental, Rate: 500}, {Duration: 300, LoadType: types.LoadTypeLinear, Rate: 500}}
+	if s := tuiStages(h); len(s) != 2 || s[0].desc != "incremental 500 iter/s" || s[1].duration != 300 {
+		t.Errorf("Unexpected load stages: %v", s)
+	}
+
+	h.Executor = types.ExecutorVirtualUser
+	h.VUs = 20
+	if s := tuiStages(h); len(s) != 1 || s[0].desc != "20 VUs" {
+		t.Errorf("Unexpected virtual user stages: %v", s)
+	}
+	h.VUStages = types.VUStages{{Duration: 30, Target: 50}}
+	if s := tuiStages(h); len(s) != 1 || s[0].desc != "ramp to 50 VUs" || s[0].duration != 30 {
+		t.Errorf("Unexpected virtual user ramp stages: %v", s)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if s := sparkline([]float64{0, 1, 2, 4, 8}, 10); s != "▁▁▂▄█" {
+		t.Errorf("Unexpected sparkline: %s", s)
+	}
+	if s := sparkline([]float64{8, 0, 0}, 2); s != "▁▁" {
+		t.Errorf("Sparkline should have the last values in the width, Found: %s", s)
+	}
+	if s := sparkline([]float64{1}, 0); s != "" {
+		t.Errorf("Sparkline should be empty for zero width, Found: %s", s)
+	}
+}
+
+// lockedBuffer is a bytes.Buffer that is safe to write from the render and key goroutines.
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.Write(p)
+}
+
+func (l *lockedBuffer) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.String()
+}
+
+func TestTuiReport(t *testing.T) {
+	screen := &lockedBuffer{}
+	tr := &tuiReport{}
+	tr.Configure(types.Hammer{LoadType: types.LoadTypeLinear, IterationCount: 2, TestDuration: 1})
+	tr.Init(false, 1)
+	// keys are read from a non terminal input, so the terminal is not put into raw mode
+	tr.interactive = true
+	tr.in = strings.NewReader("pq")
+	tr.screen = screen
+	tr.width = func() int { return 80 }
+
+	input := make(chan *types.ScenarioResult, 2)
+	go tr.Start(input, nil)
+
+	select {
+	case <-tr.AbortChan():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Abort key should close the abort channel")
+	}
+	if paused := <-tr.PauseChan(); !paused {
+		t.Errorf("Pause key should pause the test")
+	}
+
+	input <- tuiTestResult(1, 10*time.Millisecond, "")
+	input <- tuiTestResult(1, 20*time.Millisecond, "")
+	close(input)
+	if success := <-tr.DoneChan(); !success {
+		t.Errorf("Expected success")
+	}
+
+	out := screen.String()
+	if !strings.HasPrefix(out, enterAltScreen) || !strings.HasSuffix(out, exitAltScreen) {
+		t.Errorf("Dashboard should be drawn on the alternate screen, Found: %q", out)
+	}
+	if !strings.Contains(out, "paused") || !strings.Contains(out, "\r\n") {
+		t.Errorf("Expected the paused dashboard with raw mode line endings, Found: %q", out)
+	}
+	if tr.stdout.result.SuccessCount != 2 {
+		t.Errorf("Results should be aggregated like stdout, Found: %d", tr.stdout.result.SuccessCount)
+	}
+}
+
+func TestTuiKeys(t *testing.T) {
+	tr := &tuiReport{}
+	tr.Init(false, 1)
+	tr.dash = newTuiDashboard(nil, time.Now())
+	tr.screen = &lockedBuffer{}
+	tr.width = func() int { return 80 }
+
+	tr.handleKey('p')
+	tr.handleKey('r')
+	if paused := <-tr.PauseChan(); paused {
+		t.Errorf("Unreceived pause should be replaced by the resume")
+	}
+	if tr.dash.paused {
+		t.Errorf("Dashboard should be resumed")
+	}
+
+	tr.handleKey('x')
+	tr.handleKey(keyCtrlC)
+	tr.handleKey('q')
+	select {
+	case <-tr.AbortChan():
+	default:
+		t.Errorf("CTRL+C should abort the test")
+	}
+}
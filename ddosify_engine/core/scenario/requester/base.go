@@ -36,13 +36,49 @@ type Requester interface {
 	Done()
 }
 
+type GrpcRequesterI interface {
+	Init(ctx context.Context, ss types.ScenarioStep, url *url.URL, debug bool, ei *injection.EnvironmentInjector) error
+	Send(envs map[string]interface{}) *types.ScenarioStepResult
+}
+
+type WebSocketRequesterI interface {
+	Init(ctx context.Context, ss types.ScenarioStep, url *url.URL, debug bool, ei *injection.EnvironmentInjector) error
+	Send(envs map[string]interface{}) *types.ScenarioStepResult
+}
+
+type RawRequesterI interface {
+	Init(ctx context.Context, ss types.ScenarioStep, url *url.URL, debug bool, ei *injection.EnvironmentInjector) error
+	Send(envs map[string]interface{}) *types.ScenarioStepResult
+}
+
 type HttpRequesterI interface {
 	Init(ctx context.Context, ss types.ScenarioStep, url *url.URL, debug bool, ei *injection.EnvironmentInjector) error
 	Send(client *http.Client, envs map[string]interface{}) *types.ScenarioStepResult // should use its own client if client is nil
 }
 
+// TracedHttpRequesterI is implemented by the http requesters that propagate the trace context of the step
+// to the target in the W3C traceparent header.
+type TracedHttpRequesterI interface {
+	SendWithTrace(client *http.Client, envs map[string]interface{}, tc types.TraceContext) *types.ScenarioStepResult
+}
+
+// OAuth2HttpRequesterI is implemented by the http requesters that share the tokens of their oauth2 auth
+// with the other steps.
+type OAuth2HttpRequesterI interface {
+	SetOAuth2Tokens(tokens *OAuth2Tokens)
+}
+
 // NewRequester is the factory method of the Requester.
 func NewRequester(s types.ScenarioStep) (requester Requester, err error) {
-	requester = &HttpRequester{} // we have only HttpRequester type for now, add check for rpc in future
+	switch s.Protocol() {
+	case types.ProtocolGRPC, types.ProtocolGRPCS:
+		requester = &GrpcRequester{}
+	case types.ProtocolWS, types.ProtocolWSS:
+		requester = &WebSocketRequester{}
+	case types.ProtocolTCP, types.ProtocolUDP:
+		requester = &RawRequester{}
+	default:
+		requester = &HttpRequester{}
+	}
 	return
 }
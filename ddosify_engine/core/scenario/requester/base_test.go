@@ -21,8 +21,12 @@
 package requester
 
 import (
+	"context"
+	"net/url"
 	"reflect"
+	"testing"
 
+	"go.ddosify.com/ddosify/core/scenario/scripting/injection"
 	"go.ddosify.com/ddosify/core/types"
 )
 
@@ -30,3 +34,20 @@ var protocolStrategiesStructMap = map[string]reflect.Type{
 	types.ProtocolHTTP:  reflect.TypeOf(&HttpRequester{}),
 	types.ProtocolHTTPS: reflect.TypeOf(&HttpRequester{}),
 }
+
+type testRequester interface {
+	Requester
+	Init(ctx context.Context, s types.ScenarioStep, proxyAddr *url.URL, debug bool, ei *injection.EnvironmentInjector) error
+}
+
+// initRequester inits the requester for the step with no proxy, it is closed when the test ends.
+func initRequester[R testRequester](t *testing.T, r R, s types.ScenarioStep) R {
+	ei := &injection.EnvironmentInjector{}
+	ei.Init()
+
+	if err := r.Init(context.TODO(), s, nil, false, ei); err != nil {
+		t.Fatalf("%s requester init error: %v", r.Type(), err)
+	}
+	t.Cleanup(r.Done)
+	return r
+}
@@ -0,0 +1,425 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"go.ddosify.com/ddosify/core/scenario/scripting/assertion/evaluator"
+	"go.ddosify.com/ddosify/core/scenario/scripting/injection"
+	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/types/regex"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type GrpcRequester struct {
+	ctx        context.Context
+	packet     types.ScenarioStep
+	conn       *grpc.ClientConn
+	stub       grpcdynamic.Stub
+	method     *desc.MethodDescriptor
+	ei         *injection.EnvironmentInjector
+	debug      bool
+	dynamicRgx *regexp.Regexp
+	envRgx     *regexp.Regexp
+}
+
+// Init connects to the target and resolves the method of the step from the proto file or server reflection.
+// GrpcRequester uses the same connection for all RPCs, they are multiplexed over it. Proxies are not supported.
+func (g *GrpcRequester) Init(ctx context.Context, s types.ScenarioStep, proxyAddr *url.URL, debug bool,
+	ei *injection.EnvironmentInjector) (err error) {
+	g.ctx = ctx
+	g.packet = s
+	g.ei = ei
+	g.debug = debug
+	g.dynamicRgx = regexp.MustCompile(regex.DynamicVariableRegex)
+	g.envRgx = regexp.MustCompile(regex.EnvironmentVariableRegex)
+
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return
+	}
+
+	creds := insecure.NewCredentials()
+	if s.Protocol() == types.ProtocolGRPCS {
+		creds = credentials.NewTLS(g.initTLSConfig())
+	}
+
+	g.conn, err = grpc.DialContext(ctx, u.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return
+	}
+	g.stub = grpcdynamic.NewStub(g.conn)
+
+	g.method, err = g.resolveMethod()
+	if err != nil {
+		return fmt.Errorf("grpc method %s could not be resolved, %v", s.Grpc.Method, err)
+	}
+
+	if g.dynamicRgx.MatchString(g.packet.Payload) {
+		_, err = g.ei.InjectDynamic(g.packet.Payload)
+	}
+	return
+}
+
+func (g *GrpcRequester) resolveMethod() (*desc.MethodDescriptor, error) {
+	fullName := strings.TrimPrefix(g.packet.Grpc.Method, "/")
+	i := strings.LastIndex(fullName, "/")
+	serviceName, methodName := fullName[:i], fullName[i+1:]
+
+	var sd *desc.ServiceDescriptor
+	if g.packet.Grpc.ProtoFile != "" {
+		importPaths, file := g.packet.Grpc.ImportPaths, g.packet.Grpc.ProtoFile
+		if len(importPaths) == 0 {
+			importPaths, file = []string{filepath.Dir(file)}, filepath.Base(file)
+		}
+
+		p := protoparse.Parser{ImportPaths: importPaths}
+		fds, err := p.ParseFiles(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, fd := range fds {
+			if sd = fd.FindService(serviceName); sd != nil {
+				break
+			}
+		}
+		if sd == nil {
+			return nil, fmt.Errorf("service %s not found in %s", serviceName, g.packet.Grpc.ProtoFile)
+		}
+	} else {
+		rc := grpcreflect.NewClientAuto(g.ctx, g.conn)
+		defer rc.Reset()
+
+		var err error
+		if sd, err = rc.ResolveService(serviceName); err != nil {
+			return nil, err
+		}
+	}
+
+	md := sd.FindMethodByName(methodName)
+	if md == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", methodName, serviceName)
+	}
+	return md, nil
+}
+
+func (g *GrpcRequester) initTLSConfig() *tls.Config {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+	}
+
+	if g.packet.CertPool != nil && g.packet.Cert.Certificate != nil {
+		tlsConfig.RootCAs = g.packet.CertPool
+		tlsConfig.Certificates = []tls.Certificate{g.packet.Cert}
+	}
+
+	if val, ok := g.packet.Custom["hostname"]; ok {
+		tlsConfig.ServerName = val.(string)
+	}
+	return tlsConfig
+}
+
+func (g *GrpcRequester) Send(envs map[string]interface{}) (res *types.ScenarioStepResult) {
+	var statusCode int
+	var requestErr types.RequestError
+	var reqStartTime = time.Now()
+	var respBody []byte
+	var respHeaders http.Header
+	var extractedVars = make(map[string]interface{})
+	var failedCaptures = make(map[string]string, 0)
+	var failedAssertions = make([]types.FailedAssertion, 0)
+
+	var usableVars = make(map[string]interface{}, len(envs))
+	for k, v := range envs {
+		usableVars[k] = v
+	}
+
+	res = &types.ScenarioStepResult{
+		StepID:      g.packet.ID,
+		StepName:    g.packet.Name,
+		RequestID:   uuid.New(),
+		RequestTime: reqStartTime,
+		Url:         g.packet.URL,
+		Method:      g.method.GetFullyQualifiedName(),
+		UsableEnvs:  usableVars,
+	}
+
+	reqs, md, payload, err := g.prepareReq(usableVars)
+	if err != nil { // could not prepare req
+		res.Err = types.RequestError{
+			Type:   types.ErrorInvalidRequest,
+			Reason: fmt.Sprintf("Could not prepare req, %s", err.Error()),
+		}
+		return res
+	}
+	res.ReqHeaders = metadataToHeader(md)
+	res.ReqBody = []byte(payload)
+
+	ctx := metadata.NewOutgoingContext(g.ctx, md)
+	if g.packet.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(g.packet.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	// Action
+	resps, header, err := g.invoke(ctx, reqs)
+	duration := time.Since(reqStartTime)
+
+	statusCode = int(status.Code(err))
+	if err != nil {
+		requestErr = g.fetchErrType(err)
+	}
+
+	if requestErr.Type == "" {
+		respHeaders = metadataToHeader(header)
+		respBody, err = marshalResponses(g.method, resps)
+		if err != nil {
+			requestErr = types.RequestError{Type: types.ErrorUnkown, Reason: err.Error()}
+		}
+	}
+
+	if requestErr.Type != "" {
+		failedCaptures = captureEnvironmentVariables(g.packet.EnvsToCapture, nil, nil, nil, extractedVars)
+	} else {
+		// capture
+		if len(g.packet.EnvsToCapture) > 0 {
+			failedCaptures = captureEnvironmentVariables(g.packet.EnvsToCapture, respHeaders, respBody, nil, extractedVars)
+		}
+
+		// assert
+		if len(g.packet.Assertions) > 0 {
+			_, failedAssertions = applyAssertions(g.packet.Assertions, &evaluator.AssertEnv{
+				StatusCode:   int64(statusCode),
+				ResponseSize: int64(len(respBody)),
+				ResponseTime: duration.Milliseconds(), // in ms
+				Body:         string(respBody),
+				Headers:      respHeaders,
+				Variables:    concatEnvs(envs, extractedVars),
+			})
+		}
+	}
+
+	res.StatusCode = statusCode
+	res.Duration = duration
+	res.ContentLength = int64(len(respBody))
+	res.Err = requestErr
+	res.RespHeaders = respHeaders
+	res.RespBody = respBody
+	res.Custom = map[string]interface{}{
+		"grpcStatus": codes.Code(statusCode).String(),
+	}
+	res.ExtractedEnvs = extractedVars
+	res.FailedCaptures = failedCaptures
+	res.FailedAssertions = failedAssertions
+	return res
+}
+
+// prepareReq injects the envs to the payload and headers and creates the request messages.
+// Payload of a client streaming method can be a JSON array, each element is sent as a separate message.
+func (g *GrpcRequester) prepareReq(envs map[string]interface{}) (
+	msgs []proto.Message, md metadata.MD, payload string, err error) {
+	payload, err = g.inject(g.packet.Payload, envs)
+	if err != nil {
+		return
+	}
+
+	md = metadata.MD{}
+	for k, v := range g.packet.Headers {
+		if k, err = g.inject(k, envs); err != nil {
+			return
+		}
+		if v, err = g.inject(v, envs); err != nil {
+			return
+		}
+		md.Append(k, v)
+	}
+
+	body := strings.TrimSpace(payload)
+	if body == "" {
+		body = "{}"
+	}
+
+	var rawMsgs []json.RawMessage
+	if g.method.IsClientStreaming() && strings.HasPrefix(body, "[") {
+		if err = json.Unmarshal([]byte(body), &rawMsgs); err != nil {
+			return
+		}
+	} else {
+		rawMsgs = []json.RawMessage{json.RawMessage(body)}
+	}
+
+	for _, raw := range rawMsgs {
+		msg := dynamic.NewMessage(g.method.GetInputType())
+		if err = msg.UnmarshalJSON(raw); err != nil {
+			return
+		}
+		msgs = append(msgs, msg)
+	}
+	return
+}
+
+func (g *GrpcRequester) inject(text string, envs map[string]interface{}) (string, error) {
+	var err error
+	if g.dynamicRgx.MatchString(text) {
+		if text, err = g.ei.InjectDynamic(text); err != nil {
+			return "", err
+		}
+	}
+	if g.envRgx.MatchString(text) {
+		return g.ei.InjectEnv(text, envs)
+	}
+	return text, nil
+}
+
+// invoke calls the method with the given request messages and returns the response messages and header metadata.
+func (g *GrpcRequester) invoke(ctx context.Context, reqs []proto.Message) ([]proto.Message, metadata.MD, error) {
+	var header metadata.MD
+
+	if !g.method.IsClientStreaming() && !g.method.IsServerStreaming() {
+		resp, err := g.stub.InvokeRpc(ctx, g.method, reqs[0], grpc.Header(&header))
+		if err != nil {
+			return nil, header, err
+		}
+		return []proto.Message{resp}, header, nil
+	}
+
+	// all of the streaming kinds are handled as a bidirectional stream
+	fullMethod := "/" + g.method.GetService().GetFullyQualifiedName() + "/" + g.method.GetName()
+	stream, err := g.conn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}, fullMethod)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, req := range reqs {
+		if err = stream.SendMsg(req); err != nil {
+			break
+		}
+	}
+	if err == nil || errors.Is(err, io.EOF) {
+		err = stream.CloseSend()
+	}
+
+	var resps []proto.Message
+	for err == nil {
+		resp := dynamic.NewMessage(g.method.GetOutputType())
+		if err = stream.RecvMsg(resp); err == nil {
+			resps = append(resps, resp)
+		}
+	}
+	header, _ = stream.Header()
+
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return resps, header, err
+}
+
+func (g *GrpcRequester) fetchErrType(err error) types.RequestError {
+	st, ok := status.FromError(err)
+	if !ok {
+		return types.RequestError{Type: types.ErrorUnkown, Reason: err.Error()}
+	}
+
+	switch st.Code() {
+	case codes.Canceled:
+		if g.ctx.Err() != nil {
+			return types.RequestError{Type: types.ErrorIntented, Reason: types.ReasonCtxCanceled}
+		}
+	case codes.DeadlineExceeded:
+		return types.RequestError{Type: types.ErrorConn, Reason: types.ReasonConnTimeout}
+	case codes.Unavailable:
+		return types.RequestError{Type: types.ErrorConn, Reason: st.Message()}
+	}
+
+	// other status codes are the responses of the server, they are reported as the status code
+	return types.RequestError{}
+}
+
+// marshalResponses returns the JSON form of the response message. Responses of a server streaming
+// method are returned as a JSON array.
+func marshalResponses(method *desc.MethodDescriptor, resps []proto.Message) ([]byte, error) {
+	if len(resps) == 0 && !method.IsServerStreaming() {
+		return nil, nil
+	}
+
+	bodies := make([][]byte, 0, len(resps))
+	for _, r := range resps {
+		dm, err := dynamic.AsDynamicMessage(r)
+		if err != nil {
+			return nil, err
+		}
+		b, err := dm.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, b)
+	}
+
+	if !method.IsServerStreaming() {
+		return bodies[0], nil
+	}
+	return append(append([]byte("["), bytes.Join(bodies, []byte(","))...), ']'), nil
+}
+
+func metadataToHeader(md metadata.MD) http.Header {
+	header := make(http.Header, len(md))
+	for k, values := range md {
+		for _, v := range values {
+			header.Add(k, v)
+		}
+	}
+	return header
+}
+
+func (g *GrpcRequester) Done() {
+	if g.conn != nil {
+		g.conn.Close()
+	}
+}
+
+func (g *GrpcRequester) Type() string {
+	return "GRPC"
+}
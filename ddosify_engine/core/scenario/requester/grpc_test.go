@@ -0,0 +1,272 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"go.ddosify.com/ddosify/core/scenario/scripting/injection"
+	"go.ddosify.com/ddosify/core/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// startGrpcServer serves the echo service of testdata/echo.proto with dynamic messages,
+// and the health service with server reflection.
+func startGrpcServer(t *testing.T) string {
+	p := protoparse.Parser{ImportPaths: []string{"testdata"}}
+	fds, err := p.ParseFiles("echo.proto")
+	if err != nil {
+		t.Fatalf("proto parse error: %v", err)
+	}
+	sd := fds[0].FindService("echo.Echo")
+
+	echo := func(_ interface{}, stream grpc.ServerStream) error {
+		fullMethod, _ := grpc.MethodFromServerStream(stream)
+		md := sd.FindMethodByName(path.Base(fullMethod))
+		if md == nil {
+			return status.Error(codes.Unimplemented, fullMethod)
+		}
+
+		incoming, _ := metadata.FromIncomingContext(stream.Context())
+		stream.SetHeader(metadata.Pairs("x-echo", strings.Join(incoming.Get("x-token"), "")))
+
+		var names []string
+		var count int32
+		for {
+			in := dynamic.NewMessage(md.GetInputType())
+			if err := stream.RecvMsg(in); err == io.EOF {
+				break
+			} else if err != nil {
+				return err
+			}
+			names = append(names, in.GetFieldByName("name").(string))
+			count = in.GetFieldByName("count").(int32)
+			if !md.IsClientStreaming() {
+				break
+			}
+		}
+		if len(names) > 0 && names[0] == "missing" {
+			return status.Error(codes.NotFound, "not found")
+		}
+
+		send := func(msg string) error {
+			out := dynamic.NewMessage(md.GetOutputType())
+			out.SetFieldByName("message", msg)
+			return stream.SendMsg(out)
+		}
+		if md.IsServerStreaming() {
+			for i := 0; i < int(count); i++ {
+				if err := send(fmt.Sprintf("hello %s %d", names[0], i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return send("hello " + strings.Join(names, " "))
+	}
+
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(echo))
+	hs := health.NewServer()
+	hs.SetServingStatus("ddosify", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return "grpc://" + lis.Addr().String()
+}
+
+func TestGrpcRequester(t *testing.T) {
+	t.Parallel()
+	target := startGrpcServer(t)
+	protoConf := func(method string) types.GrpcConf {
+		return types.GrpcConf{Method: method, ProtoFile: "testdata/echo.proto"}
+	}
+	jsonPath := "message"
+	headerKey := "X-Echo"
+
+	tests := []struct {
+		name       string
+		step       types.ScenarioStep
+		statusCode int
+		body       string
+		errType    string
+	}{
+		{
+			name: "Unary",
+			step: types.ScenarioStep{
+				Grpc:    protoConf("echo.Echo/Say"),
+				Payload: `{"name": "{{name}}"}`,
+			},
+			body: `{"message":"hello ddosify"}`,
+		},
+		{
+			name: "ServerStreaming",
+			step: types.ScenarioStep{
+				Grpc:    protoConf("/echo.Echo/Stream"),
+				Payload: `{"name": "{{name}}", "count": 2}`,
+			},
+			body: `[{"message":"hello ddosify 0"},{"message":"hello ddosify 1"}]`,
+		},
+		{
+			name: "ClientStreaming",
+			step: types.ScenarioStep{
+				Grpc:    protoConf("echo.Echo/Collect"),
+				Payload: `[{"name": "a"}, {"name": "{{name}}"}]`,
+			},
+			body: `{"message":"hello a ddosify"}`,
+		},
+		{
+			name: "ErrorStatus",
+			step: types.ScenarioStep{
+				Grpc:    protoConf("echo.Echo/Say"),
+				Payload: `{"name": "missing"}`,
+			},
+			statusCode: int(codes.NotFound),
+		},
+		{
+			name: "Reflection",
+			step: types.ScenarioStep{
+				Grpc:    types.GrpcConf{Method: "grpc.health.v1.Health/Check"},
+				Payload: `{"service": "{{name}}"}`,
+			},
+			body: `{"status":"SERVING"}`,
+		},
+		{
+			name: "InvalidPayload",
+			step: types.ScenarioStep{
+				Grpc:    protoConf("echo.Echo/Say"),
+				Payload: `{"unknown": 1}`,
+			},
+			errType: types.ErrorInvalidRequest,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		tf := func(t *testing.T) {
+			t.Parallel()
+			test.step.ID = 1
+			test.step.URL = target
+			test.step.Timeout = types.DefaultTimeout
+			test.step.Headers = map[string]string{"x-token": "{{name}}"}
+			test.step.Assertions = []string{"equals(status_code, 0)"}
+			test.step.EnvsToCapture = []types.EnvCaptureConf{
+				{Name: "msg", From: types.Body, JsonPath: &jsonPath},
+				{Name: "echo", From: types.Header, Key: &headerKey},
+			}
+
+			g := initRequester(t, &GrpcRequester{}, test.step)
+			res := g.Send(map[string]interface{}{"name": "ddosify"})
+
+			if res.Err.Type != test.errType {
+				t.Fatalf("Expected error type: %q, Found: %q %s", test.errType, res.Err.Type, res.Err.Reason)
+			}
+			if test.errType != "" {
+				return
+			}
+			if res.StatusCode != test.statusCode {
+				t.Errorf("Expected status code: %d, Found: %d", test.statusCode, res.StatusCode)
+			}
+			if string(res.RespBody) != test.body {
+				t.Errorf("Expected body: %s, Found: %s", test.body, res.RespBody)
+			}
+			if res.Duration <= 0 {
+				t.Errorf("Expected duration to be set")
+			}
+
+			if test.statusCode != 0 {
+				if len(res.FailedAssertions) != 1 {
+					t.Errorf("Expected status code assertion to fail, Found: %v", res.FailedAssertions)
+				}
+				return
+			}
+			if len(res.FailedAssertions) != 0 {
+				t.Errorf("Expected no failed assertions, Found: %v", res.FailedAssertions)
+			}
+			if test.name != "Reflection" {
+				if res.ExtractedEnvs["echo"] != "ddosify" {
+					t.Errorf("Expected metadata to be captured, Found: %v", res.ExtractedEnvs["echo"])
+				}
+				if test.name != "ServerStreaming" && !strings.HasPrefix(res.ExtractedEnvs["msg"].(string), "hello") {
+					t.Errorf("Expected message to be captured, Found: %v", res.ExtractedEnvs["msg"])
+				}
+			}
+		}
+		t.Run(test.name, tf)
+	}
+}
+
+func TestGrpcRequesterUnknownMethod(t *testing.T) {
+	t.Parallel()
+	target := startGrpcServer(t)
+
+	for _, conf := range []types.GrpcConf{
+		{Method: "echo.Echo/Unknown", ProtoFile: "testdata/echo.proto"},
+		{Method: "unknown.Service/Method"},
+	} {
+		g := &GrpcRequester{}
+		s := types.ScenarioStep{ID: 1, URL: target, Grpc: conf}
+		if err := g.Init(context.TODO(), s, nil, false, &injection.EnvironmentInjector{}); err == nil {
+			t.Errorf("Expected error for unknown method %s", conf.Method)
+		}
+		g.Done()
+	}
+}
+
+func TestGrpcRequesterUnavailable(t *testing.T) {
+	t.Parallel()
+
+	lis, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := lis.Addr().String()
+	lis.Close()
+
+	g := initRequester(t, &GrpcRequester{}, types.ScenarioStep{
+		ID:      1,
+		URL:     "grpc://" + addr,
+		Timeout: types.DefaultTimeout,
+		Grpc:    types.GrpcConf{Method: "echo.Echo/Say", ProtoFile: "testdata/echo.proto"},
+	})
+
+	res := g.Send(map[string]interface{}{})
+	if res.Err.Type != types.ErrorConn {
+		t.Errorf("Expected connection error, Found: %v", res.Err)
+	}
+}
@@ -24,13 +24,16 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptrace"
 	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -58,6 +61,12 @@ type HttpRequester struct {
 	debug                bool
 	dynamicRgx           *regexp.Regexp
 	envRgx               *regexp.Regexp
+	tokens               *OAuth2Tokens
+}
+
+// SetOAuth2Tokens sets the token cache shared with the other steps. It should be called before Init.
+func (h *HttpRequester) SetOAuth2Tokens(tokens *OAuth2Tokens) {
+	h.tokens = tokens
 }
 
 // Init creates a client with the given scenarioItem. HttpRequester uses the same http.Client for all requests
@@ -74,12 +83,18 @@ func (h *HttpRequester) Init(ctx context.Context, s types.ScenarioStep, proxyAdd
 	h.envRgx = regexp.MustCompile(regex.EnvironmentVariableRegex)
 
 	// Transport segment
-	tr := h.initTransport()
-	tr.MaxIdleConnsPerHost = 60000
-	tr.MaxIdleConns = 0
+	var tr http.RoundTripper
+	if h.isH3() {
+		tr = h.initH3Transport()
+	} else {
+		t := h.initTransport()
+		t.MaxIdleConnsPerHost = 60000
+		t.MaxIdleConns = 0
+		tr = t
+	}
 
 	// http client
-	h.client = &http.Client{Transport: tr, Timeout: time.Duration(h.packet.Timeout) * time.Second}
+	h.client = &http.Client{Transport: tr, Timeout: h.clientTimeout()}
 	if val, ok := h.packet.Custom["disable-redirect"]; ok {
 		val := val.(bool)
 		if val {
@@ -160,6 +175,19 @@ func (h *HttpRequester) Init(ctx context.Context, s types.ScenarioStep, proxyAdd
 		h.containsEnvVar["basicauth"] = true
 	}
 
+	// oauth2
+	if h.packet.Auth.IsOAuth2() {
+		if h.tokens == nil {
+			h.tokens = NewOAuth2Tokens()
+		}
+		a := h.packet.Auth
+		for _, v := range []string{a.TokenURL, a.ClientID, a.ClientSecret, a.Scope} {
+			if h.envRgx.MatchString(v) {
+				h.containsEnvVar["oauth2"] = true
+			}
+		}
+	}
+
 	return
 }
 
@@ -173,6 +201,13 @@ func (h *HttpRequester) Done() {
 }
 
 func (h *HttpRequester) Send(client *http.Client, envs map[string]interface{}) (res *types.ScenarioStepResult) {
+	return h.SendWithTrace(client, envs, types.TraceContext{})
+}
+
+// SendWithTrace is the same as Send, except that the request carries the traceparent header of the given span and
+// the phases of the request are recorded as trace events. The request is not traced if tc is not valid.
+func (h *HttpRequester) SendWithTrace(client *http.Client, envs map[string]interface{},
+	tc types.TraceContext) (res *types.ScenarioStepResult) {
 	var statusCode int
 	var contentLength int64
 	var requestErr types.RequestError
@@ -183,6 +218,7 @@ func (h *HttpRequester) Send(client *http.Client, envs map[string]interface{}) (
 	var respBody []byte
 	var respHeaders http.Header
 	var bodyReadErr error
+	var stream *sseStream
 	var extractedVars = make(map[string]interface{})
 	var failedCaptures = make(map[string]string, 0)
 	var failedAssertions = make([]types.FailedAssertion, 0)
@@ -196,6 +232,13 @@ func (h *HttpRequester) Send(client *http.Client, envs map[string]interface{}) (
 		// engine mode is 'ddosify'
 		// if passed client is nil , use requesters client that is dedicated to one step, thereby one transport
 		client = h.client
+	} else if h.isH3() {
+		// engine mode is 'distinct-user' or 'repeated-user'
+		// QUIC connections of the step are shared between iterations, only the cookies of the passed client are used
+		h3Client := *client
+		h3Client.Transport = h.client.Transport
+		h3Client.Timeout = h.clientTimeout()
+		client = &h3Client
 	} else {
 		// engine mode is 'distinct-user' or 'repeated-user'
 		// passed client is used for multiple steps throughout an iteration, update transport
@@ -207,7 +250,7 @@ func (h *HttpRequester) Send(client *http.Client, envs map[string]interface{}) (
 		}
 
 		// update client timeout
-		client.Timeout = time.Duration(h.packet.Timeout) * time.Second
+		client.Timeout = h.clientTimeout()
 	}
 
 	durations := &duration{
@@ -217,7 +260,11 @@ func (h *HttpRequester) Send(client *http.Client, envs map[string]interface{}) (
 		resStartCh:           make(chan time.Time, 1),
 	}
 	headersAddedByClient := make(map[string][]string)
-	trace := newTrace(durations, h.proxyAddr, headersAddedByClient)
+	var events *traceEvents
+	if tc.IsValid() {
+		events = &traceEvents{}
+	}
+	trace := newTrace(durations, h.proxyAddr, headersAddedByClient, events)
 
 	httpReq, err := h.prepareReq(usableVars, trace)
 
@@ -229,11 +276,40 @@ func (h *HttpRequester) Send(client *http.Client, envs map[string]interface{}) (
 			StepName:  h.packet.Name,
 			RequestID: uuid.New(),
 			Err:       requestErr,
+			Trace:     tc,
 		}
 
 		return res
 	}
 
+	if tc.IsValid() {
+		httpReq.Header.Set("traceparent", tc.Traceparent())
+	}
+
+	// the token request is not a phase of the step, its duration is reported separately
+	var auth types.Auth
+	var accessToken string
+	var tokenFetchDur time.Duration
+	if h.packet.Auth.IsOAuth2() {
+		auth, err = h.oauth2Auth(usableVars)
+		if err == nil {
+			ctx, cancel := context.WithTimeout(h.ctx, time.Duration(h.packet.Timeout)*time.Second)
+			accessToken, tokenFetchDur, err = h.tokens.Token(ctx, auth, client)
+			cancel()
+		}
+		if err != nil {
+			res = &types.ScenarioStepResult{
+				StepID:    h.packet.ID,
+				StepName:  h.packet.Name,
+				RequestID: uuid.New(),
+				Err:       types.RequestError{Type: types.ErrorAuth, Reason: fmt.Sprintf("Could not get oauth2 token, %s", err)},
+				Trace:     tc,
+			}
+			return res
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
 	if httpReq.Body != nil {
 		if int64(len(h.packet.Payload)) > 300000 {
 			// Don't store req bodies bigger than 300KB
@@ -252,8 +328,8 @@ func (h *HttpRequester) Send(client *http.Client, envs map[string]interface{}) (
 	httpRes, err := client.Do(httpReq)
 	if err != nil {
 		requestErr = fetchErrType(err)
-		failedCaptures = h.captureEnvironmentVariables(nil, nil, nil, extractedVars)
-	} else {
+		failedCaptures = captureEnvironmentVariables(h.packet.EnvsToCapture, nil, nil, nil, extractedVars)
+	} else if !h.packet.IsSse() {
 		// got response, no timeout or any other error, resStart should be set
 		durations.setResDur()
 	}
@@ -263,7 +339,16 @@ func (h *HttpRequester) Send(client *http.Client, envs map[string]interface{}) (
 	// may not be able to re-use a persistent TCP connection to the server for a subsequent "keep-alive" request.
 	if httpRes != nil {
 		// read resp body conditionally
-		if h.debug || len(h.packet.EnvsToCapture) > 0 || len(h.packet.Assertions) > 0 {
+		if h.packet.IsSse() {
+			// response read duration includes the whole stream
+			stream, bodyReadErr = readSseStream(httpRes.Body, h.packet.Sse)
+			durations.setResDur()
+			respBody = stream.raw
+			if bodyReadErr != nil {
+				requestErr = fetchErrType(bodyReadErr)
+			}
+		} else if h.debug || h.packet.CaptureBody || len(h.packet.EnvsToCapture) > 0 || len(h.packet.Assertions) > 0 ||
+			h.packet.IsGraphql() {
 			respBody, bodyReadErr = io.ReadAll(httpRes.Body)
 			if bodyReadErr != nil {
 				requestErr = fetchErrType(bodyReadErr)
@@ -296,14 +381,28 @@ func (h *HttpRequester) Send(client *http.Client, envs map[string]interface{}) (
 			}
 		}
 
+		// a GraphQL response with errors is a failure even if its status code is 200
+		var gqlRes graphqlResponse
+		if h.packet.IsGraphql() && bodyReadErr == nil {
+			json.Unmarshal(respBody, &gqlRes)
+			if gqlRes.Errors == nil {
+				gqlRes.Errors = []interface{}{}
+			}
+			if len(gqlRes.Errors) > 0 && statusCode == http.StatusOK {
+				requestErr = types.RequestError{Type: types.ErrorGraphql, Reason: gqlRes.reason()}
+			}
+		}
+
 		// capture
-		if len(h.packet.EnvsToCapture) > 0 {
-			failedCaptures = h.captureEnvironmentVariables(httpRes.Header, respBody, cookies, extractedVars)
+		if len(h.packet.EnvsToCapture) > 0 && stream != nil {
+			failedCaptures = captureFromEvents(h.packet.EnvsToCapture, httpRes.Header, stream, cookies, extractedVars)
+		} else if len(h.packet.EnvsToCapture) > 0 {
+			failedCaptures = captureEnvironmentVariables(h.packet.EnvsToCapture, httpRes.Header, respBody, cookies, extractedVars)
 		}
 
 		// assert
 		if len(h.packet.Assertions) > 0 {
-			_, failedAssertions = h.applyAssertions(&evaluator.AssertEnv{
+			_, failedAssertions = applyAssertions(h.packet.Assertions, &evaluator.AssertEnv{
 				StatusCode:   int64(httpRes.StatusCode),
 				ResponseSize: int64(len(respBody)),
 				ResponseTime: durations.totalDuration().Milliseconds(), // in ms
@@ -311,6 +410,8 @@ func (h *HttpRequester) Send(client *http.Client, envs map[string]interface{}) (
 				Headers:      httpRes.Header,
 				Variables:    concatEnvs(envs, extractedVars),
 				Cookies:      cookies,
+				GqlErrors:    gqlRes.Errors,
+				GqlData:      gqlRes.Data,
 			})
 		}
 	}
@@ -353,16 +454,37 @@ func (h *HttpRequester) Send(client *http.Client, envs map[string]interface{}) (
 		UsableEnvs:       usableVars,
 		FailedCaptures:   failedCaptures,
 		FailedAssertions: failedAssertions,
+		Trace:            tc,
+		TraceEvents:      events.list(),
 	}
 
 	if strings.EqualFold(h.request.URL.Scheme, types.ProtocolHTTPS) { // TODOcorr : check here, used URL.scheme instead TODOcorr
 		res.Custom["tlsDuration"] = durations.getTLSDur()
 	}
 
+	if h.tunneledProxy() {
+		res.Custom["proxyConnDuration"] = durations.getProxyConnDur()
+	}
+
+	if tokenFetchDur > 0 {
+		res.Custom["tokenFetchDuration"] = tokenFetchDur
+		res.Custom["tokenFetchCount"] = int64(1)
+	}
+	if statusCode == http.StatusUnauthorized && accessToken != "" {
+		// the token is revoked or expired earlier than its expires_in, the next request gets a new one
+		h.tokens.Invalidate(auth, client, accessToken)
+	}
+
 	if ddResTime != 0 {
 		res.Custom["ddResponseTime"] = ddResTime
 	}
 
+	if stream != nil {
+		for k, v := range stream.metrics(reqStartTime) {
+			res.Custom[k] = v
+		}
+	}
+
 	return
 }
 
@@ -505,7 +627,7 @@ func (h *HttpRequester) prepareReq(envs map[string]interface{}, trace *httptrace
 			return nil, err
 		}
 	}
-	if username != "" && password != "" {
+	if username != "" && password != "" && !h.packet.Auth.IsOAuth2() {
 		httpReq.SetBasicAuth(username, password)
 	}
 
@@ -513,6 +635,28 @@ func (h *HttpRequester) prepareReq(envs map[string]interface{}, trace *httptrace
 	return httpReq, nil
 }
 
+// oauth2Auth returns the oauth2 auth of the step with the envs injected, the injected auth keys the token cache.
+func (h *HttpRequester) oauth2Auth(envs map[string]interface{}) (auth types.Auth, err error) {
+	auth = h.packet.Auth
+	inject := func(fields ...*string) error {
+		for _, f := range fields {
+			if *f, err = h.ei.InjectEnv(*f, envs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if h.containsEnvVar["oauth2"] {
+		if err = inject(&auth.TokenURL, &auth.ClientID, &auth.ClientSecret, &auth.Scope); err != nil {
+			return
+		}
+	}
+	if h.containsEnvVar["basicauth"] {
+		err = inject(&auth.Username, &auth.Password)
+	}
+	return
+}
+
 // Currently we can't detect exact error type by returned err.
 // But we need to find an elegant way instead of this.
 func fetchErrType(err error) types.RequestError {
@@ -531,6 +675,12 @@ func fetchErrType(err error) types.RequestError {
 			} else {
 				requestErr = types.RequestError{Type: types.ErrorProxy, Reason: errString}
 			}
+		} else if strings.Contains(errString, "Proxy Authentication Required") ||
+			strings.Contains(errString, "username/password authentication failed") {
+			// failed CONNECT and SOCKS5 authentications are not wrapped as proxyconnect errors
+			requestErr = types.RequestError{Type: types.ErrorProxy, Reason: types.ReasonProxyAuth}
+		} else if strings.Contains(errString, "socks connect") {
+			requestErr = types.RequestError{Type: types.ErrorProxy, Reason: errString}
 		} else if strings.Contains(errString, context.DeadlineExceeded.Error()) {
 			requestErr = types.RequestError{Type: types.ErrorConn, Reason: types.ReasonConnTimeout}
 		} else if strings.Contains(errString, "Client.Timeout exceeded while awaiting headers") {
@@ -556,6 +706,11 @@ func (h *HttpRequester) initTransport() *http.Transport {
 		TLSClientConfig: h.initTLSConfig(),
 		Proxy:           http.ProxyURL(h.proxyAddr),
 	}
+	if h.packet.SourceIP != nil {
+		// connections to the target, or to the proxy, are made from the source IP
+		dialer := &net.Dialer{LocalAddr: &net.TCPAddr{IP: h.packet.SourceIP}}
+		tr.DialContext = dialer.DialContext
+	}
 
 	tr.DisableKeepAlives = false
 	if h.packet.Headers["Connection"] == "close" {
@@ -573,6 +728,35 @@ func (h *HttpRequester) initTransport() *http.Transport {
 	return tr
 }
 
+// initH3Transport creates an HTTP/3 transport if the h3 custom flag is set. Proxies are not supported.
+func (h *HttpRequester) initH3Transport() http.RoundTripper {
+	disableCompression := false
+	if val, ok := h.packet.Custom["disable-compression"]; ok {
+		disableCompression = val.(bool)
+	}
+	return newH3RoundTripper(h.initTLSConfig(), disableCompression)
+}
+
+// clientTimeout returns the timeout of the whole request. The stream duration of the SSE steps is added to the
+// timeout of the step, so that a stream can be read for the given duration.
+func (h *HttpRequester) clientTimeout() time.Duration {
+	return time.Duration(h.packet.Timeout+h.packet.Sse.Duration) * time.Second
+}
+
+// tunneledProxy returns whether the requests are tunneled through the proxy, by the SOCKS5 handshake
+// or by the HTTP CONNECT for an HTTPS target.
+func (h *HttpRequester) tunneledProxy() bool {
+	if h.proxyAddr == nil || h.isH3() {
+		return false
+	}
+	return strings.HasPrefix(h.proxyAddr.Scheme, "socks") || strings.EqualFold(h.request.URL.Scheme, types.ProtocolHTTPS)
+}
+
+func (h *HttpRequester) isH3() bool {
+	val, ok := h.packet.Custom["h3"]
+	return ok && val.(bool)
+}
+
 func (h *HttpRequester) updateTransport(tr *http.Transport) {
 	tr.TLSClientConfig = h.initTLSConfig()
 	tr.Proxy = http.ProxyURL(h.proxyAddr)
@@ -637,7 +821,7 @@ func (h *HttpRequester) initRequestInstance() (err error) {
 	h.request.Header = header
 
 	// Auth should be set after header assignment.
-	if h.packet.Auth != (types.Auth{}) {
+	if h.packet.Auth != (types.Auth{}) && !h.packet.Auth.IsOAuth2() {
 		h.request.SetBasicAuth(h.packet.Auth.Username, h.packet.Auth.Password)
 	}
 
@@ -653,9 +837,27 @@ func (h *HttpRequester) Type() string {
 	return "HTTP"
 }
 
-func newTrace(duration *duration, proxyAddr *url.URL, headersByClient map[string][]string) *httptrace.ClientTrace {
+func newTrace(duration *duration, proxyAddr *url.URL, headersByClient map[string][]string,
+	events *traceEvents) *httptrace.ClientTrace {
 	var dnsStart, connStart, tlsStart, reqStart time.Time
 
+	// The tunnel through the proxy, the SOCKS5 handshake or the HTTP CONNECT, starts when the connection
+	// to the proxy is established, after the TLS handshake for an HTTPS proxy. It ends when the TLS handshake
+	// with the target starts, or when the connection is got for an HTTP target through a SOCKS5 proxy.
+	// An HTTP target through an HTTP(S) proxy is not tunneled.
+	var proxyConnStart time.Time
+	var proxyConnEnded bool
+	httpsProxy := proxyAddr != nil && proxyAddr.Scheme == "https"
+	socksProxy := proxyAddr != nil && strings.HasPrefix(proxyAddr.Scheme, "socks")
+	endProxyConn := func(tunneled bool) {
+		if !proxyConnStart.IsZero() && !proxyConnEnded {
+			proxyConnEnded = true
+			if tunneled {
+				duration.setProxyConnDur(time.Since(proxyConnStart))
+			}
+		}
+	}
+
 	// According to the doc in the trace.go;
 	// Some of the hooks below can be triggered multiple times in case of retried connections, "Happy Eyeballs" etc..
 	// Also, some of the hooks can be triggered after the TCP roundtrip if the request is not successfully finished.
@@ -666,6 +868,7 @@ func newTrace(duration *duration, proxyAddr *url.URL, headersByClient map[string
 
 	return &httptrace.ClientTrace{
 		DNSStart: func(info httptrace.DNSStartInfo) {
+			events.add("dns_start")
 			m.Lock()
 			if dnsStart.IsZero() {
 				dnsStart = time.Now()
@@ -673,6 +876,7 @@ func newTrace(duration *duration, proxyAddr *url.URL, headersByClient map[string
 			m.Unlock()
 		},
 		DNSDone: func(dnsInfo httptrace.DNSDoneInfo) {
+			events.add("dns_done")
 			m.Lock()
 			// no need to handle error in here. We can detect it at http.Client.Do return.
 			if dnsInfo.Err == nil {
@@ -681,6 +885,7 @@ func newTrace(duration *duration, proxyAddr *url.URL, headersByClient map[string
 			m.Unlock()
 		},
 		ConnectStart: func(network, addr string) {
+			events.add("connect_start")
 			m.Lock()
 			if connStart.IsZero() {
 				connStart = time.Now()
@@ -688,23 +893,30 @@ func newTrace(duration *duration, proxyAddr *url.URL, headersByClient map[string
 			m.Unlock()
 		},
 		ConnectDone: func(network, addr string, err error) {
+			events.add("connect_done")
 			m.Lock()
 			// no need to handle error in here. We can detect it at http.Client.Do return.
 			if err == nil {
 				duration.setConnDur(time.Since(connStart))
+				if proxyAddr != nil && !httpsProxy && proxyConnStart.IsZero() {
+					proxyConnStart = time.Now()
+				}
 			}
 			m.Unlock()
 		},
 		TLSHandshakeStart: func() {
+			events.add("tls_handshake_start")
 			m.Lock()
 			// This hook can be hit 2 times;
 			// If both proxy and target are HTTPS
 			//	First hit is for proxy, second is for target.
 			//  To catch the second TLS start time (for target), we can't perform tlsStart.IsZero() check here.
 			tlsStart = time.Now()
+			endProxyConn(true)
 			m.Unlock()
 		},
 		TLSHandshakeDone: func(cs tls.ConnectionState, e error) {
+			events.add("tls_handshake_done")
 			m.Lock()
 			// This hook can be hit 2 times;
 			// If proxy: HTTPS, target: HTTPS
@@ -714,18 +926,23 @@ func newTrace(duration *duration, proxyAddr *url.URL, headersByClient map[string
 			if e == nil {
 				if proxyAddr == nil || proxyAddr.Hostname() != cs.ServerName {
 					duration.setTLSDur(time.Since(tlsStart))
+				} else if httpsProxy && proxyConnStart.IsZero() {
+					proxyConnStart = time.Now()
 				}
 			}
 			m.Unlock()
 		},
 		GotConn: func(connInfo httptrace.GotConnInfo) {
+			events.add("got_conn")
 			m.Lock()
+			endProxyConn(socksProxy)
 			if reqStart.IsZero() {
 				reqStart = time.Now()
 			}
 			m.Unlock()
 		},
 		WroteRequest: func(w httptrace.WroteRequestInfo) {
+			events.add("wrote_request")
 			// no need to handle error in here. We can detect it at http.Client.Do return.
 			if w.Err == nil {
 				go duration.setReqDur(time.Since(reqStart))
@@ -733,6 +950,7 @@ func newTrace(duration *duration, proxyAddr *url.URL, headersByClient map[string
 			}
 		},
 		GotFirstResponseByte: func() {
+			events.add("got_first_response_byte")
 			go duration.setServerProcessDur()
 			go duration.setResStartTime(time.Now())
 		},
@@ -742,9 +960,53 @@ func newTrace(duration *duration, proxyAddr *url.URL, headersByClient map[string
 	}
 }
 
-func (h *HttpRequester) applyAssertions(assertEnv *evaluator.AssertEnv) (bool, []types.FailedAssertion) {
+// traceEvents records the hooks of the httptrace as the events of the span of a step. Nil records nothing.
+type traceEvents struct {
+	mu     sync.Mutex
+	events []types.TraceEvent
+}
+
+func (t *traceEvents) add(name string) {
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	t.mu.Lock()
+	t.events = append(t.events, types.TraceEvent{Name: name, Time: now})
+	t.mu.Unlock()
+}
+
+// list returns the events in the order of their times.
+func (t *traceEvents) list() []types.TraceEvent {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := make([]types.TraceEvent, len(t.events))
+	copy(events, t.events)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events
+}
+
+// graphqlResponse is the response body of a GraphQL operation.
+type graphqlResponse struct {
+	Data   interface{}   `json:"data"`
+	Errors []interface{} `json:"errors"`
+}
+
+// reason returns the message of the first error to be shown in the error distribution.
+func (g graphqlResponse) reason() string {
+	if e, ok := g.Errors[0].(map[string]interface{}); ok {
+		if msg, ok := e["message"].(string); ok {
+			return msg
+		}
+	}
+	return "graphql error"
+}
+
+func applyAssertions(assertions []string, assertEnv *evaluator.AssertEnv) (bool, []types.FailedAssertion) {
 	// result, failedAssertionIndex, assertionError
-	assertions := h.packet.Assertions
 	assertionsSuccess := true
 	failedAssertions := []types.FailedAssertion{}
 	for _, rule := range assertions {
@@ -772,7 +1034,7 @@ func (h *HttpRequester) applyAssertions(assertEnv *evaluator.AssertEnv) (bool, [
 
 }
 
-func (h *HttpRequester) captureEnvironmentVariables(header http.Header, respBody []byte,
+func captureEnvironmentVariables(envsToCapture []types.EnvCaptureConf, header http.Header, respBody []byte,
 	cookies map[string]*http.Cookie, extractedVars map[string]interface{}) map[string]string {
 	var err error
 	failedCaptures := make(map[string]string, 0)
@@ -780,7 +1042,7 @@ func (h *HttpRequester) captureEnvironmentVariables(header http.Header, respBody
 
 	// request failed, only set default value for later steps
 	if header == nil && respBody == nil {
-		for _, ce := range h.packet.EnvsToCapture {
+		for _, ce := range envsToCapture {
 			extractedVars[ce.Name] = "" // default value for not extracted envs
 			failedCaptures[ce.Name] = "request failed"
 		}
@@ -788,7 +1050,7 @@ func (h *HttpRequester) captureEnvironmentVariables(header http.Header, respBody
 	}
 
 	// extract from response
-	for _, ce := range h.packet.EnvsToCapture {
+	for _, ce := range envsToCapture {
 		var val interface{}
 		switch ce.From {
 		case types.Header:
@@ -818,6 +1080,9 @@ type duration struct {
 	// TCP connection setup duration
 	connDur time.Duration
 
+	// Duration of the tunnel setup through the proxy, the SOCKS5 handshake or the HTTP CONNECT
+	proxyConnDur time.Duration
+
 	// TLS handshake duration. For HTTP this will be 0
 	tlsDur time.Duration
 
@@ -914,6 +1179,20 @@ func (d *duration) getConnDur() time.Duration {
 	return d.connDur
 }
 
+func (d *duration) setProxyConnDur(t time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.proxyConnDur == 0 {
+		d.proxyConnDur = t
+	}
+}
+
+func (d *duration) getProxyConnDur() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.proxyConnDur
+}
+
 func (d *duration) setReqDur(t time.Duration) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -988,7 +1267,7 @@ func (d *duration) totalDuration() time.Duration {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	return d.dnsDur + d.connDur + d.tlsDur + d.reqDur + d.getServerProcessDur() + d.getResDur()
+	return d.dnsDur + d.connDur + d.proxyConnDur + d.tlsDur + d.reqDur + d.getServerProcessDur() + d.getResDur()
 }
 
 // normally channels are closed by sender, but in case of senders are not called, we close them here
@@ -0,0 +1,150 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// h3RoundTripper sends the requests over HTTP/3. The QUIC round tripper doesn't support httptrace,
+// so the trace hooks of the request are called here to report the durations like the HTTP/1.1 and HTTP/2 requests.
+// The QUIC handshake includes the TLS handshake, it is reported as the TLS duration.
+type h3RoundTripper struct {
+	rt *http3.RoundTripper
+
+	// open connections by address, used to know whether a request will dial a new connection
+	conns sync.Map
+}
+
+func newH3RoundTripper(tlsConfig *tls.Config, disableCompression bool) *h3RoundTripper {
+	t := &h3RoundTripper{}
+	t.rt = &http3.RoundTripper{
+		TLSClientConfig:    tlsConfig,
+		DisableCompression: disableCompression,
+		Dial:               t.dial,
+	}
+	return t
+}
+
+func (t *h3RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := httptrace.ContextClientTrace(req.Context())
+	if trace == nil {
+		return t.rt.RoundTrip(req)
+	}
+
+	// dial calls the connection hooks if a new connection is needed
+	if conn, ok := t.conns.Load(hostPort(req.URL.Host)); ok && conn.(quic.EarlyConnection).Context().Err() == nil {
+		gotConn(trace)
+	}
+
+	resp, err := t.rt.RoundTrip(req)
+	if err == nil && trace.GotFirstResponseByte != nil {
+		// the round tripper returns as soon as the response headers are received
+		trace.GotFirstResponseByte()
+	}
+	return resp, err
+}
+
+func (t *h3RoundTripper) dial(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (
+	quic.EarlyConnection, error) {
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace == nil {
+		trace = &httptrace.ClientTrace{}
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if trace.DNSDone != nil {
+		trace.DNSDone(httptrace.DNSDoneInfo{Addrs: ips, Err: err})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	conn, err := quic.DialAddrEarly(ctx, net.JoinHostPort(ips[0].IP.String(), port), tlsCfg, cfg)
+	if err == nil {
+		select {
+		case <-conn.HandshakeComplete():
+		case <-conn.Context().Done():
+			err = context.Cause(conn.Context())
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	if trace.TLSHandshakeDone != nil {
+		var state tls.ConnectionState
+		if err == nil {
+			state = conn.ConnectionState().TLS
+		}
+		trace.TLSHandshakeDone(state, err)
+	}
+	if err != nil {
+		if conn != nil {
+			conn.CloseWithError(0, "")
+		}
+		return nil, err
+	}
+
+	t.conns.Store(addr, conn)
+	gotConn(trace)
+	return conn, nil
+}
+
+// gotConn calls the hooks of a ready connection. Request write can not be observed separately in HTTP/3,
+// the request is assumed to be written when the connection is ready.
+func gotConn(trace *httptrace.ClientTrace) {
+	if trace.GotConn != nil {
+		trace.GotConn(httptrace.GotConnInfo{})
+	}
+	if trace.WroteRequest != nil {
+		trace.WroteRequest(httptrace.WroteRequestInfo{})
+	}
+}
+
+func (t *h3RoundTripper) CloseIdleConnections() {
+	t.rt.CloseIdleConnections()
+}
+
+// hostPort adds the default HTTPS port to the host if it doesn't have a port.
+func hostPort(host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, "443")
+}
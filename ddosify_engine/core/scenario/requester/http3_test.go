@@ -0,0 +1,103 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"go.ddosify.com/ddosify/core/types"
+)
+
+// startH3Server serves the protocol of the request on HTTP/3 with the certificate of httptest.
+func startH3Server(t *testing.T) string {
+	ts := httptest.NewUnstartedServer(nil)
+	ts.StartTLS()
+	ts.Close()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+
+	srv := &http3.Server{
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: ts.TLS.Certificates}),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(10 * time.Millisecond)
+			w.Write([]byte(r.Proto))
+		}),
+	}
+	go srv.Serve(conn)
+	t.Cleanup(func() {
+		srv.Close()
+		conn.Close()
+	})
+
+	return "https://" + conn.LocalAddr().String()
+}
+
+func TestSendH3(t *testing.T) {
+	t.Parallel()
+	target := startH3Server(t)
+
+	s := types.ScenarioStep{
+		ID:      1,
+		Method:  http.MethodGet,
+		URL:     target,
+		Timeout: types.DefaultTimeout,
+		Custom:  map[string]interface{}{"h3": true},
+	}
+
+	h := &HttpRequester{}
+	if err := h.Init(context.TODO(), s, nil, true, nil); err != nil {
+		t.Fatalf("init error: %v", err)
+	}
+	defer h.Done()
+
+	for i := 0; i < 2; i++ {
+		res := h.Send(nil, map[string]interface{}{})
+		if res.Err.Type != "" {
+			t.Fatalf("request %d errored: %v", i, res.Err)
+		}
+		if string(res.RespBody) != "HTTP/3.0" {
+			t.Errorf("request %d protocol expected HTTP/3.0, got %s", i, res.RespBody)
+		}
+
+		tlsDur := res.Custom["tlsDuration"].(time.Duration)
+		if i == 0 && tlsDur == 0 {
+			t.Errorf("handshake duration should be set on the first request")
+		}
+		if i == 1 && tlsDur != 0 {
+			t.Errorf("connection should be reused, found handshake duration %v", tlsDur)
+		}
+
+		serverProcessDur := res.Custom["serverProcessDuration"].(time.Duration)
+		if serverProcessDur < 10*time.Millisecond || serverProcessDur > res.Duration {
+			t.Errorf("request %d server process duration is not valid: %v, total %v", i, serverProcessDur, res.Duration)
+		}
+	}
+}
@@ -24,15 +24,22 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httptrace"
 	"net/url"
 	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"go.ddosify.com/ddosify/core/scenario/scripting/injection"
 	"go.ddosify.com/ddosify/core/types"
 	"golang.org/x/net/http2"
 )
@@ -392,6 +399,295 @@ func TestSendOnDebugModePopulatesDebugInfo(t *testing.T) {
 	t.Run("populate-debug-info", tf)
 }
 
+func TestSendKeepsResponseBodyWhenCaptureBodyIsSet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("response body"))
+	}))
+	defer server.Close()
+
+	for _, captureBody := range []bool{false, true} {
+		h := &HttpRequester{}
+		s := types.ScenarioStep{ID: 1, Method: http.MethodGet, URL: server.URL, CaptureBody: captureBody}
+		if err := h.Init(context.TODO(), s, nil, false, nil); err != nil {
+			t.Fatalf("TestSendKeepsResponseBodyWhenCaptureBodyIsSet init error: %v", err)
+		}
+		res := h.Send(http.DefaultClient, map[string]interface{}{})
+
+		expected := []byte(nil)
+		if captureBody {
+			expected = []byte("response body")
+		}
+		if !bytes.Equal(res.RespBody, expected) {
+			t.Errorf("CaptureBody %v, expected response body %q, Found: %q", captureBody, expected, res.RespBody)
+		}
+	}
+}
+
+func TestSendWithTracePropagatesTraceContext(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	h := &HttpRequester{}
+	s := types.ScenarioStep{ID: 1, Method: http.MethodGet, URL: server.URL}
+	if err := h.Init(context.TODO(), s, nil, false, nil); err != nil {
+		t.Fatalf("TestSendWithTracePropagatesTraceContext init error: %v", err)
+	}
+
+	tc := types.TraceContext{TraceID: [16]byte{0x4b, 0xf9, 0x2f, 0x35}, SpanID: [8]byte{0x00, 0xf0, 0x67, 0xaa}}
+	res := h.SendWithTrace(&http.Client{}, map[string]interface{}{}, tc)
+
+	expected := "00-4bf92f35000000000000000000000000-00f067aa00000000-01"
+	if traceparent != expected {
+		t.Errorf("Expected traceparent %s, Found: %s", expected, traceparent)
+	}
+	if res.Trace != tc {
+		t.Errorf("Expected trace context %v in the result, Found: %v", tc, res.Trace)
+	}
+
+	names := make([]string, 0, len(res.TraceEvents))
+	for i, e := range res.TraceEvents {
+		if i > 0 && e.Time.Before(res.TraceEvents[i-1].Time) {
+			t.Errorf("Trace events should be ordered by time: %v", res.TraceEvents)
+		}
+		names = append(names, e.Name)
+	}
+	for _, e := range []string{"connect_start", "connect_done", "got_conn", "wrote_request", "got_first_response_byte"} {
+		if !strings.Contains(strings.Join(names, ","), e) {
+			t.Errorf("Expected %s in the trace events, Found: %v", e, names)
+		}
+	}
+
+	traceparent = ""
+	res = h.Send(&http.Client{}, map[string]interface{}{})
+	if traceparent != "" || res.TraceEvents != nil || res.Trace.IsValid() {
+		t.Errorf("Untraced request should not carry the trace context: %s, %v", traceparent, res.TraceEvents)
+	}
+}
+
+func TestSendThroughAuthenticatedProxies(t *testing.T) {
+	target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	tests := []struct {
+		name  string
+		proxy *url.URL
+		err   bool
+	}{
+		{"HttpConnect", startConnectProxy(t, "user", "pass"), false},
+		{"HttpConnectInvalidCredentials", startConnectProxy(t, "user", "other"), true},
+		{"Socks5", startSocks5Proxy(t, "user", "pass"), false},
+		{"Socks5InvalidCredentials", startSocks5Proxy(t, "user", "other"), true},
+	}
+
+	for _, test := range tests {
+		tf := func(t *testing.T) {
+			test.proxy.User = url.UserPassword("user", "pass")
+			h := &HttpRequester{}
+			s := types.ScenarioStep{ID: 1, Method: http.MethodGet, URL: target.URL, Timeout: 5}
+			if err := h.Init(context.TODO(), s, test.proxy, false, nil); err != nil {
+				t.Fatalf("init error: %v", err)
+			}
+
+			res := h.Send(&http.Client{}, map[string]interface{}{})
+			if test.err {
+				if res.Err.Type != types.ErrorProxy || res.Err.Reason != types.ReasonProxyAuth {
+					t.Errorf("Expected proxy error, Found: %#v", res.Err)
+				}
+				return
+			}
+
+			if res.Err.Type != "" || res.StatusCode != http.StatusOK {
+				t.Fatalf("Expected success, Found: %d %#v", res.StatusCode, res.Err)
+			}
+			proxyConnDur, ok := res.Custom["proxyConnDuration"].(time.Duration)
+			if !ok || proxyConnDur <= 0 {
+				t.Errorf("Expected proxy connect duration, Found: %v", res.Custom["proxyConnDuration"])
+			}
+			if res.Duration < proxyConnDur+res.Custom["connDuration"].(time.Duration) {
+				t.Errorf("Proxy connect duration should be in the total duration")
+			}
+		}
+		t.Run(test.name, tf)
+	}
+}
+
+func TestProxyConnDurationIsNotReportedWithoutTunnel(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	// an HTTP target is requested from the HTTP proxy without CONNECT
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer proxy.Close()
+	proxyAddr, _ := url.Parse(proxy.URL)
+
+	h := &HttpRequester{}
+	s := types.ScenarioStep{ID: 1, Method: http.MethodGet, URL: target.URL, Timeout: 5}
+	if err := h.Init(context.TODO(), s, proxyAddr, false, nil); err != nil {
+		t.Fatalf("init error: %v", err)
+	}
+	res := h.Send(&http.Client{}, map[string]interface{}{})
+	if res.Err.Type != "" {
+		t.Fatalf("Expected success, Found: %#v", res.Err)
+	}
+	if _, ok := res.Custom["proxyConnDuration"]; ok {
+		t.Errorf("Proxy connect duration should not be reported without a tunnel")
+	}
+}
+
+func TestSendFromSourceIP(t *testing.T) {
+	remoteIPs := make(chan string, 2)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		remoteIPs <- host
+	}))
+	defer target.Close()
+
+	// all of 127.0.0.0/8 is routed to the loopback interface on linux
+	sourceIP := net.ParseIP("127.0.0.2")
+	h := &HttpRequester{}
+	s := types.ScenarioStep{ID: 1, Method: http.MethodGet, URL: target.URL, Timeout: 5, SourceIP: sourceIP}
+	if err := h.Init(context.TODO(), s, nil, false, nil); err != nil {
+		t.Fatalf("init error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		client *http.Client
+	}{
+		{"Ddosify mode", nil},
+		{"User mode", &http.Client{}},
+	}
+	for _, test := range tests {
+		res := h.Send(test.client, map[string]interface{}{})
+		if res.Err.Type != "" {
+			if runtime.GOOS != "linux" {
+				t.Skipf("binding to %s is not supported: %v", sourceIP, res.Err)
+			}
+			t.Fatalf("%s: Expected success, Found: %#v", test.name, res.Err)
+		}
+		if ip := <-remoteIPs; ip != sourceIP.String() {
+			t.Errorf("%s: Expected request from %s, Found: %s", test.name, sourceIP, ip)
+		}
+	}
+}
+
+// startConnectProxy starts an HTTP proxy that tunnels the CONNECT requests with the given basic credentials.
+func startConnectProxy(t *testing.T, username, password string) *url.URL {
+	t.Helper()
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != auth {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		pipe(conn, target)
+	}))
+	t.Cleanup(proxy.Close)
+	addr, _ := url.Parse(proxy.URL)
+	return addr
+}
+
+// startSocks5Proxy starts a SOCKS5 proxy that accepts the given username/password, RFC 1928 and RFC 1929.
+func startSocks5Proxy(t *testing.T, username, password string) *url.URL {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				buf := make([]byte, 256)
+				read := func(n int) []byte {
+					if _, err := io.ReadFull(conn, buf[:n]); err != nil {
+						return nil
+					}
+					return buf[:n]
+				}
+
+				// greeting, username/password method
+				if b := read(2); b == nil || b[0] != 5 || read(int(b[1])) == nil {
+					conn.Close()
+					return
+				}
+				_, _ = conn.Write([]byte{5, 2})
+
+				// username/password authentication
+				b := read(2)
+				if b == nil {
+					conn.Close()
+					return
+				}
+				user := string(read(int(b[1])))
+				pass := string(read(int(read(1)[0])))
+				if user != username || pass != password {
+					_, _ = conn.Write([]byte{1, 1})
+					conn.Close()
+					return
+				}
+				_, _ = conn.Write([]byte{1, 0})
+
+				// connect request
+				b = read(4)
+				if b == nil || b[1] != 1 {
+					conn.Close()
+					return
+				}
+				var host string
+				switch b[3] {
+				case 1:
+					host = net.IP(read(4)).String()
+				case 3:
+					host = string(read(int(read(1)[0])))
+				case 4:
+					host = net.IP(read(16)).String()
+				}
+				port := read(2)
+				target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port[0])<<8|int(port[1]))))
+				if err != nil {
+					_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					conn.Close()
+					return
+				}
+				_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				pipe(conn, target)
+			}()
+		}
+	}()
+
+	addr, _ := url.Parse("socks5://" + ln.Addr().String())
+	return addr
+}
+
+// pipe copies the data between the connections until one of them is closed.
+func pipe(c1, c2 net.Conn) {
+	go func() {
+		_, _ = io.Copy(c1, c2)
+		c1.Close()
+	}()
+	_, _ = io.Copy(c2, c1)
+	c2.Close()
+}
+
 func TestCaptureEnvShouldSetEmptyStringWhenReqFails(t *testing.T) {
 	ctx := context.TODO()
 	// Failed request
@@ -511,7 +807,7 @@ func TestTraceResDur_TypicalScenario(t *testing.T) {
 		resDurCh:   make(chan time.Duration, 1),
 		resStartCh: make(chan time.Time, 1),
 	}
-	trace := newTrace(d, nil, nil)
+	trace := newTrace(d, nil, nil, nil)
 
 	// below two is called by different goroutines
 	// typically wroteRequest is called before gotFirstResponseByte
@@ -542,7 +838,7 @@ func TestTraceResDur_UnusualScenario(t *testing.T) {
 		resDurCh:   make(chan time.Duration, 1),
 		resStartCh: make(chan time.Time, 1),
 	}
-	trace := newTrace(d, nil, nil)
+	trace := newTrace(d, nil, nil, nil)
 
 	// below two is called by different goroutines
 	// typically wroteRequest is called before gotFirstResponseByte
@@ -574,7 +870,7 @@ func TestTraceServerProcessDur(t *testing.T) {
 		resDurCh:   make(chan time.Duration, 1),
 		resStartCh: make(chan time.Time, 1),
 	}
-	trace := newTrace(d, nil, nil)
+	trace := newTrace(d, nil, nil, nil)
 
 	// below two is called by different goroutines
 	// typically wroteRequest is called before gotFirstResponseByte
@@ -601,7 +897,7 @@ func TestTraceServerProcessDur_2(t *testing.T) {
 		resDurCh:   make(chan time.Duration, 1),
 		resStartCh: make(chan time.Time, 1),
 	}
-	trace := newTrace(d, nil, nil)
+	trace := newTrace(d, nil, nil, nil)
 
 	// below two is called by different goroutines
 	// typically wroteRequest is called before gotFirstResponseByte
@@ -630,7 +926,7 @@ func TestTraceServerProcessDur_3(t *testing.T) {
 		resDurCh:   make(chan time.Duration, 1),
 		resStartCh: make(chan time.Time, 1),
 	}
-	trace := newTrace(d, nil, nil)
+	trace := newTrace(d, nil, nil, nil)
 
 	// below two is called by different goroutines
 	// typically wroteRequest is called before gotFirstResponseByte
@@ -664,7 +960,7 @@ func TestTraceServerProcessDur_ErrCase(t *testing.T) {
 		resDurCh:             make(chan time.Duration, 1),
 		resStartCh:           make(chan time.Time, 1),
 	}
-	trace := newTrace(d, nil, nil)
+	trace := newTrace(d, nil, nil, nil)
 
 	// below two is called by different goroutines
 	// typically wroteRequest is called before gotFirstResponseByte
@@ -734,3 +1030,59 @@ func TestResponseCookiesSentToAssertions(t *testing.T) {
 		t.Errorf("received expected %s, got %v", "Ronaldo", res.FailedAssertions[0].Received)
 	}
 }
+
+func TestGraphqlErrorsFailTheStep(t *testing.T) {
+	t.Parallel()
+	// Test server
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if id, ok := req.Variables["id"].(float64); ok && id == 10 {
+			w.Write([]byte(`{"data":{"user":{"name":"messi"}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":null,"errors":[{"message":"user not found"}]}`))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	gql := types.GraphqlConf{
+		Query:     "query User($id: Int!) { user(id: $id) { name } }",
+		Variables: map[string]interface{}{"id": "{{id}}"},
+	}
+	payload, _ := gql.Payload()
+	s := types.ScenarioStep{
+		ID:         1,
+		Method:     http.MethodPost,
+		URL:        server.URL,
+		Payload:    payload,
+		Graphql:    gql,
+		Assertions: []string{`equals(gql_errors, [])`, `equals(data.user.name, "messi")`},
+	}
+
+	ei := &injection.EnvironmentInjector{}
+	ei.Init()
+	h := &HttpRequester{}
+	h.Init(context.TODO(), s, nil, false, ei)
+
+	res := h.Send(http.DefaultClient, map[string]interface{}{"id": 10})
+	if res.Err.Type != "" || len(res.FailedAssertions) != 0 {
+		t.Errorf("expected no error, got %v %v", res.Err, res.FailedAssertions)
+	}
+
+	res = h.Send(http.DefaultClient, map[string]interface{}{"id": 11})
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("status code expected %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	if res.Err.Type != types.ErrorGraphql || res.Err.Reason != "user not found" {
+		t.Errorf("expected graphql error, got %v", res.Err)
+	}
+	if len(res.FailedAssertions) != 2 {
+		t.Errorf("expected 2 failed assertions, got %d", len(res.FailedAssertions))
+	}
+}
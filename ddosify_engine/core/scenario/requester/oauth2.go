@@ -0,0 +1,197 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+// OAuth2Tokens caches the access tokens of the oauth2 authentications of the steps. A token is fetched from the
+// token endpoint when it is first needed, and again when it is about to expire or is rejected by the target.
+// Tokens are keyed by the auth, so the steps with the same auth share their tokens.
+type OAuth2Tokens struct {
+	client *http.Client
+	mu     sync.Mutex
+	tokens map[oauth2TokenKey]*oauth2Token
+
+	now func() time.Time
+}
+
+type oauth2TokenKey struct {
+	auth types.Auth
+
+	// Client of the user engine modes in the user cache, nil in the global cache.
+	user *http.Client
+}
+
+type oauth2Token struct {
+	// Held while the token is fetched, so the concurrent iterations wait for a single token request.
+	mu          sync.Mutex
+	accessToken string
+	refreshAt   time.Time // zero if the token does not expire
+}
+
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func NewOAuth2Tokens() *OAuth2Tokens {
+	return &OAuth2Tokens{
+		// the requesters bound the token requests by the step timeout, this bounds the other callers
+		client: &http.Client{Timeout: types.DefaultTimeout * time.Second},
+		tokens: make(map[oauth2TokenKey]*oauth2Token),
+		now:    time.Now,
+	}
+}
+
+// Token returns the access token of the auth for the client, fetching it if it is not cached or needs to be refreshed.
+// fetchDur is the duration of the token request, zero if the cached token is used. The token request is canceled
+// with ctx, the other callers of the same token wait for it, so ctx should have a deadline.
+func (o *OAuth2Tokens) Token(ctx context.Context, auth types.Auth, client *http.Client) (
+	accessToken string, fetchDur time.Duration, err error) {
+	t := o.get(auth, client)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.accessToken != "" && (t.refreshAt.IsZero() || o.now().Before(t.refreshAt)) {
+		return t.accessToken, 0, nil
+	}
+
+	issuedAt := o.now()
+	start := time.Now()
+	res, err := o.fetch(ctx, auth)
+	fetchDur = time.Since(start)
+	if err != nil {
+		t.accessToken = ""
+		return "", fetchDur, err
+	}
+
+	t.accessToken = res.AccessToken
+	t.refreshAt = time.Time{}
+	if res.ExpiresIn > 0 {
+		lifetime := time.Duration(res.ExpiresIn) * time.Second
+		// short-lived tokens are refreshed in the middle of their lifetime
+		refreshBefore := time.Duration(auth.RefreshBefore) * time.Second
+		if refreshBefore > lifetime/2 {
+			refreshBefore = lifetime / 2
+		}
+		t.refreshAt = issuedAt.Add(lifetime - refreshBefore)
+	}
+	return t.accessToken, fetchDur, nil
+}
+
+// Invalidate drops the access token of the auth for the client if it is still cached, so the next Token call
+// fetches a new one.
+func (o *OAuth2Tokens) Invalidate(auth types.Auth, client *http.Client, accessToken string) {
+	t := o.get(auth, client)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.accessToken == accessToken {
+		t.accessToken = ""
+	}
+}
+
+func (o *OAuth2Tokens) get(auth types.Auth, client *http.Client) *oauth2Token {
+	key := oauth2TokenKey{auth: auth}
+	if auth.Cache == types.OAuth2CacheUser {
+		key.user = client
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	t, ok := o.tokens[key]
+	if !ok {
+		t = &oauth2Token{}
+		o.tokens[key] = t
+	}
+	return t
+}
+
+// fetch requests a token from the token endpoint of the auth. The client credentials are sent in the basic
+// authorization header, as required to be supported by the authorization servers in RFC 6749.
+func (o *OAuth2Tokens) fetch(ctx context.Context, auth types.Auth) (*oauth2TokenResponse, error) {
+	grant := auth.Grant
+	if grant == "" {
+		grant = types.OAuth2GrantClientCredentials
+	}
+	form := url.Values{"grant_type": {grant}}
+	if auth.Scope != "" {
+		form.Set("scope", auth.Scope)
+	}
+	if grant == types.OAuth2GrantPassword {
+		form.Set("username", auth.Username)
+		form.Set("password", auth.Password)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if auth.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+	}
+
+	httpRes, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+	body, err := io.ReadAll(httpRes.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &oauth2TokenResponse{}
+	jsonErr := json.Unmarshal(body, res)
+	if httpRes.StatusCode < 200 || httpRes.StatusCode > 299 {
+		if res.Error != "" {
+			return nil, fmt.Errorf("token endpoint responded %d %s %s", httpRes.StatusCode, res.Error,
+				res.ErrorDescription)
+		}
+		return nil, fmt.Errorf("token endpoint responded %d", httpRes.StatusCode)
+	}
+	if jsonErr != nil {
+		return nil, fmt.Errorf("token response is not valid: %v", jsonErr)
+	}
+	if res.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+	if res.TokenType != "" && !strings.EqualFold(res.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported token_type: %s", res.TokenType)
+	}
+	return res, nil
+}
@@ -0,0 +1,271 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+// startTokenServer starts a token endpoint that issues the tokens "token-1", "token-2" ... with the given expires_in.
+func startTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int64) {
+	t.Helper()
+	var issued int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if r.Method != http.MethodPost || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "invalid_client", "error_description": "bad credentials"}`)
+			return
+		}
+		r.ParseForm()
+		if r.Form.Get("grant_type") == types.OAuth2GrantPassword && r.Form.Get("password") != "123" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_grant"}`)
+			return
+		}
+		n := atomic.AddInt64(&issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": %d}`, n, expiresIn)
+	}))
+	t.Cleanup(server.Close)
+	return server, &issued
+}
+
+func TestOAuth2TokensRefresh(t *testing.T) {
+	server, issued := startTokenServer(t, 60)
+	now := time.Now()
+	tokens := NewOAuth2Tokens()
+	tokens.now = func() time.Time { return now }
+	auth := types.Auth{Type: types.AuthOAuth2, TokenURL: server.URL, ClientID: "client", ClientSecret: "secret",
+		RefreshBefore: 10}
+
+	tests := []struct {
+		name     string
+		advance  time.Duration
+		expected string
+		fetched  bool
+	}{
+		{"First request", 0, "token-1", true},
+		{"Cached", 45 * time.Second, "token-1", false},
+		{"Refreshed before expiry", 5 * time.Second, "token-2", true},
+		{"Cached after refresh", time.Second, "token-2", false},
+	}
+	for _, test := range tests {
+		now = now.Add(test.advance)
+		token, fetchDur, err := tokens.Token(context.TODO(), auth, nil)
+		if err != nil {
+			t.Fatalf("%s: errored: %v", test.name, err)
+		}
+		if token != test.expected {
+			t.Errorf("%s: Expected: %s, Found: %s", test.name, test.expected, token)
+		}
+		if test.fetched != (fetchDur > 0) {
+			t.Errorf("%s: Expected fetched: %v, Found fetch duration: %v", test.name, test.fetched, fetchDur)
+		}
+	}
+	if *issued != 2 {
+		t.Errorf("Expected 2 token requests, Found: %d", *issued)
+	}
+}
+
+func TestOAuth2TokensShortLived(t *testing.T) {
+	server, _ := startTokenServer(t, 20)
+	now := time.Now()
+	tokens := NewOAuth2Tokens()
+	tokens.now = func() time.Time { return now }
+	auth := types.Auth{Type: types.AuthOAuth2, TokenURL: server.URL, ClientID: "client", ClientSecret: "secret",
+		RefreshBefore: 30}
+
+	first, _, _ := tokens.Token(context.TODO(), auth, nil)
+	now = now.Add(9 * time.Second)
+	if token, _, _ := tokens.Token(context.TODO(), auth, nil); token != first {
+		t.Errorf("Token should be cached for half of its lifetime, Found: %s", token)
+	}
+	now = now.Add(time.Second)
+	if token, _, _ := tokens.Token(context.TODO(), auth, nil); token == first {
+		t.Errorf("Token should be refreshed in the middle of its lifetime")
+	}
+}
+
+func TestOAuth2TokensCache(t *testing.T) {
+	server, _ := startTokenServer(t, 3600)
+	user1, user2 := &http.Client{}, &http.Client{}
+
+	tests := []struct {
+		name  string
+		cache string
+		same  bool
+	}{
+		{"Global cache", types.OAuth2CacheGlobal, true},
+		{"User cache", types.OAuth2CacheUser, false},
+	}
+	for _, test := range tests {
+		tokens := NewOAuth2Tokens()
+		auth := types.Auth{Type: types.AuthOAuth2, TokenURL: server.URL, ClientID: "client", ClientSecret: "secret",
+			Grant: types.OAuth2GrantPassword, Username: "test", Password: "123", Cache: test.cache}
+
+		token1, _, err1 := tokens.Token(context.TODO(), auth, user1)
+		token2, _, err2 := tokens.Token(context.TODO(), auth, user2)
+		again, _, _ := tokens.Token(context.TODO(), auth, user1)
+		if err1 != nil || err2 != nil {
+			t.Fatalf("%s: errored: %v %v", test.name, err1, err2)
+		}
+		if (token1 == token2) != test.same {
+			t.Errorf("%s: Expected same tokens for the users: %v, Found: %s %s", test.name, test.same, token1, token2)
+		}
+		if again != token1 {
+			t.Errorf("%s: Token of the user should be cached, Expected: %s, Found: %s", test.name, token1, again)
+		}
+	}
+}
+
+func TestOAuth2TokensInvalidate(t *testing.T) {
+	server, _ := startTokenServer(t, 3600)
+	tokens := NewOAuth2Tokens()
+	auth := types.Auth{Type: types.AuthOAuth2, TokenURL: server.URL, ClientID: "client", ClientSecret: "secret"}
+
+	first, _, _ := tokens.Token(context.TODO(), auth, nil)
+	tokens.Invalidate(auth, nil, "stale-token")
+	if token, _, _ := tokens.Token(context.TODO(), auth, nil); token != first {
+		t.Errorf("Token should not be dropped by an invalidation of another token, Found: %s", token)
+	}
+	tokens.Invalidate(auth, nil, first)
+	if token, _, _ := tokens.Token(context.TODO(), auth, nil); token == first {
+		t.Errorf("Invalidated token should be fetched again")
+	}
+}
+
+func TestOAuth2TokensFetchError(t *testing.T) {
+	server, _ := startTokenServer(t, 3600)
+	tests := []struct {
+		name     string
+		auth     types.Auth
+		expected string
+	}{
+		{"Invalid client", types.Auth{ClientID: "client", ClientSecret: "wrong"}, "401 invalid_client bad credentials"},
+		{"Invalid grant", types.Auth{ClientID: "client", ClientSecret: "secret", Grant: types.OAuth2GrantPassword,
+			Username: "test", Password: "wrong"}, "400 invalid_grant"},
+	}
+	for _, test := range tests {
+		test.auth.Type = types.AuthOAuth2
+		test.auth.TokenURL = server.URL
+		_, _, err := NewOAuth2Tokens().Token(context.TODO(), test.auth, nil)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: Expected error containing %q, Found: %v", test.name, test.expected, err)
+		}
+	}
+}
+
+func TestSendWithOAuth2(t *testing.T) {
+	server, issued := startTokenServer(t, 3600)
+	var revoked int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer token-") || (atomic.LoadInt32(&revoked) == 1 && auth == "Bearer token-1") {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer target.Close()
+
+	h := &HttpRequester{}
+	s := types.ScenarioStep{ID: 1, Method: http.MethodGet, URL: target.URL, Timeout: 5,
+		Auth: types.Auth{Type: types.AuthOAuth2, TokenURL: server.URL, ClientID: "client", ClientSecret: "secret",
+			Username: "not-sent", Password: "not-sent"}}
+	if err := h.Init(context.TODO(), s, nil, false, nil); err != nil {
+		t.Fatalf("init error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		revoke     bool
+		statusCode int
+		fetched    bool
+	}{
+		{"Token fetched", false, http.StatusOK, true},
+		{"Token cached", false, http.StatusOK, false},
+		{"Token revoked", true, http.StatusUnauthorized, false},
+		{"Token fetched after revoke", false, http.StatusOK, true},
+	}
+	for _, test := range tests {
+		if test.revoke {
+			atomic.StoreInt32(&revoked, 1)
+		}
+		res := h.Send(nil, map[string]interface{}{})
+		if res.Err.Type != "" || res.StatusCode != test.statusCode {
+			t.Fatalf("%s: Expected status %d, Found: %d %#v", test.name, test.statusCode, res.StatusCode, res.Err)
+		}
+		if _, ok := res.Custom["tokenFetchDuration"]; ok != test.fetched {
+			t.Errorf("%s: Expected token fetch duration reported: %v, Found: %v", test.name, test.fetched, ok)
+		}
+	}
+	if *issued != 2 {
+		t.Errorf("Expected 2 token requests, Found: %d", *issued)
+	}
+}
+
+func TestSendWithOAuth2FetchError(t *testing.T) {
+	server, _ := startTokenServer(t, 3600)
+	h := &HttpRequester{}
+	s := types.ScenarioStep{ID: 1, Method: http.MethodGet, URL: "http://127.0.0.1:1", Timeout: 5,
+		Auth: types.Auth{Type: types.AuthOAuth2, TokenURL: server.URL, ClientID: "client", ClientSecret: "wrong"}}
+	if err := h.Init(context.TODO(), s, nil, false, nil); err != nil {
+		t.Fatalf("init error: %v", err)
+	}
+
+	res := h.Send(nil, map[string]interface{}{})
+	if res.Err.Type != types.ErrorAuth {
+		t.Errorf("Expected: %s, Found: %#v", types.ErrorAuth, res.Err)
+	}
+}
+
+func TestSendWithOAuth2TokenTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	h := &HttpRequester{}
+	s := types.ScenarioStep{ID: 1, Method: http.MethodGet, URL: "http://127.0.0.1:1", Timeout: 1,
+		Auth: types.Auth{Type: types.AuthOAuth2, TokenURL: server.URL, ClientID: "client", ClientSecret: "secret"}}
+	if err := h.Init(context.TODO(), s, nil, false, nil); err != nil {
+		t.Fatalf("init error: %v", err)
+	}
+
+	start := time.Now()
+	res := h.Send(nil, map[string]interface{}{})
+	if res.Err.Type != types.ErrorAuth {
+		t.Errorf("Expected: %s, Found: %#v", types.ErrorAuth, res.Err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Token request should time out with the step timeout, took %v", elapsed)
+	}
+}
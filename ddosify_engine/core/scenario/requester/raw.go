@@ -0,0 +1,338 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.ddosify.com/ddosify/core/scenario/scripting/assertion/evaluator"
+	"go.ddosify.com/ddosify/core/scenario/scripting/injection"
+	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/types/regex"
+)
+
+// udpMaxDatagramSize is the size of the read buffer of the UDP steps.
+const udpMaxDatagramSize = 65535
+
+type RawRequester struct {
+	ctx        context.Context
+	packet     types.ScenarioStep
+	network    string
+	address    string
+	delimiter  []byte
+	dialer     *net.Dialer
+	ei         *injection.EnvironmentInjector
+	debug      bool
+	dynamicRgx *regexp.Regexp
+	envRgx     *regexp.Regexp
+}
+
+// Init resolves the network and address of the step. Each Send opens its own socket. Proxies are not supported.
+func (r *RawRequester) Init(ctx context.Context, s types.ScenarioStep, proxyAddr *url.URL, debug bool,
+	ei *injection.EnvironmentInjector) (err error) {
+	r.ctx = ctx
+	r.packet = s
+	r.ei = ei
+	r.debug = debug
+	r.dynamicRgx = regexp.MustCompile(regex.DynamicVariableRegex)
+	r.envRgx = regexp.MustCompile(regex.EnvironmentVariableRegex)
+
+	r.network = strings.ToLower(s.Protocol())
+	r.address = s.URL[strings.Index(s.URL, "://")+3:]
+	r.dialer = &net.Dialer{Timeout: time.Duration(s.Timeout) * time.Second}
+
+	if s.Raw.Delimiter != "" {
+		if r.delimiter, err = r.decode(s.Raw.Delimiter); err != nil {
+			return fmt.Errorf("delimiter is not valid: %v", err)
+		}
+	}
+
+	if r.dynamicRgx.MatchString(s.Payload) {
+		_, err = r.ei.InjectDynamic(s.Payload)
+	}
+	return
+}
+
+func (r *RawRequester) Send(envs map[string]interface{}) (res *types.ScenarioStepResult) {
+	var requestErr types.RequestError
+	var reqStartTime = time.Now()
+	var respBody []byte
+	var extractedVars = make(map[string]interface{})
+	var failedCaptures = make(map[string]string, 0)
+	var failedAssertions = make([]types.FailedAssertion, 0)
+
+	var usableVars = make(map[string]interface{}, len(envs))
+	for k, v := range envs {
+		usableVars[k] = v
+	}
+
+	res = &types.ScenarioStepResult{
+		StepID:      r.packet.ID,
+		StepName:    r.packet.Name,
+		RequestID:   uuid.New(),
+		RequestTime: reqStartTime,
+		Method:      r.packet.Protocol(),
+		UsableEnvs:  usableVars,
+	}
+
+	address, payload, err := r.prepareReq(usableVars)
+	if err != nil { // could not prepare req
+		res.Err = types.RequestError{
+			Type:   types.ErrorInvalidRequest,
+			Reason: fmt.Sprintf("Could not prepare req, %s", err.Error()),
+		}
+		return res
+	}
+	res.Url = fmt.Sprintf("%s://%s", r.network, address)
+	res.ReqBody = payload
+
+	// Action
+	var connDur, writeDur, readDur time.Duration
+	conn, err := r.dialer.DialContext(r.ctx, r.network, address)
+	connDur = time.Since(reqStartTime)
+	if err != nil {
+		requestErr = r.fetchErrType(err, true)
+	} else {
+		// unblock the read on test stop
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-r.ctx.Done():
+				conn.Close()
+			case <-done:
+			}
+		}()
+
+		if r.packet.Timeout > 0 {
+			conn.SetDeadline(reqStartTime.Add(time.Duration(r.packet.Timeout) * time.Second))
+		}
+
+		writeStart := time.Now()
+		if _, err = conn.Write(payload); err == nil {
+			writeDur = time.Since(writeStart)
+
+			readStart := time.Now()
+			respBody, err = r.read(conn)
+			readDur = time.Since(readStart)
+		}
+		if err != nil {
+			requestErr = r.fetchErrType(err, false)
+		}
+
+		close(done)
+		conn.Close()
+	}
+	duration := time.Since(reqStartTime)
+
+	if requestErr.Type != "" {
+		failedCaptures = captureEnvironmentVariables(r.packet.EnvsToCapture, nil, nil, nil, extractedVars)
+	} else {
+		if r.packet.Raw.Format == types.RawFormatHex {
+			respBody = []byte(hex.EncodeToString(respBody))
+		}
+
+		// capture
+		if len(r.packet.EnvsToCapture) > 0 {
+			failedCaptures = captureEnvironmentVariables(r.packet.EnvsToCapture, http.Header{}, respBody, nil, extractedVars)
+		}
+
+		// assert
+		if len(r.packet.Assertions) > 0 {
+			_, failedAssertions = applyAssertions(r.packet.Assertions, &evaluator.AssertEnv{
+				ResponseSize: int64(len(respBody)),
+				ResponseTime: duration.Milliseconds(), // in ms
+				Body:         string(respBody),
+				Headers:      http.Header{},
+				Variables:    concatEnvs(envs, extractedVars),
+			})
+		}
+	}
+
+	res.Duration = duration
+	res.ContentLength = int64(len(respBody))
+	res.Err = requestErr
+	res.RespBody = respBody
+	res.Custom = map[string]interface{}{
+		"connDuration": connDur,
+		"reqDuration":  writeDur,
+		"resDuration":  readDur,
+	}
+	res.ExtractedEnvs = extractedVars
+	res.FailedCaptures = failedCaptures
+	res.FailedAssertions = failedAssertions
+	return res
+}
+
+// prepareReq injects the envs to the address and payload, and decodes the payload if its format is hex.
+func (r *RawRequester) prepareReq(envs map[string]interface{}) (string, []byte, error) {
+	address, err := r.inject(r.address, envs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	payload, err := r.inject(r.packet.Payload, envs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	p, err := r.decode(payload)
+	if err != nil {
+		return "", nil, err
+	}
+	return address, p, nil
+}
+
+func (r *RawRequester) decode(text string) ([]byte, error) {
+	if r.packet.Raw.Format == types.RawFormatHex {
+		return hex.DecodeString(strings.Join(strings.Fields(text), ""))
+	}
+	return []byte(text), nil
+}
+
+func (r *RawRequester) inject(text string, envs map[string]interface{}) (string, error) {
+	var err error
+	if r.dynamicRgx.MatchString(text) {
+		if text, err = r.ei.InjectDynamic(text); err != nil {
+			return "", err
+		}
+	}
+	if r.envRgx.MatchString(text) {
+		return r.ei.InjectEnv(text, envs)
+	}
+	return text, nil
+}
+
+// read reads the response by the read options of the step. A UDP read returns a single datagram.
+func (r *RawRequester) read(conn net.Conn) ([]byte, error) {
+	switch {
+	case len(r.delimiter) > 0:
+		return r.readUntil(conn)
+	case r.packet.Raw.ReadBytes > 0:
+		return r.readN(conn, r.packet.Raw.ReadBytes)
+	case r.packet.Raw.ReadTimeout > 0:
+		return r.readFor(conn, time.Duration(r.packet.Raw.ReadTimeout)*time.Second)
+	}
+	return []byte{}, nil
+}
+
+// readFor reads until the given duration passes, it is not an error to receive nothing.
+func (r *RawRequester) readFor(conn net.Conn, d time.Duration) ([]byte, error) {
+	conn.SetReadDeadline(time.Now().Add(d))
+
+	var b []byte
+	var err error
+	if r.network == "udp" {
+		buf := make([]byte, udpMaxDatagramSize)
+		var n int
+		n, err = conn.Read(buf)
+		b = buf[:n]
+	} else {
+		b, err = io.ReadAll(conn)
+	}
+
+	if isTimeout(err) {
+		err = nil
+	}
+	return b, err
+}
+
+// readUntil reads until the delimiter is received. The delimiter is included in the returned bytes.
+func (r *RawRequester) readUntil(conn net.Conn) ([]byte, error) {
+	var received []byte
+	buf := make([]byte, r.bufferSize())
+	for {
+		n, err := conn.Read(buf)
+		received = append(received, buf[:n]...)
+		if i := bytes.Index(received, r.delimiter); i >= 0 {
+			return received[:i+len(r.delimiter)], nil
+		}
+		if err != nil {
+			return received, err
+		}
+	}
+}
+
+// readN reads until n bytes are received.
+func (r *RawRequester) readN(conn net.Conn, n int) ([]byte, error) {
+	if r.network == "udp" {
+		buf := make([]byte, udpMaxDatagramSize)
+		received := make([]byte, 0, n)
+		for len(received) < n {
+			m, err := conn.Read(buf)
+			received = append(received, buf[:m]...)
+			if err != nil {
+				return received, err
+			}
+		}
+		return received[:n], nil
+	}
+
+	received := make([]byte, n)
+	m, err := io.ReadFull(conn, received)
+	return received[:m], err
+}
+
+func (r *RawRequester) bufferSize() int {
+	if r.network == "udp" {
+		return udpMaxDatagramSize
+	}
+	return 4096
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (r *RawRequester) fetchErrType(err error, dial bool) types.RequestError {
+	switch {
+	case r.ctx.Err() != nil:
+		return types.RequestError{Type: types.ErrorIntented, Reason: types.ReasonCtxCanceled}
+	case isTimeout(err) && dial:
+		return types.RequestError{Type: types.ErrorConn, Reason: types.ReasonConnTimeout}
+	case isTimeout(err):
+		return types.RequestError{Type: types.ErrorConn, Reason: types.ReasonReadTimeout}
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return types.RequestError{Type: types.ErrorConn, Reason: "connection closed before the response is read"}
+	case strings.Contains(err.Error(), "connection refused"):
+		return types.RequestError{Type: types.ErrorConn, Reason: types.ReasonConnRefused}
+	}
+	return types.RequestError{Type: types.ErrorConn, Reason: err.Error()}
+}
+
+func (r *RawRequester) Done() {
+}
+
+func (r *RawRequester) Type() string {
+	return "RAW"
+}
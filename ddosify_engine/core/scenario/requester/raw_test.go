@@ -0,0 +1,214 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+// startTcpServer serves a line protocol that replies each line with "+OK <line>\r\n".
+func startTcpServer(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				s := bufio.NewScanner(conn)
+				for s.Scan() {
+					conn.Write([]byte("+OK " + strings.TrimSpace(s.Text()) + "\r\n"))
+				}
+			}()
+		}
+	}()
+	return "tcp://" + lis.Addr().String()
+}
+
+// startUdpServer echoes each datagram back with its bytes reversed.
+func startUdpServer(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			out := make([]byte, n)
+			for i := 0; i < n; i++ {
+				out[i] = buf[n-1-i]
+			}
+			conn.WriteTo(out, addr)
+		}
+	}()
+	return "udp://" + conn.LocalAddr().String()
+}
+
+func TestRawRequester(t *testing.T) {
+	t.Parallel()
+	tcpTarget := startTcpServer(t)
+	udpTarget := startUdpServer(t)
+
+	tests := []struct {
+		name    string
+		url     string
+		payload string
+		conf    types.RawConf
+		body    string
+		errType string
+	}{
+		{
+			name:    "TcpDelimiter",
+			url:     tcpTarget,
+			payload: "PING {{name}}\r\n",
+			conf:    types.RawConf{Delimiter: "\r\n"},
+			body:    "+OK PING ddosify\r\n",
+		},
+		{
+			name:    "TcpReadBytes",
+			url:     tcpTarget,
+			payload: "PING\r\n",
+			conf:    types.RawConf{ReadBytes: 3},
+			body:    "+OK",
+		},
+		{
+			name:    "TcpHex",
+			url:     tcpTarget,
+			payload: "50 49 4e 47 0d 0a",
+			conf:    types.RawConf{Format: types.RawFormatHex, Delimiter: "0d0a"},
+			body:    "2b4f4b2050494e470d0a",
+		},
+		{
+			name:    "TcpReadTimeout",
+			url:     tcpTarget,
+			payload: "A\r\nB\r\n",
+			conf:    types.RawConf{ReadTimeout: 1},
+			body:    "+OK A\r\n+OK B\r\n",
+		},
+		{
+			name:    "TcpNoRead",
+			url:     tcpTarget,
+			payload: "PING\r\n",
+			body:    "",
+		},
+		{
+			name:    "TcpDelimiterNotReceived",
+			url:     tcpTarget,
+			payload: "PING\r\n",
+			conf:    types.RawConf{Delimiter: "END"},
+			errType: types.ErrorConn,
+		},
+		{
+			name:    "UdpHex",
+			url:     udpTarget,
+			payload: "{{id}}0100",
+			conf:    types.RawConf{Format: types.RawFormatHex, ReadTimeout: 1},
+			body:    "00012a1b",
+		},
+		{
+			name:    "UdpReadBytes",
+			url:     udpTarget,
+			payload: "abc{{name}}",
+			conf:    types.RawConf{ReadBytes: 4},
+			body:    "yfis",
+		},
+		{
+			name:    "InvalidHex",
+			url:     udpTarget,
+			payload: "xyz",
+			conf:    types.RawConf{Format: types.RawFormatHex},
+			errType: types.ErrorInvalidRequest,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		tf := func(t *testing.T) {
+			t.Parallel()
+			s := types.ScenarioStep{
+				ID:         1,
+				URL:        test.url,
+				Payload:    test.payload,
+				Timeout:    2,
+				Raw:        test.conf,
+				Assertions: []string{fmt.Sprintf("equals(response_size, %d)", len(test.body))},
+			}
+
+			r := initRequester(t, &RawRequester{}, s)
+			res := r.Send(map[string]interface{}{"name": "ddosify", "id": "1b2a"})
+
+			if res.Err.Type != test.errType {
+				t.Fatalf("Expected error type: %q, Found: %q %s", test.errType, res.Err.Type, res.Err.Reason)
+			}
+			if test.errType != "" {
+				return
+			}
+			if string(res.RespBody) != test.body {
+				t.Errorf("Expected body: %q, Found: %q", test.body, res.RespBody)
+			}
+			if len(res.FailedAssertions) != 0 {
+				t.Errorf("Expected no failed assertions, Found: %v", res.FailedAssertions)
+			}
+			for _, k := range []string{"connDuration", "reqDuration", "resDuration"} {
+				if _, ok := res.Custom[k]; !ok {
+					t.Errorf("Expected %s to be set", k)
+				}
+			}
+		}
+		t.Run(test.name, tf)
+	}
+}
+
+func TestRawRequesterConnRefused(t *testing.T) {
+	t.Parallel()
+	s := types.ScenarioStep{
+		ID:      1,
+		URL:     "tcp://127.0.0.1:1",
+		Payload: "PING",
+		Timeout: types.DefaultTimeout,
+	}
+
+	r := initRequester(t, &RawRequester{}, s)
+	res := r.Send(map[string]interface{}{})
+
+	if res.Err.Type != types.ErrorConn || res.Err.Reason != types.ReasonConnRefused {
+		t.Errorf("Expected connection refused error, Found: %v", res.Err)
+	}
+}
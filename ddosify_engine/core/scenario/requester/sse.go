@@ -0,0 +1,161 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+// sseEvent is an event of a text/event-stream response.
+type sseEvent struct {
+	Type string
+	Data string
+	ID   string
+
+	receivedAt time.Time
+}
+
+// sseStream is the result of reading a text/event-stream response.
+type sseStream struct {
+	events []sseEvent
+	raw    []byte
+	start  time.Time // time of the response headers
+	end    time.Time
+}
+
+// readSseStream reads the events from the body until the limits of the conf are reached or the stream is closed.
+// The body is closed if the duration limit is reached, it is not an error.
+func readSseStream(body io.ReadCloser, conf types.SseConf) (*sseStream, error) {
+	s := &sseStream{start: time.Now()}
+
+	var durationReached int32
+	if conf.Duration > 0 {
+		t := time.AfterFunc(time.Duration(conf.Duration)*time.Second, func() {
+			atomic.StoreInt32(&durationReached, 1)
+			body.Close()
+		})
+		defer t.Stop()
+	}
+
+	var raw bytes.Buffer
+	var ev sseEvent
+	var data []string
+	scanner := bufio.NewScanner(io.TeeReader(body, &raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" { // dispatch
+			if len(data) > 0 {
+				ev.Data = strings.Join(data, "\n")
+				if ev.Type == "" {
+					ev.Type = "message"
+				}
+				ev.receivedAt = time.Now()
+				s.events = append(s.events, ev)
+				if conf.EventCount > 0 && len(s.events) >= conf.EventCount {
+					break
+				}
+			}
+			ev = sseEvent{ID: ev.ID}
+			data = nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") { // comment
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.Type = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			ev.ID = value
+		}
+	}
+	s.end = time.Now()
+	s.raw = raw.Bytes()
+
+	if err := scanner.Err(); err != nil && atomic.LoadInt32(&durationReached) == 0 {
+		return s, err
+	}
+	return s, nil
+}
+
+// lastEvent returns the data of the last event of the given type, or of any type if it is empty.
+func (s *sseStream) lastEvent(eventType string) []byte {
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if eventType == "" || s.events[i].Type == eventType {
+			return []byte(s.events[i].Data)
+		}
+	}
+	return []byte{}
+}
+
+// metrics returns the event metrics of the stream to be reported. Durations are relative to the request start.
+func (s *sseStream) metrics(reqStart time.Time) map[string]interface{} {
+	m := map[string]interface{}{
+		"eventCount": int64(len(s.events)),
+	}
+	if len(s.events) == 0 {
+		return m
+	}
+
+	m["firstEventDuration"] = s.events[0].receivedAt.Sub(reqStart)
+	if streamDur := s.end.Sub(s.start); streamDur > 0 {
+		m["eventsPerSecond"] = float64(len(s.events)) / streamDur.Seconds()
+	}
+	if len(s.events) > 1 {
+		gaps := make([]time.Duration, 0, len(s.events)-1)
+		for i := 1; i < len(s.events); i++ {
+			gaps = append(gaps, s.events[i].receivedAt.Sub(s.events[i-1].receivedAt))
+		}
+		m["eventGapDuration"] = averageDuration(gaps)
+	}
+	return m
+}
+
+// captureFromEvents captures the body envs from the data of the events, and the others from the response.
+func captureFromEvents(envsToCapture []types.EnvCaptureConf, header http.Header, s *sseStream,
+	cookies map[string]*http.Cookie, extractedVars map[string]interface{}) map[string]string {
+	failedCaptures := make(map[string]string, 0)
+	for _, ce := range envsToCapture {
+		body := s.raw
+		if ce.From == types.Body {
+			body = s.lastEvent(ce.Event)
+		}
+		for name, reason := range captureEnvironmentVariables([]types.EnvCaptureConf{ce}, header, body, cookies,
+			extractedVars) {
+			failedCaptures[name] = reason
+		}
+	}
+	return failedCaptures
+}
@@ -0,0 +1,151 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+// sseHandler streams a token event every 50ms and a done event at the end, until the client goes away.
+func sseHandler(tokens int, keepOpen bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		fmt.Fprint(w, ": stream started\n\n")
+		flusher.Flush()
+		for i := 0; i < tokens; i++ {
+			fmt.Fprintf(w, "event: token\nid: %d\ndata: {\"text\": \"t%d\"}\n\n", i, i)
+			flusher.Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+		fmt.Fprint(w, "event: done\ndata: {\"usage\": 42}\n\n")
+		flusher.Flush()
+		if keepOpen {
+			<-r.Context().Done()
+		}
+	}
+}
+
+func TestSseEventCount(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(sseHandler(10, true))
+	defer server.Close()
+
+	textPath := "text"
+	h := initRequester(t, &HttpRequester{}, types.ScenarioStep{
+		ID:      1,
+		Method:  http.MethodGet,
+		URL:     server.URL,
+		Timeout: 5,
+		Sse:     types.SseConf{EventCount: 3},
+		EnvsToCapture: []types.EnvCaptureConf{
+			{Name: "TOKEN", From: types.Body, Event: "token", JsonPath: &textPath},
+		},
+		Assertions: []string{`equals(status_code, 200)`},
+	})
+
+	res := h.Send(http.DefaultClient, map[string]interface{}{})
+	if res.Err.Type != "" || len(res.FailedAssertions) != 0 || len(res.FailedCaptures) != 0 {
+		t.Fatalf("expected no error, got %v %v %v", res.Err, res.FailedAssertions, res.FailedCaptures)
+	}
+	if res.Custom["eventCount"] != int64(3) {
+		t.Errorf("eventCount expected 3, got %v", res.Custom["eventCount"])
+	}
+	if res.ExtractedEnvs["TOKEN"] != "t2" {
+		t.Errorf("TOKEN expected t2, got %v", res.ExtractedEnvs["TOKEN"])
+	}
+	for _, k := range []string{"firstEventDuration", "eventGapDuration", "eventsPerSecond"} {
+		if _, ok := res.Custom[k]; !ok {
+			t.Errorf("%s should be reported", k)
+		}
+	}
+	if gap := res.Custom["eventGapDuration"].(time.Duration); gap < 30*time.Millisecond {
+		t.Errorf("eventGapDuration expected around 50ms, got %v", gap)
+	}
+}
+
+func TestSseDuration(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(sseHandler(2, true))
+	defer server.Close()
+
+	usagePath := "usage"
+	h := initRequester(t, &HttpRequester{}, types.ScenarioStep{
+		ID:      1,
+		Method:  http.MethodGet,
+		URL:     server.URL,
+		Timeout: 5,
+		Sse:     types.SseConf{Duration: 1},
+		EnvsToCapture: []types.EnvCaptureConf{
+			{Name: "USAGE", From: types.Body, Event: "done", JsonPath: &usagePath},
+		},
+	})
+
+	start := time.Now()
+	res := h.Send(http.DefaultClient, map[string]interface{}{})
+	if res.Err.Type != "" {
+		t.Fatalf("expected no error, got %v", res.Err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 3*time.Second {
+		t.Errorf("stream should be read for 1s, took %v", elapsed)
+	}
+	if res.Custom["eventCount"] != int64(3) {
+		t.Errorf("eventCount expected 3, got %v", res.Custom["eventCount"])
+	}
+	if fmt.Sprint(res.ExtractedEnvs["USAGE"]) != "42" {
+		t.Errorf("USAGE expected 42, got %v", res.ExtractedEnvs["USAGE"])
+	}
+}
+
+func TestSseStreamClosedByServer(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(sseHandler(2, false))
+	defer server.Close()
+
+	usagePath := "usage"
+	h := initRequester(t, &HttpRequester{}, types.ScenarioStep{
+		ID:      1,
+		Method:  http.MethodGet,
+		URL:     server.URL,
+		Timeout: 5,
+		Sse:     types.SseConf{EventCount: 10},
+		EnvsToCapture: []types.EnvCaptureConf{
+			{Name: "LAST", From: types.Body, JsonPath: &usagePath},
+		},
+	})
+
+	res := h.Send(http.DefaultClient, map[string]interface{}{})
+	if res.Err.Type != "" {
+		t.Fatalf("expected no error, got %v", res.Err)
+	}
+	if res.Custom["eventCount"] != int64(3) {
+		t.Errorf("eventCount expected 3, got %v", res.Custom["eventCount"])
+	}
+	if fmt.Sprint(res.ExtractedEnvs["LAST"]) != "42" {
+		t.Errorf("LAST expected 42, got %v", res.ExtractedEnvs["LAST"])
+	}
+}
@@ -0,0 +1,451 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"go.ddosify.com/ddosify/core/scenario/scripting/assertion/evaluator"
+	"go.ddosify.com/ddosify/core/scenario/scripting/extraction"
+	"go.ddosify.com/ddosify/core/scenario/scripting/injection"
+	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/types/regex"
+)
+
+type WebSocketRequester struct {
+	ctx        context.Context
+	proxyAddr  *url.URL
+	packet     types.ScenarioStep
+	dialer     *websocket.Dialer
+	ei         *injection.EnvironmentInjector
+	debug      bool
+	dynamicRgx *regexp.Regexp
+	envRgx     *regexp.Regexp
+
+	// compiled regexes of the expect actions, by action index
+	expectRgx map[int]*regexp.Regexp
+}
+
+// Init creates the dialer of the step. Each Send opens its own socket and closes it at the end of the step.
+func (w *WebSocketRequester) Init(ctx context.Context, s types.ScenarioStep, proxyAddr *url.URL, debug bool,
+	ei *injection.EnvironmentInjector) (err error) {
+	w.ctx = ctx
+	w.packet = s
+	w.proxyAddr = proxyAddr
+	w.ei = ei
+	w.debug = debug
+	w.dynamicRgx = regexp.MustCompile(regex.DynamicVariableRegex)
+	w.envRgx = regexp.MustCompile(regex.EnvironmentVariableRegex)
+
+	w.dialer = &websocket.Dialer{
+		Proxy:            http.ProxyURL(proxyAddr),
+		TLSClientConfig:  w.initTLSConfig(),
+		HandshakeTimeout: time.Duration(s.Timeout) * time.Second,
+	}
+
+	w.expectRgx = make(map[int]*regexp.Regexp)
+	for i, a := range s.Ws.Actions {
+		if a.Type == types.WsActionExpect && a.Regex != "" {
+			if w.expectRgx[i], err = regexp.Compile(a.Regex); err != nil {
+				return
+			}
+		}
+		if w.dynamicRgx.MatchString(a.Payload) {
+			if _, err = w.ei.InjectDynamic(a.Payload); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (w *WebSocketRequester) initTLSConfig() *tls.Config {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+	}
+
+	if w.packet.CertPool != nil && w.packet.Cert.Certificate != nil {
+		tlsConfig.RootCAs = w.packet.CertPool
+		tlsConfig.Certificates = []tls.Certificate{w.packet.Cert}
+	}
+
+	if val, ok := w.packet.Custom["hostname"]; ok {
+		tlsConfig.ServerName = val.(string)
+	}
+	return tlsConfig
+}
+
+func (w *WebSocketRequester) Send(envs map[string]interface{}) (res *types.ScenarioStepResult) {
+	var statusCode int
+	var requestErr types.RequestError
+	var reqStartTime = time.Now()
+	var respHeaders http.Header
+	var extractedVars = make(map[string]interface{})
+	var failedCaptures = make(map[string]string, 0)
+	var failedAssertions = make([]types.FailedAssertion, 0)
+
+	var usableVars = make(map[string]interface{}, len(envs))
+	for k, v := range envs {
+		usableVars[k] = v
+	}
+
+	res = &types.ScenarioStepResult{
+		StepID:      w.packet.ID,
+		StepName:    w.packet.Name,
+		RequestID:   uuid.New(),
+		RequestTime: reqStartTime,
+		Method:      http.MethodGet,
+		UsableEnvs:  usableVars,
+	}
+
+	target, header, err := w.prepareReq(usableVars)
+	if err != nil { // could not prepare req
+		res.Err = types.RequestError{
+			Type:   types.ErrorInvalidRequest,
+			Reason: fmt.Sprintf("Could not prepare req, %s", err.Error()),
+		}
+		return res
+	}
+	res.Url = target
+	res.ReqHeaders = header
+
+	// Action
+	conn, httpRes, err := w.dialer.DialContext(w.ctx, target, header)
+	handshakeDur := time.Since(reqStartTime)
+	if httpRes != nil {
+		statusCode = httpRes.StatusCode
+		respHeaders = httpRes.Header
+	}
+
+	var sess *wsSession
+	if err != nil {
+		requestErr = w.fetchErrType(err)
+	} else {
+		sess = newWsSession(conn)
+		requestErr = w.runActions(sess, usableVars)
+		sess.close()
+	}
+
+	duration := time.Since(reqStartTime)
+	custom := map[string]interface{}{
+		"wsHandshakeDuration": handshakeDur,
+	}
+
+	var respBody []byte
+	if sess != nil {
+		custom["sentFrameCount"] = sess.sent
+		custom["receivedFrameCount"] = atomic.LoadInt64(&sess.received)
+		if len(sess.roundTrips) > 0 {
+			custom["wsRoundTripDuration"] = averageDuration(sess.roundTrips)
+		}
+		if len(sess.matched) > 0 {
+			respBody = sess.matched[len(sess.matched)-1]
+		}
+	}
+
+	if requestErr.Type != "" {
+		failedCaptures = captureEnvironmentVariables(w.packet.EnvsToCapture, nil, nil, nil, extractedVars)
+	} else {
+		// capture
+		if len(w.packet.EnvsToCapture) > 0 {
+			failedCaptures = captureFromFrames(w.packet.EnvsToCapture, respHeaders, sess.matched, extractedVars)
+		}
+
+		// assert
+		if len(w.packet.Assertions) > 0 {
+			_, failedAssertions = applyAssertions(w.packet.Assertions, &evaluator.AssertEnv{
+				StatusCode:   int64(statusCode),
+				ResponseSize: int64(len(respBody)),
+				ResponseTime: duration.Milliseconds(), // in ms
+				Body:         string(respBody),
+				Headers:      respHeaders,
+				Variables:    concatEnvs(envs, extractedVars),
+			})
+		}
+	}
+
+	res.StatusCode = statusCode
+	res.Duration = duration
+	res.ContentLength = int64(len(respBody))
+	res.Err = requestErr
+	res.RespHeaders = respHeaders
+	res.RespBody = respBody
+	res.Custom = custom
+	res.ExtractedEnvs = extractedVars
+	res.FailedCaptures = failedCaptures
+	res.FailedAssertions = failedAssertions
+	return res
+}
+
+func (w *WebSocketRequester) prepareReq(envs map[string]interface{}) (string, http.Header, error) {
+	target, err := w.inject(w.packet.URL, envs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	header := make(http.Header)
+	for k, v := range w.packet.Headers {
+		if k, err = w.inject(k, envs); err != nil {
+			return "", nil, err
+		}
+		if v, err = w.inject(v, envs); err != nil {
+			return "", nil, err
+		}
+		header.Set(k, v)
+	}
+
+	if w.packet.Auth.Username != "" || w.packet.Auth.Password != "" {
+		r := &http.Request{Header: header}
+		r.SetBasicAuth(w.packet.Auth.Username, w.packet.Auth.Password)
+	}
+	return target, header, nil
+}
+
+func (w *WebSocketRequester) inject(text string, envs map[string]interface{}) (string, error) {
+	var err error
+	if w.dynamicRgx.MatchString(text) {
+		if text, err = w.ei.InjectDynamic(text); err != nil {
+			return "", err
+		}
+	}
+	if w.envRgx.MatchString(text) {
+		return w.ei.InjectEnv(text, envs)
+	}
+	return text, nil
+}
+
+// runActions runs the actions of the step on the socket in order. Stops at the first failing action.
+func (w *WebSocketRequester) runActions(sess *wsSession, envs map[string]interface{}) types.RequestError {
+	for i, a := range w.packet.Ws.Actions {
+		var err error
+		switch a.Type {
+		case types.WsActionSend:
+			var msg string
+			if msg, err = w.inject(a.Payload, envs); err != nil {
+				return types.RequestError{
+					Type:   types.ErrorInvalidRequest,
+					Reason: fmt.Sprintf("Could not prepare message, %s", err.Error()),
+				}
+			}
+			err = sess.send(msg)
+		case types.WsActionExpect:
+			err = w.expect(sess, i, a, envs)
+		case types.WsActionClose:
+			return types.RequestError{}
+		}
+
+		if err != nil {
+			return w.fetchErrType(err)
+		}
+	}
+	return types.RequestError{}
+}
+
+// expect waits for a message that matches the given expect action. Unmatched messages are skipped.
+func (w *WebSocketRequester) expect(sess *wsSession, i int, a types.WsAction, envs map[string]interface{}) error {
+	equals, err := w.inject(a.Equals, envs)
+	if err != nil {
+		return err
+	}
+
+	timeout := a.Timeout
+	if timeout == 0 {
+		timeout = w.packet.Timeout
+	}
+	timer := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer timer.Stop()
+
+	for {
+		select {
+		case msg, ok := <-sess.frames:
+			if !ok {
+				return sess.readErr
+			}
+			if w.matches(msg, i, a, equals) {
+				sess.matched = append(sess.matched, msg)
+				sess.roundTrips = append(sess.roundTrips, time.Since(sess.lastSend))
+				return nil
+			}
+		case <-timer.C:
+			return errWsExpectTimeout
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		}
+	}
+}
+
+func (w *WebSocketRequester) matches(msg []byte, i int, a types.WsAction, equals string) bool {
+	if rgx, ok := w.expectRgx[i]; ok && !rgx.Match(msg) {
+		return false
+	}
+
+	if a.JsonPath != "" {
+		val, err := extraction.ExtractFromJson(msg, a.JsonPath)
+		if err != nil {
+			return false
+		}
+		if a.Equals != "" && fmt.Sprint(val) != equals {
+			return false
+		}
+	}
+	return true
+}
+
+var errWsExpectTimeout = errors.New("expected message is not received")
+
+func (w *WebSocketRequester) fetchErrType(err error) types.RequestError {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return types.RequestError{Type: types.ErrorIntented, Reason: types.ReasonCtxCanceled}
+	case errors.Is(err, errWsExpectTimeout):
+		return types.RequestError{Type: types.ErrorConn, Reason: types.ReasonReadTimeout}
+	case errors.Is(err, websocket.ErrBadHandshake):
+		return types.RequestError{Type: types.ErrorConn, Reason: err.Error()}
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return types.RequestError{Type: types.ErrorConn, Reason: types.ReasonConnTimeout}
+	case strings.Contains(err.Error(), "connection refused"):
+		if w.proxyAddr != nil && strings.Contains(err.Error(), w.proxyAddr.Host) {
+			return types.RequestError{Type: types.ErrorProxy, Reason: types.ReasonProxyFailed}
+		}
+		return types.RequestError{Type: types.ErrorConn, Reason: types.ReasonConnRefused}
+	}
+	return types.RequestError{Type: types.ErrorConn, Reason: err.Error()}
+}
+
+func (w *WebSocketRequester) Done() {
+}
+
+func (w *WebSocketRequester) Type() string {
+	return "WS"
+}
+
+// wsSession is an open socket of a step. Received messages are read in the background.
+type wsSession struct {
+	conn     *websocket.Conn
+	frames   chan []byte
+	readErr  error
+	done     chan struct{}
+	lastSend time.Time
+
+	sent       int64
+	received   int64
+	matched    [][]byte
+	roundTrips []time.Duration
+}
+
+func newWsSession(conn *websocket.Conn) *wsSession {
+	s := &wsSession{
+		conn:     conn,
+		frames:   make(chan []byte, 64),
+		done:     make(chan struct{}),
+		lastSend: time.Now(),
+	}
+	go s.read()
+	return s
+}
+
+func (s *wsSession) read() {
+	defer close(s.frames)
+	for {
+		_, msg, err := s.conn.ReadMessage()
+		if err != nil {
+			s.readErr = err
+			return
+		}
+		atomic.AddInt64(&s.received, 1)
+
+		select {
+		case s.frames <- msg:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *wsSession) send(msg string) error {
+	s.lastSend = time.Now()
+	if err := s.conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		return err
+	}
+	s.sent++
+	return nil
+}
+
+// close sends the close frame and closes the underlying connection.
+func (s *wsSession) close() {
+	deadline := time.Now().Add(time.Second)
+	s.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+	close(s.done)
+	s.conn.Close()
+}
+
+// captureFromFrames captures the envs from the matched frames. Value of an env is taken from the latest frame
+// that it can be extracted from.
+func captureFromFrames(envsToCapture []types.EnvCaptureConf, header http.Header, frames [][]byte,
+	extractedVars map[string]interface{}) map[string]string {
+	if len(frames) == 0 {
+		frames = [][]byte{{}}
+	}
+
+	var failedCaptures map[string]string
+	for i, f := range frames {
+		vars := make(map[string]interface{})
+		failed := captureEnvironmentVariables(envsToCapture, header, f, nil, vars)
+		for name, v := range vars {
+			if _, ok := failed[name]; !ok || i == 0 {
+				extractedVars[name] = v
+			}
+		}
+
+		if i == 0 {
+			failedCaptures = failed
+			continue
+		}
+		for name := range failedCaptures {
+			if _, ok := failed[name]; !ok {
+				delete(failedCaptures, name)
+			}
+		}
+	}
+	return failedCaptures
+}
+
+func averageDuration(durations []time.Duration) time.Duration {
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return total / time.Duration(len(durations))
+}
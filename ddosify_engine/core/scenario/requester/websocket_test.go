@@ -0,0 +1,193 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package requester
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"go.ddosify.com/ddosify/core/types"
+)
+
+// startWsServer serves a socket that replies each message with a notification and an ack frame.
+// The value of the X-Token header of the handshake is echoed in the frames.
+func startWsServer(t *testing.T) string {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Token")
+		conn, err := upgrader.Upgrade(w, r, http.Header{"X-Echo": {token}})
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			notification, _ := json.Marshal(map[string]string{"type": "notification", "token": token})
+			ack, _ := json.Marshal(map[string]string{"type": "ack", "echo": string(msg)})
+			conn.WriteMessage(websocket.TextMessage, notification)
+			conn.WriteMessage(websocket.TextMessage, ack)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestWebSocketRequester(t *testing.T) {
+	t.Parallel()
+	target := startWsServer(t)
+	jsonPath := "echo"
+	headerKey := "X-Echo"
+
+	tests := []struct {
+		name     string
+		actions  []types.WsAction
+		body     string
+		sent     int64
+		errType  string
+		captured string
+	}{
+		{
+			name: "JsonPath",
+			actions: []types.WsAction{
+				{Type: types.WsActionSend, Payload: "hello {{name}}"},
+				{Type: types.WsActionExpect, JsonPath: "type", Equals: "ack"},
+			},
+			body:     `{"echo":"hello ddosify","type":"ack"}`,
+			sent:     1,
+			captured: "hello ddosify",
+		},
+		{
+			name: "Regex",
+			actions: []types.WsAction{
+				{Type: types.WsActionSend, Payload: "first"},
+				{Type: types.WsActionExpect, Regex: `"echo":"first"`},
+				{Type: types.WsActionSend, Payload: "{{name}}"},
+				{Type: types.WsActionExpect, JsonPath: "type", Equals: "ack", Regex: "ddosify"},
+				{Type: types.WsActionClose},
+				{Type: types.WsActionSend, Payload: "not sent"},
+			},
+			body:     `{"echo":"ddosify","type":"ack"}`,
+			sent:     2,
+			captured: "ddosify",
+		},
+		{
+			name: "ExpectTimeout",
+			actions: []types.WsAction{
+				{Type: types.WsActionSend, Payload: "hello"},
+				{Type: types.WsActionExpect, JsonPath: "type", Equals: "unknown", Timeout: 1},
+			},
+			errType: types.ErrorConn,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		tf := func(t *testing.T) {
+			t.Parallel()
+			s := types.ScenarioStep{
+				ID:         1,
+				URL:        target,
+				Timeout:    types.DefaultTimeout,
+				Headers:    map[string]string{"X-Token": "{{name}}"},
+				Ws:         types.WsConf{Actions: test.actions},
+				Assertions: []string{"equals(status_code, 101)"},
+				EnvsToCapture: []types.EnvCaptureConf{
+					{Name: "echo", From: types.Body, JsonPath: &jsonPath},
+					{Name: "token", From: types.Header, Key: &headerKey},
+				},
+			}
+
+			w := initRequester(t, &WebSocketRequester{}, s)
+			res := w.Send(map[string]interface{}{"name": "ddosify"})
+
+			if res.Err.Type != test.errType {
+				t.Fatalf("Expected error type: %q, Found: %q %s", test.errType, res.Err.Type, res.Err.Reason)
+			}
+			if test.errType != "" {
+				if res.Err.Reason != types.ReasonReadTimeout {
+					t.Errorf("Expected error reason: %s, Found: %s", types.ReasonReadTimeout, res.Err.Reason)
+				}
+				if res.FailedCaptures["echo"] == "" {
+					t.Errorf("Expected capture to fail")
+				}
+				return
+			}
+
+			if res.StatusCode != http.StatusSwitchingProtocols {
+				t.Errorf("Expected status code: %d, Found: %d", http.StatusSwitchingProtocols, res.StatusCode)
+			}
+			if string(res.RespBody) != test.body {
+				t.Errorf("Expected body: %s, Found: %s", test.body, res.RespBody)
+			}
+			if len(res.FailedAssertions) != 0 {
+				t.Errorf("Expected no failed assertions, Found: %v", res.FailedAssertions)
+			}
+			if len(res.FailedCaptures) != 0 {
+				t.Errorf("Expected no failed captures, Found: %v", res.FailedCaptures)
+			}
+			if res.ExtractedEnvs["echo"] != test.captured {
+				t.Errorf("Expected echo: %s, Found: %v", test.captured, res.ExtractedEnvs["echo"])
+			}
+			if res.ExtractedEnvs["token"] != "ddosify" {
+				t.Errorf("Expected token: ddosify, Found: %v", res.ExtractedEnvs["token"])
+			}
+
+			if res.Custom["sentFrameCount"] != test.sent {
+				t.Errorf("Expected sent frame count: %d, Found: %v", test.sent, res.Custom["sentFrameCount"])
+			}
+			if res.Custom["receivedFrameCount"].(int64) < test.sent {
+				t.Errorf("Expected at least %d received frames, Found: %v", test.sent, res.Custom["receivedFrameCount"])
+			}
+			for _, k := range []string{"wsHandshakeDuration", "wsRoundTripDuration"} {
+				if _, ok := res.Custom[k]; !ok {
+					t.Errorf("Expected %s to be set", k)
+				}
+			}
+		}
+		t.Run(test.name, tf)
+	}
+}
+
+func TestWebSocketRequesterConnRefused(t *testing.T) {
+	t.Parallel()
+	s := types.ScenarioStep{
+		ID:      1,
+		URL:     "ws://127.0.0.1:1",
+		Timeout: types.DefaultTimeout,
+		Ws:      types.WsConf{Actions: []types.WsAction{{Type: types.WsActionSend, Payload: "hello"}}},
+	}
+
+	w := initRequester(t, &WebSocketRequester{}, s)
+	res := w.Send(map[string]interface{}{})
+
+	if res.Err.Type != types.ErrorConn || res.Err.Reason != types.ReasonConnRefused {
+		t.Errorf("Expected connection refused error, Found: %v", res.Err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"go.ddosify.com/ddosify/core/scenario/scripting/assertion/evaluator"
+	"go.ddosify.com/ddosify/core/util"
 )
 
 func TestAssert(t *testing.T) {
@@ -689,63 +690,63 @@ func TestAssert(t *testing.T) {
 		{
 			input: "p99(iteration_duration) == 99",
 			envs: &evaluator.AssertEnv{
-				TotalTime: []int64{34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99},
+				TotalTime: histogramOf(34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99),
 			},
 			expected: true,
 		},
 		{
 			input: "p98(iteration_duration) == 99",
 			envs: &evaluator.AssertEnv{
-				TotalTime: []int64{34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99},
+				TotalTime: histogramOf(34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99),
 			},
 			expected: true,
 		},
 		{
 			input: "p95(iteration_duration) == 99",
 			envs: &evaluator.AssertEnv{
-				TotalTime: []int64{34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99},
+				TotalTime: histogramOf(34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99),
 			},
 			expected: true,
 		},
 		{
 			input: "p90(iteration_duration) == 98",
 			envs: &evaluator.AssertEnv{
-				TotalTime: []int64{34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99},
+				TotalTime: histogramOf(34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99),
 			},
 			expected: true,
 		},
 		{
 			input: "p80(iteration_duration) == 89",
 			envs: &evaluator.AssertEnv{
-				TotalTime: []int64{34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99},
+				TotalTime: histogramOf(34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99),
 			},
 			expected: true,
 		},
 		{
 			input: "min(iteration_duration) == 34",
 			envs: &evaluator.AssertEnv{
-				TotalTime: []int64{34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99},
+				TotalTime: histogramOf(34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99),
 			},
 			expected: true,
 		},
 		{
 			input: "max(iteration_duration) == 99",
 			envs: &evaluator.AssertEnv{
-				TotalTime: []int64{34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99},
+				TotalTime: histogramOf(34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99),
 			},
 			expected: true,
 		},
 		{
 			input: "max(iteration_duration) == 2222",
 			envs: &evaluator.AssertEnv{
-				TotalTime: []int64{34, 37, 39, 44, 45, 55, 66, 67, 2222, 72, 75, 77, 89, 92, 98, 99},
+				TotalTime: histogramOf(34, 37, 39, 44, 45, 55, 66, 67, 2222, 72, 75, 77, 89, 92, 98, 99),
 			},
 			expected: true,
 		},
 		{
 			input: "avg(iteration_duration) == 200.6875",
 			envs: &evaluator.AssertEnv{
-				TotalTime: []int64{34, 37, 39, 44, 45, 55, 66, 67, 2222, 72, 75, 77, 89, 92, 98, 99},
+				TotalTime: histogramOf(34, 37, 39, 44, 45, 55, 66, 67, 2222, 72, 75, 77, 89, 92, 98, 99),
 			},
 			expected: true,
 		},
@@ -853,6 +854,40 @@ func TestAssert(t *testing.T) {
 			input:    "avg([])", // empty interface array, not []int64
 			expected: false,
 		},
+		{
+			input: `equals(gql_errors, [])`,
+			envs: &evaluator.AssertEnv{
+				GqlErrors: []interface{}{},
+			},
+			expected: true,
+		},
+		{
+			input: `equals(json_path("errors.0.message"), "not found") && equals(data, null)`,
+			envs: &evaluator.AssertEnv{
+				Body:      `{"errors":[{"message":"not found"}],"data":null}`,
+				GqlErrors: []interface{}{map[string]interface{}{"message": "not found"}},
+			},
+			expected: true,
+		},
+		{
+			input: `equals(data.user.name, "messi") && equals(data.user.goals, 800)`,
+			envs: &evaluator.AssertEnv{
+				GqlData: map[string]interface{}{
+					"user": map[string]interface{}{"name": "messi", "goals": 800},
+				},
+			},
+			expected: true,
+		},
+		{
+			input: `equals(data.team.name, "barcelona")`,
+			envs: &evaluator.AssertEnv{
+				GqlData: map[string]interface{}{
+					"user": map[string]interface{}{"name": "messi"},
+				},
+			},
+			expected:      false,
+			expectedError: "NotFoundError",
+		},
 	}
 
 	for _, tc := range tests {
@@ -895,3 +930,11 @@ func TestAssert(t *testing.T) {
 	}
 
 }
+
+func histogramOf(values ...int64) *util.Histogram {
+	h := util.NewHistogram(util.DefaultHistogramPrecision)
+	for _, v := range values {
+		h.Record(v)
+	}
+	return h
+}
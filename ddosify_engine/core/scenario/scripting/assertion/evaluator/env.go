@@ -1,6 +1,10 @@
 package evaluator
 
-import "net/http"
+import (
+	"net/http"
+
+	"go.ddosify.com/ddosify/core/util"
+)
 
 type AssertEnv struct {
 	StatusCode   int64
@@ -11,8 +15,12 @@ type AssertEnv struct {
 	Variables    map[string]interface{}
 	Cookies      map[string]*http.Cookie // cookies sent by the server, name -> cookie
 
+	// For GraphQL steps, errors and data fields of the response
+	GqlErrors []interface{}
+	GqlData   interface{}
+
 	// For test-wide assertions
-	TotalTime     []int64 // in ms
+	TotalTime     *util.Histogram // iteration durations in ms
 	FailCount     int
 	FailCountPerc float64 // should be in range [0,1]
 }
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"go.ddosify.com/ddosify/core/scenario/scripting/assertion/ast"
+	"go.ddosify.com/ddosify/core/util"
 )
 
 func Eval(node ast.Node, env *AssertEnv, receivedMap map[string]interface{}) (interface{}, error) {
@@ -201,74 +202,98 @@ func Eval(node ast.Node, env *AssertEnv, receivedMap map[string]interface{}) (in
 					}
 					return contains(p1, p2), nil
 				case AVG:
+					if h, ok := args[0].(*util.Histogram); ok {
+						return histAvg(h)
+					}
 					arr, ok := args[0].([]int64)
 					if !ok {
 						return false, ArgumentError{
-							msg:        "argument of avg func must be an int64 array",
+							msg:        "argument of avg func must be an int64 array or a histogram",
 							wrappedErr: nil,
 						}
 					}
 					return avg(arr)
 				case MIN:
+					if h, ok := args[0].(*util.Histogram); ok {
+						return histMin(h)
+					}
 					arr, ok := args[0].([]int64)
 					if !ok {
 						return false, ArgumentError{
-							msg:        "argument of min func must be an int64 array",
+							msg:        "argument of min func must be an int64 array or a histogram",
 							wrappedErr: nil,
 						}
 					}
 					return min(arr)
 				case MAX:
+					if h, ok := args[0].(*util.Histogram); ok {
+						return histMax(h)
+					}
 					arr, ok := args[0].([]int64)
 					if !ok {
 						return false, ArgumentError{
-							msg:        "argument of max func must be an int64 array",
+							msg:        "argument of max func must be an int64 array or a histogram",
 							wrappedErr: nil,
 						}
 					}
 					return max(arr)
 				// TODO only one func percentile(arr, num) ?
 				case P99:
+					if h, ok := args[0].(*util.Histogram); ok {
+						return histPercentile(h, 99)
+					}
 					arr, ok := args[0].([]int64)
 					if !ok {
 						return false, ArgumentError{
-							msg:        "argument of percentile funcs must be an int64 array",
+							msg:        "argument of percentile funcs must be an int64 array or a histogram",
 							wrappedErr: nil,
 						}
 					}
 					return percentile(arr, 99)
 				case P98:
+					if h, ok := args[0].(*util.Histogram); ok {
+						return histPercentile(h, 98)
+					}
 					arr, ok := args[0].([]int64)
 					if !ok {
 						return false, ArgumentError{
-							msg:        "argument of percentile funcs must be an int64 array",
+							msg:        "argument of percentile funcs must be an int64 array or a histogram",
 							wrappedErr: nil,
 						}
 					}
 					return percentile(arr, 98)
 				case P95:
+					if h, ok := args[0].(*util.Histogram); ok {
+						return histPercentile(h, 95)
+					}
 					arr, ok := args[0].([]int64)
 					if !ok {
 						return false, ArgumentError{
-							msg:        "argument of percentile funcs must be an int64 array",
+							msg:        "argument of percentile funcs must be an int64 array or a histogram",
 							wrappedErr: nil,
 						}
 					}
 					return percentile(arr, 95)
 				case P90:
+					if h, ok := args[0].(*util.Histogram); ok {
+						return histPercentile(h, 90)
+					}
 					arr, ok := args[0].([]int64)
 					if !ok {
 						return false, ArgumentError{
-							msg:        "argument of percentile funcs must be an int64 array",
+							msg:        "argument of percentile funcs must be an int64 array or a histogram",
 							wrappedErr: nil,
 						}
 					}
 					return percentile(arr, 90)
 				case P80:
+					if h, ok := args[0].(*util.Histogram); ok {
+						return histPercentile(h, 80)
+					}
 					arr, ok := args[0].([]int64)
 					if !ok {
 						return false, ArgumentError{
-							msg:        "argument of percentile funcs must be an int64 array",
+							msg:        "argument of percentile funcs must be an int64 array or a histogram",
 							wrappedErr: nil,
 						}
 					}
@@ -603,6 +628,15 @@ func evalIdentifier(
 		return env.Body, nil
 	}
 
+	if strings.EqualFold(ident, "gql_errors") {
+		receivedMap[ident] = env.GqlErrors
+		return env.GqlErrors, nil
+	}
+	if strings.EqualFold(ident, "data") {
+		receivedMap[ident] = env.GqlData
+		return env.GqlData, nil
+	}
+
 	// test-wide identifiers
 	if strings.EqualFold(ident, "fail_count") {
 		receivedMap[ident] = env.FailCount
@@ -613,7 +647,10 @@ func evalIdentifier(
 		return env.FailCountPerc, nil
 	}
 	if strings.EqualFold(ident, "iteration_duration") {
-		receivedMap[ident] = env.TotalTime
+		if env.TotalTime == nil {
+			env.TotalTime = util.NewHistogram(util.DefaultHistogramPrecision)
+		}
+		receivedMap[ident] = env.TotalTime.Summary()
 		return env.TotalTime, nil
 	}
 
@@ -628,6 +665,22 @@ func evalIdentifier(
 			wrappedErr: nil,
 		}
 	}
+	if strings.HasPrefix(ident, "data.") {
+		// data.user.name, json path in the data field of the GraphQL response
+		vr := strings.TrimPrefix(ident, "data.")
+		d, err := json.Marshal(env.GqlData)
+		if err == nil {
+			var v interface{}
+			if v, err = jsonExtract(string(d), vr); err == nil {
+				receivedMap[ident] = v
+				return v, nil
+			}
+		}
+		return "", NotFoundError{
+			source:     fmt.Sprintf("data field not found %s", vr),
+			wrappedErr: err,
+		}
+	}
 	if strings.HasPrefix(ident, "headers.") {
 		vr := strings.TrimPrefix(ident, "headers.")
 		hv := env.Headers.Get(vr)
@@ -11,6 +11,7 @@ import (
 
 	"go.ddosify.com/ddosify/core/scenario/scripting/extraction"
 	"go.ddosify.com/ddosify/core/types"
+	"go.ddosify.com/ddosify/core/util"
 )
 
 var less_than = func(variable int64, limit int64) bool {
@@ -82,6 +83,34 @@ var avg = func(arr []int64) (float64, error) {
 	return float64(total) / float64(len(arr)), nil
 }
 
+var histPercentile = func(h *util.Histogram, num int) (int64, error) {
+	if h.Count() == 0 {
+		return 0, fmt.Errorf("empty histogram on percentile func")
+	}
+	return h.ValueAtPercentile(float64(num)), nil
+}
+
+var histMin = func(h *util.Histogram) (int64, error) {
+	if h.Count() == 0 {
+		return 0, fmt.Errorf("empty histogram on min func")
+	}
+	return h.Min(), nil
+}
+
+var histMax = func(h *util.Histogram) (int64, error) {
+	if h.Count() == 0 {
+		return 0, fmt.Errorf("empty histogram on max func")
+	}
+	return h.Max(), nil
+}
+
+var histAvg = func(h *util.Histogram) (float64, error) {
+	if h.Count() == 0 {
+		return 0, fmt.Errorf("empty histogram on avg func")
+	}
+	return h.Mean(), nil
+}
+
 var equals = func(a, b interface{}) (bool, error) {
 	b, err := evalInfixExpression("==", a, b)
 	if err != nil {
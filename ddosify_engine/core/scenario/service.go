@@ -24,6 +24,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -35,6 +36,7 @@ import (
 
 	"go.ddosify.com/ddosify/core/scenario/requester"
 	"go.ddosify.com/ddosify/core/scenario/scripting/injection"
+	"go.ddosify.com/ddosify/core/tracing"
 	"go.ddosify.com/ddosify/core/types"
 	"go.ddosify.com/ddosify/core/types/regex"
 	"go.ddosify.com/ddosify/core/util"
@@ -47,6 +49,18 @@ type ScenarioService struct {
 	// Each scenarioItem has a requester
 	clients map[*url.URL][]scenarioItemRequester
 
+	// Requesters of the steps bound to the source IPs, used instead of clients if source IPs are given.
+	sourceClients map[sourceKey][]scenarioItemRequester
+
+	// Source IPs rotated between the iterations or the clients of the pool.
+	sourceIPs        []net.IP
+	sourceIPRotation string
+	sourceIPIndex    uint64
+	clientIPs        map[*http.Client]net.IP
+
+	// Tokens of the oauth2 auths, shared by the steps.
+	tokens *requester.OAuth2Tokens
+
 	cPool *util.Pool[*http.Client]
 
 	scenario types.Scenario
@@ -55,6 +69,9 @@ type ScenarioService struct {
 	clientMutex sync.Mutex
 	debug       bool
 	engineMode  string
+	thinkTime   bool
+	captureBody bool
+	tracing     bool
 
 	ei        *injection.EnvironmentInjector
 	iterIndex int64
@@ -71,6 +88,26 @@ type ScenarioOpts struct {
 	MaxConcurrentIterCount int
 	EngineMode             string
 	InitialCookies         []*http.Cookie
+
+	// Iterations are looped by long-lived virtual users. Sleep of the last step is applied
+	// between the iterations as think time, even for single step scenarios.
+	ThinkTime bool
+
+	// Response bodies are kept in the step results to be exported.
+	CaptureBody bool
+
+	// Iterations and steps are given trace contexts, which are propagated to the targets of the http steps.
+	Tracing bool
+
+	// Connections of the http steps are bound to the source IPs, rotated by SourceIPRotation.
+	SourceIPs        []net.IP
+	SourceIPRotation string
+}
+
+// sourceKey is the key of the requesters bound to a source IP.
+type sourceKey struct {
+	proxy    *url.URL
+	sourceIP string
 }
 
 // Init initializes the ScenarioService.clients with the given types.Scenario and proxies.
@@ -80,14 +117,29 @@ func (s *ScenarioService) Init(ctx context.Context, scenario types.Scenario,
 	s.scenario = scenario
 	s.ctx = ctx
 	s.debug = opts.Debug
+	s.thinkTime = opts.ThinkTime
+	s.captureBody = opts.CaptureBody
+	s.tracing = opts.Tracing
 	s.clients = make(map[*url.URL][]scenarioItemRequester, len(proxies))
+	s.sourceClients = make(map[sourceKey][]scenarioItemRequester, len(proxies)*len(opts.SourceIPs))
+	s.sourceIPs = opts.SourceIPs
+	s.sourceIPRotation = opts.SourceIPRotation
+	s.clientIPs = make(map[*http.Client]net.IP)
+	s.tokens = requester.NewOAuth2Tokens()
 
 	ei := &injection.EnvironmentInjector{}
 	ei.Init()
 	s.ei = ei
 
 	for _, p := range proxies {
-		err = s.createRequesters(p)
+		if len(s.sourceIPs) == 0 {
+			err = s.createRequesters(p)
+		}
+		for _, ip := range s.sourceIPs {
+			if err == nil {
+				_, err = s.getOrCreateSourceRequesters(p, ip)
+			}
+		}
 		if err != nil {
 			return
 		}
@@ -136,13 +188,48 @@ func putInitialCookiesInJarFactory(engineMode string, initCookies []*http.Cookie
 // Returns "types.Response" filled by the requester of the given Proxy, injects the given startTime to the response
 // Returns error only if types.Response.Err.Type is types.ErrorProxy or types.ErrorIntented
 func (s *ScenarioService) Do(proxy *url.URL, startTime time.Time) (
+	response *types.ScenarioResult, err *types.RequestError) {
+	client := s.AcquireClient()
+	defer s.ReleaseClient(client)
+
+	return s.DoWithClient(proxy, startTime, client)
+}
+
+// AcquireClient gets a client from the client pool, so the caller can use it for multiple iterations.
+// Returns nil if the engine is not in user mode, requesters use their own clients in that case.
+func (s *ScenarioService) AcquireClient() *http.Client {
+	if !s.engineInUserMode() {
+		return nil
+	}
+	return s.cPool.Get()
+}
+
+// ReleaseClient puts the client taken by AcquireClient back to the client pool.
+func (s *ScenarioService) ReleaseClient(client *http.Client) {
+	if client == nil || !s.engineInUserMode() {
+		return
+	}
+	s.cPool.Put(client)
+}
+
+// DoWithClient is the same as Do, except that the scenario is executed with the given client.
+func (s *ScenarioService) DoWithClient(proxy *url.URL, startTime time.Time, client *http.Client) (
 	response *types.ScenarioResult, err *types.RequestError) {
 	response = &types.ScenarioResult{StepResults: []*types.ScenarioStepResult{}}
 	response.StartTime = startTime
 	response.ProxyAddr = proxy
+	if s.tracing {
+		response.Trace = tracing.NewTraceContext()
+	}
 	rand.Seed(time.Now().UnixNano())
 
-	requesters, e := s.getOrCreateRequesters(proxy)
+	var requesters []scenarioItemRequester
+	var e error
+	if sourceIP := s.sourceIP(client); sourceIP != nil {
+		requesters, e = s.getOrCreateSourceRequesters(proxy, sourceIP)
+	} else {
+		requesters, e = s.getOrCreateRequesters(proxy)
+	}
 	if e != nil {
 		return nil, &types.RequestError{Type: types.ErrorUnkown, Reason: e.Error()}
 	}
@@ -158,19 +245,29 @@ func (s *ScenarioService) Do(proxy *url.URL, startTime time.Time) (
 	s.enrichEnvFromData(envs)
 	atomic.AddInt64(&s.iterIndex, 1)
 
-	var client *http.Client
-	if s.engineInUserMode() {
-		// get client from pool
-		client = s.cPool.Get()
-		defer s.cPool.Put(client)
-	}
-
 	for _, sr := range requesters {
 		var res *types.ScenarioStepResult
+		var tc types.TraceContext
+		if s.tracing {
+			tc = tracing.ChildContext(response.Trace)
+		}
 		switch sr.requester.Type() {
 		case "HTTP":
 			httpRequester := sr.requester.(requester.HttpRequesterI)
-			res = httpRequester.Send(client, envs)
+			if tr, ok := httpRequester.(requester.TracedHttpRequesterI); ok && s.tracing {
+				res = tr.SendWithTrace(client, envs, tc)
+			} else {
+				res = httpRequester.Send(client, envs)
+			}
+		case "GRPC":
+			grpcRequester := sr.requester.(requester.GrpcRequesterI)
+			res = grpcRequester.Send(envs)
+		case "WS":
+			wsRequester := sr.requester.(requester.WebSocketRequesterI)
+			res = wsRequester.Send(envs)
+		case "RAW":
+			rawRequester := sr.requester.(requester.RawRequesterI)
+			res = rawRequester.Send(envs)
 		default:
 			res = &types.ScenarioStepResult{Err: types.RequestError{Type: fmt.Sprintf("type not defined: %s", sr.requester.Type())}}
 		}
@@ -182,10 +279,14 @@ func (s *ScenarioService) Do(proxy *url.URL, startTime time.Time) (
 				return
 			}
 		}
+		if s.tracing {
+			// steps of the other protocols are traced without propagating the context
+			res.Trace = tc
+		}
 		response.StepResults = append(response.StepResults, res)
 
 		// Sleep before running the next step
-		if sr.sleeper != nil && len(s.scenario.Steps) > 1 {
+		if sr.sleeper != nil && (len(s.scenario.Steps) > 1 || s.thinkTime) {
 			sr.sleeper.sleep()
 		}
 
@@ -237,6 +338,11 @@ func (s *ScenarioService) Done() {
 			r.requester.Done()
 		}
 	}
+	for _, v := range s.sourceClients {
+		for _, r := range v {
+			r.requester.Done()
+		}
+	}
 
 	if s.cPool != nil {
 		s.cPool.Done()
@@ -257,16 +363,73 @@ func (s *ScenarioService) getOrCreateRequesters(proxy *url.URL) (requesters []sc
 	return s.clients[proxy], err
 }
 
+// sourceIP returns the source IP of the iteration, nil if no source IP is given. The next source IP is used for
+// each iteration, except that the clients of the user engine modes keep their first source IP in the client rotation.
+// The connections of a client are closed if its source IP changes, so that they are made from the new IP.
+func (s *ScenarioService) sourceIP(client *http.Client) net.IP {
+	if len(s.sourceIPs) == 0 {
+		return nil
+	}
+	if client == nil {
+		return s.nextSourceIP()
+	}
+
+	s.clientMutex.Lock()
+	defer s.clientMutex.Unlock()
+	bound, ok := s.clientIPs[client]
+	if ok && s.sourceIPRotation != types.SourceIPRotationIteration {
+		return bound
+	}
+	ip := s.nextSourceIP()
+	if ok && !bound.Equal(ip) {
+		client.CloseIdleConnections()
+		client.Transport = nil
+	}
+	s.clientIPs[client] = ip
+	return ip
+}
+
+func (s *ScenarioService) nextSourceIP() net.IP {
+	i := atomic.AddUint64(&s.sourceIPIndex, 1) - 1
+	return s.sourceIPs[i%uint64(len(s.sourceIPs))]
+}
+
+func (s *ScenarioService) getOrCreateSourceRequesters(proxy *url.URL, sourceIP net.IP) (
+	requesters []scenarioItemRequester, err error) {
+	s.clientMutex.Lock()
+	defer s.clientMutex.Unlock()
+
+	key := sourceKey{proxy: proxy, sourceIP: sourceIP.String()}
+	requesters, ok := s.sourceClients[key]
+	if !ok {
+		requesters, err = s.newRequesters(proxy, sourceIP)
+		if err != nil {
+			return
+		}
+		s.sourceClients[key] = requesters
+	}
+	return requesters, nil
+}
+
 func (s *ScenarioService) createRequesters(proxy *url.URL) (err error) {
-	s.clients[proxy] = []scenarioItemRequester{}
+	s.clients[proxy], err = s.newRequesters(proxy, nil)
+	return err
+}
+
+// newRequesters creates the requesters of the steps for the proxy, bound to the source IP if it is not nil.
+func (s *ScenarioService) newRequesters(proxy *url.URL, sourceIP net.IP) (requesters []scenarioItemRequester, err error) {
+	requesters = []scenarioItemRequester{}
 	for _, si := range s.scenario.Steps {
+		si.CaptureBody = si.CaptureBody || s.captureBody
+		si.SourceIP = sourceIP
+
 		var r requester.Requester
 		r, err = requester.NewRequester(si)
 		if err != nil {
 			return
 		}
-		s.clients[proxy] = append(
-			s.clients[proxy],
+		requesters = append(
+			requesters,
 			scenarioItemRequester{
 				scenarioItemID: si.ID,
 				sleeper:        newSleeper(si.Sleep),
@@ -277,7 +440,19 @@ func (s *ScenarioService) createRequesters(proxy *url.URL) (err error) {
 		switch r.Type() {
 		case "HTTP":
 			httpRequester := r.(requester.HttpRequesterI)
+			if or, ok := httpRequester.(requester.OAuth2HttpRequesterI); ok && si.Auth.IsOAuth2() {
+				or.SetOAuth2Tokens(s.tokens)
+			}
 			err = httpRequester.Init(s.ctx, si, proxy, s.debug, s.ei)
+		case "GRPC":
+			grpcRequester := r.(requester.GrpcRequesterI)
+			err = grpcRequester.Init(s.ctx, si, proxy, s.debug, s.ei)
+		case "WS":
+			wsRequester := r.(requester.WebSocketRequesterI)
+			err = wsRequester.Init(s.ctx, si, proxy, s.debug, s.ei)
+		case "RAW":
+			rawRequester := r.(requester.RawRequesterI)
+			err = rawRequester.Init(s.ctx, si, proxy, s.debug, s.ei)
 		default:
 			err = fmt.Errorf("type not defined: %s", r.Type())
 		}
@@ -286,7 +461,7 @@ func (s *ScenarioService) createRequesters(proxy *url.URL) (err error) {
 			return
 		}
 	}
-	return err
+	return requesters, err
 }
 
 func injectDynamicVars(vi *injection.EnvironmentInjector, envs map[string]interface{}) {
@@ -23,9 +23,12 @@ package scenario
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -68,6 +71,18 @@ func (m *MockHttpRequester) Type() string {
 	return "HTTP"
 }
 
+type MockTracedHttpRequester struct {
+	MockHttpRequester
+
+	SentTrace types.TraceContext
+}
+
+func (m *MockTracedHttpRequester) SendWithTrace(client *http.Client, envs map[string]interface{},
+	tc types.TraceContext) (res *types.ScenarioStepResult) {
+	m.SentTrace = tc
+	return m.Send(client, envs)
+}
+
 type MockSleep struct {
 	SleepCalled    bool
 	SleepCallCount int
@@ -279,6 +294,42 @@ func TestDo(t *testing.T) {
 	}
 }
 
+func TestDoWithTracing(t *testing.T) {
+	t.Parallel()
+
+	p1, _ := url.Parse("http://proxy_server.com:80")
+	traced := &MockTracedHttpRequester{MockHttpRequester: MockHttpRequester{ReturnSend: &types.ScenarioStepResult{StepID: 1}}}
+	untraced := &MockHttpRequester{ReturnSend: &types.ScenarioStepResult{StepID: 2}}
+	service := ScenarioService{
+		clients: map[*url.URL][]scenarioItemRequester{
+			p1: {{scenarioItemID: 1, requester: traced}, {scenarioItemID: 2, requester: untraced}},
+		},
+		scenario: types.Scenario{Steps: []types.ScenarioStep{{ID: 1}, {ID: 2}}},
+		ctx:      context.TODO(),
+		tracing:  true,
+	}
+
+	response, err := service.Do(p1, time.Now())
+	if err != nil {
+		t.Fatalf("TestDoWithTracing errored: %v", err)
+	}
+	if !response.Trace.IsValid() {
+		t.Fatalf("Iteration should have a trace context")
+	}
+	if traced.SentTrace != response.StepResults[0].Trace {
+		t.Errorf("Trace context of the step should be propagated, Expected: %v, Found: %v",
+			response.StepResults[0].Trace, traced.SentTrace)
+	}
+	for _, sr := range response.StepResults {
+		if sr.Trace.TraceID != response.Trace.TraceID || sr.Trace.SpanID == response.Trace.SpanID || !sr.Trace.IsValid() {
+			t.Errorf("Step %d should have a child span of the iteration, Found: %v", sr.StepID, sr.Trace)
+		}
+	}
+	if response.StepResults[0].Trace.SpanID == response.StepResults[1].Trace.SpanID {
+		t.Errorf("Steps should have different spans")
+	}
+}
+
 func TestDoErrorOnSend(t *testing.T) {
 	t.Parallel()
 
@@ -602,7 +653,7 @@ func TestCreateRequestersErrorOnRequesterInit(t *testing.T) {
 	}
 }
 
-func TestnewSleeper(t *testing.T) {
+func TestNewSleeper(t *testing.T) {
 	t.Parallel()
 
 	sleepRange := "300-500"
@@ -735,3 +786,120 @@ func TestOnlyOneClientInDebugModeInUserMode(t *testing.T) {
 		t.Fatal("TestOnlyOneClientInDebugModeInUserMode should have only one client")
 	}
 }
+
+func TestDoFromSourceIPs(t *testing.T) {
+	t.Parallel()
+	remoteIPs := make(chan string, 1)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		remoteIPs <- host
+	}))
+	defer target.Close()
+
+	scenario := types.Scenario{
+		Steps: []types.ScenarioStep{
+			{ID: 1, Method: http.MethodGet, URL: target.URL, Timeout: types.DefaultDuration},
+		},
+	}
+	// all of 127.0.0.0/8 is routed to the loopback interface on linux
+	sourceIPs := []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("127.0.0.2")}
+
+	tests := []struct {
+		name       string
+		engineMode string
+		rotation   string
+		expected   []string
+	}{
+		{"Ddosify mode", types.EngineModeDdosify, "", []string{"127.0.0.1", "127.0.0.2", "127.0.0.1"}},
+		{"Ddosify mode client rotation", types.EngineModeDdosify, types.SourceIPRotationClient,
+			[]string{"127.0.0.1", "127.0.0.2", "127.0.0.1"}},
+		{"User mode", types.EngineModeDistinctUser, "", []string{"127.0.0.1", "127.0.0.1", "127.0.0.1"}},
+		{"User mode iteration rotation", types.EngineModeDistinctUser, types.SourceIPRotationIteration,
+			[]string{"127.0.0.1", "127.0.0.2", "127.0.0.1"}},
+	}
+
+	for _, test := range tests {
+		service := ScenarioService{}
+		err := service.Init(context.TODO(), scenario, []*url.URL{nil}, ScenarioOpts{
+			EngineMode:             test.engineMode,
+			IterationCount:         3,
+			MaxConcurrentIterCount: 1,
+			SourceIPs:              sourceIPs,
+			SourceIPRotation:       test.rotation,
+		})
+		if err != nil {
+			t.Fatalf("%s: init errored: %v", test.name, err)
+		}
+		if len(service.sourceClients) != len(sourceIPs) {
+			t.Errorf("%s: Expected requesters for each source ip, Found: %d", test.name, len(service.sourceClients))
+		}
+
+		var client *http.Client
+		if test.engineMode != types.EngineModeDdosify {
+			client = service.AcquireClient()
+		}
+		for i, expected := range test.expected {
+			res, err := service.DoWithClient(nil, time.Now(), client)
+			if err != nil {
+				t.Fatalf("%s: iteration %d errored: %v", test.name, i, err)
+			}
+			if res.StepResults[0].Err.Type != "" {
+				t.Skipf("binding to the loopback ips is not supported: %v", res.StepResults[0].Err)
+			}
+			if ip := <-remoteIPs; ip != expected {
+				t.Errorf("%s: iteration %d, Expected request from %s, Found: %s", test.name, i, expected, ip)
+			}
+		}
+		service.Done()
+	}
+}
+
+func TestDoWithSharedOAuth2Tokens(t *testing.T) {
+	t.Parallel()
+	var issued int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&issued, 1)
+		fmt.Fprint(w, `{"access_token": "token", "token_type": "bearer", "expires_in": 3600}`)
+	}))
+	defer tokenServer.Close()
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer target.Close()
+
+	auth := types.Auth{Type: types.AuthOAuth2, TokenURL: tokenServer.URL, ClientID: "client",
+		Cache: types.OAuth2CacheGlobal}
+	scenario := types.Scenario{
+		Steps: []types.ScenarioStep{
+			{ID: 1, Method: http.MethodGet, URL: target.URL, Timeout: types.DefaultDuration, Auth: auth},
+			{ID: 2, Method: http.MethodGet, URL: target.URL, Timeout: types.DefaultDuration, Auth: auth},
+		},
+	}
+	service := ScenarioService{}
+	err := service.Init(context.TODO(), scenario, []*url.URL{nil}, ScenarioOpts{
+		EngineMode:             types.EngineModeDdosify,
+		IterationCount:         2,
+		MaxConcurrentIterCount: 1,
+	})
+	if err != nil {
+		t.Fatalf("TestDoWithSharedOAuth2Tokens init errored: %v", err)
+	}
+	defer service.Done()
+
+	for i := 0; i < 2; i++ {
+		res, err := service.Do(nil, time.Now())
+		if err != nil {
+			t.Fatalf("TestDoWithSharedOAuth2Tokens errored: %v", err)
+		}
+		for _, sr := range res.StepResults {
+			if sr.StatusCode != http.StatusOK {
+				t.Errorf("Step %d should be authorized, Found: %d %v", sr.StepID, sr.StatusCode, sr.Err)
+			}
+		}
+	}
+	if issued := atomic.LoadInt32(&issued); issued != 1 {
+		t.Errorf("Token should be shared by the steps and the iterations, Found %d token requests", issued)
+	}
+}
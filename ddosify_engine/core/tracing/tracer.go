@@ -0,0 +1,291 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package tracing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+const (
+	defaultServiceName = "ddosify"
+	tracesPath         = "/v1/traces"
+
+	flushInterval = time.Second
+	maxBatchSize  = 512   // spans
+	queueSize     = 10000 // iterations
+)
+
+// NewTraceContext returns the context of a new root span in a new trace.
+func NewTraceContext() (t types.TraceContext) {
+	rand.Read(t.TraceID[:])
+	rand.Read(t.SpanID[:])
+	return
+}
+
+// ChildContext returns the context of a new span in the trace of the parent.
+func ChildContext(parent types.TraceContext) (t types.TraceContext) {
+	t.TraceID = parent.TraceID
+	rand.Read(t.SpanID[:])
+	return
+}
+
+// Tracer exports a span per iteration and a child span per step to an OTLP/HTTP collector in JSON encoding.
+// Iterations are queued and exported in batches, they are dropped instead of slowing down the test if the queue is full.
+type Tracer struct {
+	serviceName string
+	url         string
+	headers     map[string]string
+	client      *http.Client
+
+	queue   chan *types.ScenarioResult
+	stopped chan struct{}
+	dropped int64
+	err     error
+}
+
+// NewTracer is the constructor of the Tracer, Start should be called before Export.
+func NewTracer(conf types.TracingConf) *Tracer {
+	t := &Tracer{
+		serviceName: conf.ServiceName,
+		url:         strings.TrimSuffix(conf.Endpoint, "/"),
+		headers:     conf.Headers,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		queue:       make(chan *types.ScenarioResult, queueSize),
+		stopped:     make(chan struct{}),
+	}
+	if t.serviceName == "" {
+		t.serviceName = defaultServiceName
+	}
+	if !strings.HasSuffix(t.url, tracesPath) {
+		t.url += tracesPath
+	}
+	return t
+}
+
+// Start starts exporting the queued iterations.
+func (t *Tracer) Start() {
+	go t.run()
+}
+
+// Export queues the spans of the traced iteration to be exported.
+func (t *Tracer) Export(scr *types.ScenarioResult) {
+	if scr.Dropped || !scr.Trace.IsValid() {
+		return
+	}
+	select {
+	case t.queue <- scr:
+	default:
+		atomic.AddInt64(&t.dropped, 1)
+	}
+}
+
+// Shutdown exports the queued iterations and waits for the export, Export should not be called after it.
+func (t *Tracer) Shutdown() {
+	close(t.queue)
+	<-t.stopped
+
+	if t.err != nil {
+		color.Yellow("Traces could not be exported to %s: %v\n", t.url, t.err)
+	}
+	if d := atomic.LoadInt64(&t.dropped); d > 0 {
+		color.Yellow("Traces of %d iterations are dropped since the export could not keep up with the test\n", d)
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpSpan, 0, maxBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		err := t.export(batch)
+		// warn once until the exports succeed again
+		if err != nil && t.err == nil {
+			color.Yellow("Trace export failed: %v\n", err)
+		}
+		t.err = err
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case scr, ok := <-t.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, iterationSpans(scr)...)
+			if len(batch) >= maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// export sends the spans to the collector. Spans of a failed export are dropped.
+func (t *Tracer) export(spans []otlpSpan) error {
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{stringAttr("service.name", t.serviceName)}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: defaultServiceName},
+			Spans: spans,
+		}},
+	}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("unexpected status code %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// iterationSpans returns the span of the iteration and the child spans of its steps.
+func iterationSpans(scr *types.ScenarioResult) []otlpSpan {
+	spans := make([]otlpSpan, 0, len(scr.StepResults)+1)
+
+	// the iteration span covers the requests, it starts at StartTime if none of the requests is sent
+	start, end := scr.StartTime, scr.StartTime
+	sent, failed := false, false
+	for _, sr := range scr.StepResults {
+		if !sr.RequestTime.IsZero() {
+			if !sent || sr.RequestTime.Before(start) {
+				sent = true
+				start = sr.RequestTime
+			}
+			if e := sr.RequestTime.Add(sr.Duration); e.After(end) {
+				end = e
+			}
+		}
+		if !sr.Trace.IsValid() {
+			continue
+		}
+		s := stepSpan(sr, scr.Trace)
+		failed = failed || s.Status.Code == statusError
+		spans = append(spans, s)
+	}
+
+	iteration := otlpSpan{
+		TraceID:    fmt.Sprintf("%x", scr.Trace.TraceID),
+		SpanID:     fmt.Sprintf("%x", scr.Trace.SpanID),
+		Name:       "iteration",
+		Kind:       spanKindInternal,
+		Start:      unixNano(start),
+		End:        unixNano(end),
+		Attributes: []otlpAttribute{intAttr("ddosify.step_count", int64(len(scr.StepResults)))},
+		Status:     otlpStatus{Code: statusOk},
+	}
+	if failed {
+		iteration.Status = otlpStatus{Code: statusError, Message: "step failed"}
+	}
+	return append([]otlpSpan{iteration}, spans...)
+}
+
+// stepSpan returns the span of the step as a client span, its phases are the events of the span.
+func stepSpan(sr *types.ScenarioStepResult, parent types.TraceContext) otlpSpan {
+	name := sr.StepName
+	if name == "" {
+		name = fmt.Sprintf("step %d", sr.StepID)
+	}
+
+	attrs := []otlpAttribute{intAttr("ddosify.step.id", int64(sr.StepID))}
+	if sr.StepName != "" {
+		attrs = append(attrs, stringAttr("ddosify.step.name", sr.StepName))
+	}
+	if sr.Method != "" {
+		attrs = append(attrs, stringAttr("http.request.method", sr.Method))
+	}
+	if sr.Url != "" {
+		attrs = append(attrs, stringAttr("url.full", sr.Url))
+	}
+	if sr.StatusCode != 0 {
+		attrs = append(attrs, intAttr("http.response.status_code", int64(sr.StatusCode)))
+	}
+
+	status := otlpStatus{Code: statusOk}
+	if sr.Err.Type != "" {
+		attrs = append(attrs, stringAttr("error.type", sr.Err.Type))
+		status = otlpStatus{Code: statusError, Message: sr.Err.Reason}
+	} else if len(sr.FailedAssertions) > 0 {
+		attrs = append(attrs, intAttr("ddosify.failed_assertions", int64(len(sr.FailedAssertions))))
+		status = otlpStatus{Code: statusError, Message: "assertion failed: " + sr.FailedAssertions[0].Rule}
+	}
+
+	events := make([]otlpEvent, 0, len(sr.TraceEvents))
+	for _, e := range sr.TraceEvents {
+		events = append(events, otlpEvent{Name: e.Name, Time: unixNano(e.Time)})
+	}
+
+	start := sr.RequestTime
+	return otlpSpan{
+		TraceID:      fmt.Sprintf("%x", sr.Trace.TraceID),
+		SpanID:       fmt.Sprintf("%x", sr.Trace.SpanID),
+		ParentSpanID: fmt.Sprintf("%x", parent.SpanID),
+		Name:         name,
+		Kind:         spanKindClient,
+		Start:        unixNano(start),
+		End:          unixNano(start.Add(sr.Duration)),
+		Attributes:   attrs,
+		Events:       events,
+		Status:       status,
+	}
+}
+
+func unixNano(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return fmt.Sprint(t.UnixNano())
+}
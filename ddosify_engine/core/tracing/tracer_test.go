@@ -0,0 +1,199 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"go.ddosify.com/ddosify/core/types"
+)
+
+func TestTraceContext(t *testing.T) {
+	root := NewTraceContext()
+	if !root.IsValid() || (types.TraceContext{}).IsValid() {
+		t.Errorf("Unexpected validity of the trace contexts")
+	}
+
+	child := ChildContext(root)
+	if child.TraceID != root.TraceID || child.SpanID == root.SpanID {
+		t.Errorf("Child should be in the trace of the parent with a new span id: %v, %v", root, child)
+	}
+
+	if !regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`).MatchString(child.Traceparent()) {
+		t.Errorf("Invalid traceparent: %s", child.Traceparent())
+	}
+}
+
+func tracedTestResult(start time.Time) *types.ScenarioResult {
+	scr := &types.ScenarioResult{StartTime: start.Add(-time.Second), Trace: NewTraceContext()}
+	scr.StepResults = []*types.ScenarioStepResult{
+		{
+			StepID:      1,
+			StepName:    "login",
+			Method:      http.MethodPost,
+			Url:         "https://test.com/login",
+			StatusCode:  200,
+			RequestTime: start,
+			Duration:    100 * time.Millisecond,
+			Trace:       ChildContext(scr.Trace),
+			TraceEvents: []types.TraceEvent{
+				{Name: "dns_start", Time: start},
+				{Name: "got_first_response_byte", Time: start.Add(90 * time.Millisecond)},
+			},
+		},
+		{
+			StepID:      2,
+			StatusCode:  500,
+			RequestTime: start.Add(200 * time.Millisecond),
+			Duration:    300 * time.Millisecond,
+			Trace:       ChildContext(scr.Trace),
+			Err:         types.RequestError{Type: types.ErrorConn, Reason: types.ReasonConnTimeout},
+		},
+	}
+	return scr
+}
+
+type testCollector struct {
+	mu       sync.Mutex
+	requests []otlpRequest
+	headers  []http.Header
+	status   int
+}
+
+func (c *testCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r.URL.Path != tracesPath {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var req otlpRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, r.Header)
+	if c.status != 0 {
+		w.WriteHeader(c.status)
+	}
+}
+
+func TestTracerExport(t *testing.T) {
+	collector := &testCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	tracer := NewTracer(types.TracingConf{
+		Endpoint: server.URL + "/",
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+	})
+	tracer.Start()
+
+	start := time.Now()
+	scr := tracedTestResult(start)
+	tracer.Export(scr)
+	tracer.Export(&types.ScenarioResult{Dropped: true})
+	tracer.Export(&types.ScenarioResult{StepResults: scr.StepResults}) // not traced
+	tracer.Shutdown()
+
+	if len(collector.requests) != 1 {
+		t.Fatalf("Expected 1 export request, Found: %d", len(collector.requests))
+	}
+	if h := collector.headers[0]; h.Get("Authorization") != "Bearer token" || h.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected headers: %v", h)
+	}
+
+	rs := collector.requests[0].ResourceSpans[0]
+	if *rs.Resource.Attributes[0].Value.StringValue != defaultServiceName {
+		t.Errorf("Expected service name %s, Found: %v", defaultServiceName, rs.Resource.Attributes)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, Found: %d", len(spans))
+	}
+
+	iteration, login, second := spans[0], spans[1], spans[2]
+	traceID := fmt.Sprintf("%x", scr.Trace.TraceID)
+	if iteration.TraceID != traceID || iteration.SpanID != fmt.Sprintf("%x", scr.Trace.SpanID) ||
+		iteration.ParentSpanID != "" || iteration.Kind != spanKindInternal {
+		t.Errorf("Unexpected iteration span: %+v", iteration)
+	}
+	if iteration.Start != fmt.Sprint(start.UnixNano()) || iteration.End != fmt.Sprint(start.Add(500*time.Millisecond).UnixNano()) {
+		t.Errorf("Iteration span should cover the requests: %+v", iteration)
+	}
+	if iteration.Status.Code != statusError {
+		t.Errorf("Iteration with a failed step should be errored: %+v", iteration.Status)
+	}
+
+	for _, s := range []otlpSpan{login, second} {
+		if s.TraceID != traceID || s.ParentSpanID != iteration.SpanID || s.Kind != spanKindClient {
+			t.Errorf("Step span should be a child of the iteration span: %+v", s)
+		}
+	}
+	if login.Name != "login" || login.Status.Code != statusOk || len(login.Events) != 2 ||
+		login.Events[1].Name != "got_first_response_byte" {
+		t.Errorf("Unexpected login span: %+v", login)
+	}
+	attrs := map[string]otlpValue{}
+	for _, a := range login.Attributes {
+		attrs[a.Key] = a.Value
+	}
+	if *attrs["http.request.method"].StringValue != http.MethodPost || *attrs["http.response.status_code"].IntValue != "200" {
+		t.Errorf("Unexpected login span attributes: %v", login.Attributes)
+	}
+	if second.Name != "step 2" || second.Status.Code != statusError || second.Status.Message != types.ReasonConnTimeout {
+		t.Errorf("Unexpected second span: %+v", second)
+	}
+}
+
+func TestTracerExportError(t *testing.T) {
+	collector := &testCollector{status: http.StatusUnauthorized}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	tracer := NewTracer(types.TracingConf{Endpoint: server.URL + tracesPath})
+	tracer.Start()
+	tracer.Export(tracedTestResult(time.Now()))
+	tracer.Shutdown()
+
+	if len(collector.requests) != 1 {
+		t.Fatalf("Endpoint with the traces path should be used as is, Found %d requests", len(collector.requests))
+	}
+	if tracer.err == nil {
+		t.Errorf("Export error should be kept")
+	}
+}
+
+func TestTracerDropsWhenQueueIsFull(t *testing.T) {
+	tracer := NewTracer(types.TracingConf{Endpoint: "http://localhost:4318"})
+	// not started, nothing is consumed from the queue
+	for i := 0; i < queueSize+2; i++ {
+		tracer.Export(tracedTestResult(time.Now()))
+	}
+	if tracer.dropped != 2 {
+		t.Errorf("Expected 2 dropped iterations, Found: %d", tracer.dropped)
+	}
+}
@@ -33,10 +33,13 @@ const (
 	ErrorParse          = "parseError"
 	ErrorAddr           = "addressError"
 	ErrorInvalidRequest = "invalidRequestError"
+	ErrorGraphql        = "graphqlError"
+	ErrorAuth           = "authError"
 
 	// Reasons
 	ReasonProxyFailed  = "proxy connection refused"
 	ReasonProxyTimeout = "proxy timeout"
+	ReasonProxyAuth    = "proxy authentication failed"
 	ReasonConnTimeout  = "connection timeout"
 	ReasonReadTimeout  = "read timeout"
 	ReasonConnRefused  = "connection refused"
@@ -22,7 +22,9 @@ package types
 
 import (
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"go.ddosify.com/ddosify/core/proxy"
@@ -41,6 +43,27 @@ const (
 	EngineModeRepeatedUser = "repeated-user"
 	EngineModeDdosify      = "ddosify"
 
+	// Executors
+	ExecutorBurst       = "burst"
+	ExecutorArrivalRate = "arrival-rate"
+	ExecutorVirtualUser = "virtual-user"
+
+	// Arrival distributions of the arrival-rate executor
+	ArrivalDistributionUniform = "uniform"
+	ArrivalDistributionPoisson = "poisson"
+
+	// Write modes of the influxdb output
+	InfluxdbModeRaw       = "raw"
+	InfluxdbModeAggregate = "aggregate"
+
+	// File formats of the export output
+	ExportFormatNdjson = "ndjson"
+	ExportFormatCsv    = "csv"
+
+	// Rotations of the source IPs
+	SourceIPRotationIteration = "iteration"
+	SourceIPRotationClient    = "client"
+
 	// Default Values
 	DefaultIterCount     = 100
 	DefaultLoadType      = LoadTypeLinear
@@ -54,6 +77,11 @@ const (
 
 var loadTypes = [...]string{LoadTypeLinear, LoadTypeIncremental, LoadTypeWaved}
 var engineModes = [...]string{EngineModeDdosify, EngineModeDistinctUser, EngineModeRepeatedUser}
+var executors = [...]string{ExecutorBurst, ExecutorArrivalRate, ExecutorVirtualUser}
+var arrivalDistributions = [...]string{ArrivalDistributionUniform, ArrivalDistributionPoisson}
+var influxdbModes = [...]string{InfluxdbModeRaw, InfluxdbModeAggregate}
+var exportFormats = [...]string{ExportFormatNdjson, ExportFormatCsv}
+var sourceIPRotations = [...]string{SourceIPRotationIteration, SourceIPRotationClient}
 
 type TestAssertionOpt struct {
 	Abort bool
@@ -66,6 +94,21 @@ type TimeRunCount []struct {
 	Count    int
 }
 
+// LoadStages is the data structure to store multi-stage load profile data.
+// Each stage runs with its own load type and target rate (iterations per second) for its duration.
+type LoadStages []struct {
+	Duration int
+	LoadType string
+	Rate     int
+}
+
+// VUStages is the data structure to store ramp-up/ramp-down stages of the virtual-user executor.
+// Each stage linearly changes the virtual user count from the previous stage's target to its own target.
+type VUStages []struct {
+	Duration int
+	Target   int
+}
+
 type Tag struct {
 	Tag  string `json:"tag"`
 	Type string `json:"type"`
@@ -94,6 +137,92 @@ type CustomCookie struct {
 	Raw      string `json:"raw"`
 }
 
+// PrometheusConf is the data structure to store the options of the prometheus output.
+type PrometheusConf struct {
+	// Listen address of the /metrics endpoint. Metrics are not served if it is empty.
+	ListenAddr string
+
+	// Remote-write endpoint of the Prometheus server. Metrics are not pushed if it is empty.
+	RemoteWriteURL string
+
+	// Push interval in seconds for RemoteWriteURL.
+	PushInterval int
+}
+
+// InfluxdbConf is the data structure to store the options of the influxdb output.
+type InfluxdbConf struct {
+	// Address of the InfluxDB v2 server, like http://localhost:8086
+	URL string
+
+	Org    string
+	Bucket string
+
+	// API token. INFLUXDB_TOKEN environment variable is used if it is empty.
+	Token string
+
+	// InfluxdbModeRaw writes each step result, InfluxdbModeAggregate writes the aggregates of the step results
+	// in every FlushInterval. Empty means InfluxdbModeRaw.
+	Mode string
+
+	// Write interval in seconds.
+	FlushInterval int
+}
+
+// HtmlConf is the data structure to store the options of the html output.
+type HtmlConf struct {
+	// Path of the report file. A file named with the test start time is created in the working directory if it is empty.
+	Path string
+}
+
+// JunitConf is the data structure to store the options of the junit output.
+type JunitConf struct {
+	// Path of the report file. ddosify_junit.xml in the working directory if it is empty.
+	Path string
+}
+
+// ExportConf is the data structure to store the options of the export output.
+type ExportConf struct {
+	// Path of the results file. A file named with the test start time is created in the working directory if it is empty.
+	Path string
+
+	// ExportFormatNdjson or ExportFormatCsv. Empty means ExportFormatCsv if the Path ends with .csv, ExportFormatNdjson otherwise.
+	Format string
+
+	// Request and response bodies are written to the file if it is true.
+	CaptureBody bool
+}
+
+// StdoutJsonConf is the data structure to store the options of the stdout-json output.
+type StdoutJsonConf struct {
+	// Interval of the time-series buckets of the results in seconds. Buckets are not reported if it is zero.
+	BucketInterval int
+}
+
+// TracingConf is the data structure to store the OpenTelemetry tracing options.
+type TracingConf struct {
+	// OTLP/HTTP endpoint of the collector, like http://localhost:4318. Tracing is disabled if it is empty.
+	Endpoint string
+
+	// service.name of the spans. Empty means "ddosify".
+	ServiceName string
+
+	// Headers of the export requests, like the authorization header of the tracing backend.
+	Headers map[string]string
+}
+
+// SourceAddrConf is the data structure to store the local addresses that the outgoing connections are bound to.
+type SourceAddrConf struct {
+	// Local IPs to bind the connections to.
+	IPs []string
+
+	// Network interface whose IPs are appended to IPs.
+	Interface string
+
+	// Rotation of the IPs. Each iteration uses the next IP in the iteration rotation. Each client keeps
+	// its IP for all of its iterations in the client rotation of the user engine modes.
+	Rotation string
+}
+
 // Hammer is like a lighter for the engine.
 // It includes attack metadata and all necessary data to initialize the internal services in the engine.
 type Hammer struct {
@@ -109,6 +238,9 @@ type Hammer struct {
 	// Duration (in second) - Request count map. Example: {10: 1500, 50: 400, ...}
 	TimeRunCountMap TimeRunCount
 
+	// Multi-stage load profile. Overrides LoadType, IterationCount and TestDuration if given.
+	LoadStages LoadStages
+
 	// Test Scenario
 	Scenario Scenario
 
@@ -118,6 +250,30 @@ type Hammer struct {
 	// Destination of the results data.
 	ReportDestination string
 
+	// Options of the stdout-json output.
+	StdoutJson StdoutJsonConf
+
+	// Options of the prometheus output.
+	Prometheus PrometheusConf
+
+	// Options of the influxdb output.
+	Influxdb InfluxdbConf
+
+	// Options of the html output.
+	Html HtmlConf
+
+	// Options of the junit output.
+	Junit JunitConf
+
+	// Options of the export output.
+	Export ExportConf
+
+	// OpenTelemetry tracing of the iterations.
+	Tracing TracingConf
+
+	// Local addresses of the outgoing connections of the http steps.
+	SourceAddr SourceAddrConf
+
 	// Dynamic field for extra parameters.
 	Others map[string]interface{}
 
@@ -127,6 +283,9 @@ type Hammer struct {
 	// Sampling rate
 	SamplingRate int
 
+	// Significant digits of the latency histograms. Zero means util.DefaultHistogramPrecision.
+	HistogramPrecision int
+
 	// Connection reuse
 	EngineMode string
 
@@ -144,6 +303,27 @@ type Hammer struct {
 
 	// Engine runs single
 	SingleMode bool
+
+	// Iteration scheduling model. Empty means ExecutorBurst.
+	Executor string
+
+	// Distribution of the iteration start times in a tick for ExecutorArrivalRate. Empty means uniform.
+	ArrivalDistribution string
+
+	// Maximum concurrent iterations for ExecutorArrivalRate. Zero means unlimited.
+	MaxVUs int
+
+	// Virtual user count for ExecutorVirtualUser. It is the starting count if VUStages is given.
+	VUs int
+
+	// Ramp stages of the virtual user count for ExecutorVirtualUser.
+	VUStages VUStages
+
+	// Iteration count per tick, planned by the distributed controller for its agents. Overrides the load settings.
+	ReqCountArr []int
+
+	// Virtual user count per tick for ExecutorVirtualUser, planned by the distributed controller for its agents.
+	VUCountArr []int
 }
 
 // Validate validates attack metadata and executes the validation methods of the services.
@@ -165,6 +345,21 @@ func (h *Hammer) Validate() error {
 		return fmt.Errorf("unsupported EngineMode: %s", h.EngineMode)
 	}
 
+	if h.Executor != "" && !util.StringInSlice(h.Executor, executors[:]) {
+		return fmt.Errorf("unsupported Executor: %s", h.Executor)
+	}
+	if h.ArrivalDistribution != "" && !util.StringInSlice(h.ArrivalDistribution, arrivalDistributions[:]) {
+		return fmt.Errorf("unsupported ArrivalDistribution: %s", h.ArrivalDistribution)
+	}
+	if h.MaxVUs < 0 {
+		return fmt.Errorf("max_vus should be greater than or equal to 0")
+	}
+	if h.Executor == ExecutorVirtualUser {
+		if err := h.validateVUs(); err != nil {
+			return err
+		}
+	}
+
 	if len(h.TimeRunCountMap) > 0 {
 		for _, t := range h.TimeRunCountMap {
 			if t.Duration < 1 {
@@ -173,6 +368,164 @@ func (h *Hammer) Validate() error {
 		}
 	}
 
+	if len(h.LoadStages) > 0 {
+		if err := h.validateLoadStages(); err != nil {
+			return err
+		}
+	}
+
+	if h.HistogramPrecision < 0 || h.HistogramPrecision > util.MaxHistogramPrecision {
+		return fmt.Errorf("histogram_precision should be in range [0, %d], 0 means the default precision",
+			util.MaxHistogramPrecision)
+	}
+
+	if h.StdoutJson.BucketInterval < 0 {
+		return fmt.Errorf("bucket_interval of stdout_json should be greater than or equal to 0")
+	}
+
+	if err := h.Prometheus.validate(); err != nil {
+		return err
+	}
+	if err := h.Influxdb.validate(); err != nil {
+		return err
+	}
+	if h.Export.Format != "" && !util.StringInSlice(h.Export.Format, exportFormats[:]) {
+		return fmt.Errorf("unsupported format of export: %s", h.Export.Format)
+	}
+	if err := h.Tracing.validate(); err != nil {
+		return err
+	}
+	if err := h.SourceAddr.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (i *InfluxdbConf) validate() error {
+	if i.URL != "" {
+		if u, err := url.Parse(i.URL); err != nil || u.Host == "" ||
+			!(strings.EqualFold(u.Scheme, ProtocolHTTP) || strings.EqualFold(u.Scheme, ProtocolHTTPS)) {
+			return fmt.Errorf("invalid url of influxdb: %s", i.URL)
+		}
+	}
+	if i.Mode != "" && !util.StringInSlice(i.Mode, influxdbModes[:]) {
+		return fmt.Errorf("unsupported mode of influxdb: %s", i.Mode)
+	}
+	if i.FlushInterval < 0 {
+		return fmt.Errorf("flush_interval of influxdb should be greater than or equal to 0")
+	}
+	return nil
+}
+
+func (t *TracingConf) validate() error {
+	if t.Endpoint != "" {
+		if u, err := url.Parse(t.Endpoint); err != nil || u.Host == "" ||
+			!(strings.EqualFold(u.Scheme, ProtocolHTTP) || strings.EqualFold(u.Scheme, ProtocolHTTPS)) {
+			return fmt.Errorf("invalid endpoint of tracing: %s", t.Endpoint)
+		}
+	}
+	return nil
+}
+
+func (s *SourceAddrConf) validate() error {
+	for _, ip := range s.IPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid ip of source_address: %s", ip)
+		}
+	}
+	if s.Rotation != "" && !util.StringInSlice(s.Rotation, sourceIPRotations[:]) {
+		return fmt.Errorf("unsupported rotation of source_address: %s", s.Rotation)
+	}
+	return nil
+}
+
+// LocalIPs returns the IPs and the unicast IPs of the interface. The interface is looked up on the running machine,
+// so the agents of the distributed mode use their own interfaces.
+func (s *SourceAddrConf) LocalIPs() ([]net.IP, error) {
+	ips := make([]net.IP, 0, len(s.IPs))
+	for _, ip := range s.IPs {
+		ips = append(ips, net.ParseIP(ip))
+	}
+	if s.Interface == "" {
+		return ips, nil
+	}
+
+	iface, err := net.InterfaceByName(s.Interface)
+	if err != nil {
+		return nil, fmt.Errorf("interface of source_address not found: %s", s.Interface)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("addresses of interface %s could not be read: %v", s.Interface, err)
+	}
+	ifaceIPs := 0
+	for _, addr := range addrs {
+		// link-local IPs are skipped, since they need the zone to be bound
+		if ipNet, ok := addr.(*net.IPNet); ok && (ipNet.IP.IsGlobalUnicast() || ipNet.IP.IsLoopback()) {
+			ips = append(ips, ipNet.IP)
+			ifaceIPs++
+		}
+	}
+	if ifaceIPs == 0 {
+		return nil, fmt.Errorf("interface %s has no ip", s.Interface)
+	}
+	return ips, nil
+}
+
+func (p *PrometheusConf) validate() error {
+	if p.RemoteWriteURL != "" {
+		if u, err := url.Parse(p.RemoteWriteURL); err != nil || u.Host == "" ||
+			!(strings.EqualFold(u.Scheme, ProtocolHTTP) || strings.EqualFold(u.Scheme, ProtocolHTTPS)) {
+			return fmt.Errorf("invalid remote_write_url of prometheus: %s", p.RemoteWriteURL)
+		}
+	}
+	if p.PushInterval < 0 {
+		return fmt.Errorf("push_interval of prometheus should be greater than or equal to 0")
+	}
+	return nil
+}
+
+func (h *Hammer) validateLoadStages() error {
+	if len(h.TimeRunCountMap) > 0 {
+		return fmt.Errorf("manual_load and stages can not be used together")
+	}
+	if h.Executor == ExecutorVirtualUser {
+		return fmt.Errorf("stages are not supported by the %s executor, use vu_stages instead", ExecutorVirtualUser)
+	}
+	for _, s := range h.LoadStages {
+		if !util.StringInSlice(s.LoadType, loadTypes[:]) {
+			return fmt.Errorf("unsupported LoadType in stages: %s", s.LoadType)
+		}
+		if s.Duration < 1 {
+			return fmt.Errorf("duration in stages should be greater than 0")
+		}
+		if s.Rate < 0 {
+			return fmt.Errorf("rate in stages should be greater than or equal to 0")
+		}
+	}
+	return nil
+}
+
+func (h *Hammer) validateVUs() error {
+	if h.VUs < 0 {
+		return fmt.Errorf("vus should be greater than or equal to 0")
+	}
+	if h.VUs == 0 && len(h.VUStages) == 0 {
+		return fmt.Errorf("vus or vu_stages should be given for the %s executor", ExecutorVirtualUser)
+	}
+	for _, s := range h.VUStages {
+		if s.Duration < 1 {
+			return fmt.Errorf("duration in vu_stages should be greater than 0")
+		}
+		if s.Target < 0 {
+			return fmt.Errorf("target in vu_stages should be greater than or equal to 0")
+		}
+	}
+	if h.EngineMode == EngineModeDistinctUser {
+		// a virtual user keeps its client throughout its iterations
+		return fmt.Errorf("%s engine mode is not supported by the %s executor", EngineModeDistinctUser, ExecutorVirtualUser)
+	}
 	return nil
 }
 
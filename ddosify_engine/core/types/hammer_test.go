@@ -22,6 +22,7 @@ package types
 
 import (
 	"errors"
+	"net"
 	"testing"
 
 	"go.ddosify.com/ddosify/core/proxy"
@@ -72,6 +73,7 @@ func TestHammerValidAuth(t *testing.T) {
 			Type:     v,
 			Username: "test",
 			Password: "123",
+			TokenURL: "https://test.com/oauth/token",
 		}
 
 		if err := h.Validate(); err != nil {
@@ -81,6 +83,45 @@ func TestHammerValidAuth(t *testing.T) {
 
 }
 
+func TestHammerOAuth2Auth(t *testing.T) {
+	validAuths := []Auth{
+		{Type: AuthOAuth2, TokenURL: "https://test.com/oauth/token", ClientID: "id", ClientSecret: "secret"},
+		{Type: AuthOAuth2, TokenURL: "https://test.com/oauth/token", Grant: OAuth2GrantPassword, Username: "test",
+			Password: "123", Cache: OAuth2CacheUser, RefreshBefore: 10},
+	}
+	for _, a := range validAuths {
+		h := newDummyHammer()
+		h.Scenario.Steps[0].Auth = a
+		if err := h.Validate(); err != nil {
+			t.Errorf("TestHammerOAuth2Auth %v errored: %v", a, err)
+		}
+	}
+
+	invalidAuths := []Auth{
+		{Type: AuthOAuth2, ClientID: "id"},
+		{Type: AuthOAuth2, TokenURL: "test"},
+		{Type: AuthOAuth2, TokenURL: "https://test.com/oauth/token", Grant: "implicit"},
+		{Type: AuthOAuth2, TokenURL: "https://test.com/oauth/token", Grant: OAuth2GrantPassword},
+		{Type: AuthOAuth2, TokenURL: "https://test.com/oauth/token", Cache: "iteration"},
+		{Type: AuthOAuth2, TokenURL: "https://test.com/oauth/token", RefreshBefore: -1},
+		{Type: AuthOAuth2, TokenURL: "https://test.com/oauth/token", ClientSecret: "{{NOT_DEFINED}}"},
+	}
+	for _, a := range invalidAuths {
+		h := newDummyHammer()
+		h.Scenario.Steps[0].Auth = a
+		if err := h.Validate(); err == nil {
+			t.Errorf("TestHammerOAuth2Auth %v should be errored", a)
+		}
+	}
+
+	h := newDummyHammer()
+	h.Scenario.Steps[0].URL = "ws://test.com"
+	h.Scenario.Steps[0].Auth = validAuths[0]
+	if err := h.Validate(); err == nil {
+		t.Errorf("TestHammerOAuth2Auth should be errored for websocket steps")
+	}
+}
+
 func TestHammerInValidAuth(t *testing.T) {
 	h := newDummyHammer()
 	h.Scenario.Steps[0].Auth = Auth{
@@ -323,6 +364,287 @@ func TestHammerInvalidManualLoadDuration(t *testing.T) {
 	}
 }
 
+func TestHammerExecutor(t *testing.T) {
+	tests := []struct {
+		name         string
+		executor     string
+		distribution string
+		maxVUs       int
+		shouldErr    bool
+	}{
+		{"Default", "", "", 0, false},
+		{"Burst", ExecutorBurst, "", 0, false},
+		{"ArrivalRateUniform", ExecutorArrivalRate, ArrivalDistributionUniform, 10, false},
+		{"ArrivalRatePoisson", ExecutorArrivalRate, ArrivalDistributionPoisson, 0, false},
+		{"InvalidExecutor", "closed", "", 0, true},
+		{"InvalidDistribution", ExecutorArrivalRate, "normal", 0, true},
+		{"NegativeMaxVUs", ExecutorArrivalRate, "", -1, true},
+	}
+
+	for _, test := range tests {
+		h := newDummyHammer()
+		h.Executor = test.executor
+		h.ArrivalDistribution = test.distribution
+		h.MaxVUs = test.maxVUs
+
+		err := h.Validate()
+		if test.shouldErr && err == nil {
+			t.Errorf("%s: should be errored", test.name)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("%s: error occurred %v", test.name, err)
+		}
+	}
+}
+
+func TestHammerLoadStages(t *testing.T) {
+	tests := []struct {
+		name      string
+		stages    LoadStages
+		manual    TimeRunCount
+		executor  string
+		shouldErr bool
+	}{
+		{"Valid", LoadStages{{Duration: 10, LoadType: LoadTypeIncremental, Rate: 50}, {Duration: 5, LoadType: LoadTypeWaved, Rate: 0}}, nil, "", false},
+		{"ArrivalRate", LoadStages{{Duration: 10, LoadType: LoadTypeLinear, Rate: 50}}, nil, ExecutorArrivalRate, false},
+		{"InvalidLoadType", LoadStages{{Duration: 10, LoadType: "spike", Rate: 50}}, nil, "", true},
+		{"ZeroDuration", LoadStages{{Duration: 0, LoadType: LoadTypeLinear, Rate: 50}}, nil, "", true},
+		{"NegativeRate", LoadStages{{Duration: 10, LoadType: LoadTypeLinear, Rate: -1}}, nil, "", true},
+		{"WithManualLoad", LoadStages{{Duration: 10, LoadType: LoadTypeLinear, Rate: 50}}, TimeRunCount{{Duration: 1, Count: 1}}, "", true},
+		{"VirtualUser", LoadStages{{Duration: 10, LoadType: LoadTypeLinear, Rate: 50}}, nil, ExecutorVirtualUser, true},
+	}
+
+	for _, test := range tests {
+		h := newDummyHammer()
+		h.LoadStages = test.stages
+		h.TimeRunCountMap = test.manual
+		h.Executor = test.executor
+		h.VUs = 1
+
+		err := h.Validate()
+		if test.shouldErr && err == nil {
+			t.Errorf("%s: should be errored", test.name)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("%s: error occurred %v", test.name, err)
+		}
+	}
+}
+
+func TestHammerVirtualUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		vus        int
+		stages     VUStages
+		engineMode string
+		shouldErr  bool
+	}{
+		{"FixedVUs", 10, nil, "", false},
+		{"Stages", 0, VUStages{{Duration: 10, Target: 100}, {Duration: 5, Target: 0}}, EngineModeRepeatedUser, false},
+		{"NoVUs", 0, nil, "", true},
+		{"NegativeVUs", -1, nil, "", true},
+		{"ZeroStageDuration", 0, VUStages{{Duration: 0, Target: 100}}, "", true},
+		{"NegativeStageTarget", 0, VUStages{{Duration: 10, Target: -1}}, "", true},
+		{"DistinctUser", 10, nil, EngineModeDistinctUser, true},
+	}
+
+	for _, test := range tests {
+		h := newDummyHammer()
+		h.Executor = ExecutorVirtualUser
+		h.VUs = test.vus
+		h.VUStages = test.stages
+		h.EngineMode = test.engineMode
+
+		err := h.Validate()
+		if test.shouldErr && err == nil {
+			t.Errorf("%s: should be errored", test.name)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("%s: error occurred %v", test.name, err)
+		}
+	}
+}
+
+func TestHammerPrometheus(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      PrometheusConf
+		shouldErr bool
+	}{
+		{"Empty", PrometheusConf{}, false},
+		{"Valid", PrometheusConf{ListenAddr: ":9901", RemoteWriteURL: "http://localhost:9090/api/v1/write", PushInterval: 5}, false},
+		{"InvalidScheme", PrometheusConf{RemoteWriteURL: "ftp://localhost:9090/api/v1/write"}, true},
+		{"NoHost", PrometheusConf{RemoteWriteURL: "/api/v1/write"}, true},
+		{"NegativePushInterval", PrometheusConf{PushInterval: -1}, true},
+	}
+
+	for _, test := range tests {
+		h := newDummyHammer()
+		h.Prometheus = test.conf
+
+		err := h.Validate()
+		if test.shouldErr && err == nil {
+			t.Errorf("%s: should be errored", test.name)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("%s: error occurred %v", test.name, err)
+		}
+	}
+}
+
+func TestHammerInfluxdb(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      InfluxdbConf
+		shouldErr bool
+	}{
+		{"Empty", InfluxdbConf{}, false},
+		{"Valid", InfluxdbConf{URL: "http://localhost:8086", Org: "ddosify", Bucket: "hammerBucket", Mode: InfluxdbModeAggregate}, false},
+		{"InvalidUrl", InfluxdbConf{URL: "localhost:8086"}, true},
+		{"UnsupportedMode", InfluxdbConf{URL: "http://localhost:8086", Mode: "batch"}, true},
+		{"NegativeFlushInterval", InfluxdbConf{URL: "http://localhost:8086", FlushInterval: -1}, true},
+	}
+
+	for _, test := range tests {
+		h := newDummyHammer()
+		h.Influxdb = test.conf
+
+		err := h.Validate()
+		if test.shouldErr && err == nil {
+			t.Errorf("%s: should be errored", test.name)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("%s: error occurred %v", test.name, err)
+		}
+	}
+}
+
+func TestHammerHistogramPrecision(t *testing.T) {
+	tests := []struct {
+		precision int
+		shouldErr bool
+	}{
+		{0, false},
+		{1, false},
+		{5, false},
+		{-1, true},
+		{6, true},
+	}
+
+	for _, test := range tests {
+		h := newDummyHammer()
+		h.HistogramPrecision = test.precision
+
+		err := h.Validate()
+		if test.shouldErr && err == nil {
+			t.Errorf("precision %d: should be errored", test.precision)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("precision %d: error occurred %v", test.precision, err)
+		}
+	}
+}
+
+func TestHammerStdoutJsonBucketInterval(t *testing.T) {
+	h := newDummyHammer()
+	h.StdoutJson = StdoutJsonConf{BucketInterval: 10}
+	if err := h.Validate(); err != nil {
+		t.Errorf("TestHammerStdoutJsonBucketInterval error occurred %v", err)
+	}
+
+	h.StdoutJson = StdoutJsonConf{BucketInterval: -1}
+	if err := h.Validate(); err == nil {
+		t.Errorf("TestHammerStdoutJsonBucketInterval negative interval should be errored")
+	}
+}
+
+func TestHammerExportFormat(t *testing.T) {
+	for _, format := range []string{"", ExportFormatNdjson, ExportFormatCsv} {
+		h := newDummyHammer()
+		h.Export = ExportConf{Format: format}
+		if err := h.Validate(); err != nil {
+			t.Errorf("TestHammerExportFormat %q error occurred %v", format, err)
+		}
+	}
+
+	h := newDummyHammer()
+	h.Export = ExportConf{Format: "xlsx"}
+	if err := h.Validate(); err == nil {
+		t.Errorf("TestHammerExportFormat unsupported format should be errored")
+	}
+}
+
+func TestHammerTracingEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"", "http://localhost:4318", "https://otlp.test.com/v1/traces"} {
+		h := newDummyHammer()
+		h.Tracing = TracingConf{Endpoint: endpoint}
+		if err := h.Validate(); err != nil {
+			t.Errorf("TestHammerTracingEndpoint %q error occurred %v", endpoint, err)
+		}
+	}
+
+	for _, endpoint := range []string{"localhost:4318", "grpc://localhost:4317"} {
+		h := newDummyHammer()
+		h.Tracing = TracingConf{Endpoint: endpoint}
+		if err := h.Validate(); err == nil {
+			t.Errorf("TestHammerTracingEndpoint %q should be errored", endpoint)
+		}
+	}
+}
+
+func TestHammerSourceAddress(t *testing.T) {
+	validConfs := []SourceAddrConf{
+		{},
+		{IPs: []string{"127.0.0.1", "::1"}},
+		{IPs: []string{"127.0.0.1"}, Rotation: SourceIPRotationClient},
+		{Interface: "lo", Rotation: SourceIPRotationIteration},
+	}
+	for _, conf := range validConfs {
+		h := newDummyHammer()
+		h.SourceAddr = conf
+		if err := h.Validate(); err != nil {
+			t.Errorf("TestHammerSourceAddress %v error occurred %v", conf, err)
+		}
+	}
+
+	invalidConfs := []SourceAddrConf{
+		{IPs: []string{"127.0.0.1:80"}},
+		{IPs: []string{"localhost"}},
+		{IPs: []string{"127.0.0.1"}, Rotation: "random"},
+	}
+	for _, conf := range invalidConfs {
+		h := newDummyHammer()
+		h.SourceAddr = conf
+		if err := h.Validate(); err == nil {
+			t.Errorf("TestHammerSourceAddress %v should be errored", conf)
+		}
+	}
+}
+
+func TestSourceAddressLocalIPs(t *testing.T) {
+	conf := SourceAddrConf{IPs: []string{"10.0.0.1"}, Interface: "lo"}
+	ips, err := conf.LocalIPs()
+	if err != nil {
+		t.Skipf("loopback interface lo is not available: %v", err)
+	}
+
+	if !ips[0].Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("Expected the ips first, Found: %v", ips)
+	}
+	hasLoopback := false
+	for _, ip := range ips[1:] {
+		hasLoopback = hasLoopback || ip.IsLoopback()
+	}
+	if !hasLoopback {
+		t.Errorf("Expected the loopback ip of lo, Found: %v", ips)
+	}
+
+	conf = SourceAddrConf{Interface: "not-existing-interface"}
+	if _, err = conf.LocalIPs(); err == nil {
+		t.Errorf("TestSourceAddressLocalIPs should be errored for not existing interface")
+	}
+}
+
 func TestHammerAccessingNotDefinedCsvEnvs(t *testing.T) {
 	h := newDummyHammer()
 	h.TestDataConf = make(map[string]CsvConf)
@@ -21,6 +21,7 @@
 package types
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 	"time"
@@ -36,8 +37,14 @@ type ScenarioResult struct {
 	ProxyAddr   *url.URL
 	StepResults []*ScenarioStepResult
 
+	// Iteration is not started since the executor has no free virtual user for it.
+	Dropped bool
+
 	// Dynamic field for extra data needs in response object consumers.
 	Others map[string]interface{}
+
+	// Span of the iteration, zero if the iteration is not traced.
+	Trace TraceContext
 }
 
 // ScenarioStepResult is corresponding to ScenarioStep.
@@ -98,4 +105,32 @@ type ScenarioStepResult struct {
 
 	// Failed assertion rules and received values
 	FailedAssertions []FailedAssertion
+
+	// Span of the step, its parent is the span of the iteration. Zero if the iteration is not traced.
+	Trace TraceContext
+
+	// Phases of the request, like dns_start and got_first_response_byte, recorded if the step is traced.
+	TraceEvents []TraceEvent
+}
+
+// TraceContext is the W3C trace context of a span.
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid returns false for the zero TraceContext.
+func (t TraceContext) IsValid() bool {
+	return t.TraceID != [16]byte{} && t.SpanID != [8]byte{}
+}
+
+// Traceparent returns the value of the W3C traceparent header of the span, sampled flag is always set.
+func (t TraceContext) Traceparent() string {
+	return fmt.Sprintf("00-%x-%x-01", t.TraceID, t.SpanID)
+}
+
+// TraceEvent is a phase of a request recorded as an event of its span.
+type TraceEvent struct {
+	Name string
+	Time time.Time
 }
@@ -23,8 +23,10 @@ package types
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"regexp"
@@ -40,9 +42,36 @@ const (
 	// Constants of the Protocol types
 	ProtocolHTTP  = "HTTP"
 	ProtocolHTTPS = "HTTPS"
+	ProtocolGRPC  = "GRPC"
+	ProtocolGRPCS = "GRPCS"
+	ProtocolWS    = "WS"
+	ProtocolWSS   = "WSS"
+	ProtocolTCP   = "TCP"
+	ProtocolUDP   = "UDP"
+
+	// Constants of the WebSocket action types
+	WsActionSend   = "send"
+	WsActionExpect = "expect"
+	WsActionClose  = "close"
+
+	// Constants of the raw socket payload formats
+	RawFormatText = "text"
+	RawFormatHex  = "hex"
 
 	// Constants of the Auth types
 	AuthHttpBasic = "basic"
+	AuthOAuth2    = "oauth2"
+
+	// Constants of the oauth2 grants
+	OAuth2GrantClientCredentials = "client_credentials"
+	OAuth2GrantPassword          = "password"
+
+	// Constants of the oauth2 token caches
+	OAuth2CacheGlobal = "global"
+	OAuth2CacheUser   = "user"
+
+	// Default seconds before the expiry of an oauth2 token to refresh it
+	DefaultOAuth2RefreshBefore = 30
 
 	// Max sleep in ms (90s)
 	maxSleep = 90000
@@ -55,14 +84,20 @@ const (
 )
 
 // SupportedProtocols should be updated whenever a new requester.Requester interface implemented
-var SupportedProtocols = [...]string{ProtocolHTTP, ProtocolHTTPS}
+var SupportedProtocols = [...]string{ProtocolHTTP, ProtocolHTTPS, ProtocolGRPC, ProtocolGRPCS, ProtocolWS, ProtocolWSS,
+	ProtocolTCP, ProtocolUDP}
+var wsActionTypes = []string{WsActionSend, WsActionExpect, WsActionClose}
+var rawFormats = []string{RawFormatText, RawFormatHex}
 var supportedProtocolMethods = []string{
 	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete,
 	http.MethodPatch, http.MethodHead, http.MethodOptions,
 }
 var supportedAuthentications = []string{
 	AuthHttpBasic,
+	AuthOAuth2,
 }
+var oauth2Grants = []string{OAuth2GrantClientCredentials, OAuth2GrantPassword}
+var oauth2Caches = []string{OAuth2CacheGlobal, OAuth2CacheUser}
 
 var envVarRegexp *regexp.Regexp
 var envVarNameRegexp *regexp.Regexp
@@ -185,7 +220,27 @@ func checkEnvsValidInStep(st *ScenarioStep, definedEnvs map[string]struct{}) err
 
 	// check env usage in payload
 	err = f(st.Payload)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// check env usage in oauth2 auth
+	if st.Auth.IsOAuth2() {
+		for _, v := range []string{st.Auth.TokenURL, st.Auth.ClientID, st.Auth.ClientSecret, st.Auth.Scope,
+			st.Auth.Username, st.Auth.Password} {
+			if err = f(v); err != nil {
+				return err
+			}
+		}
+	}
+
+	// check env usage in websocket messages
+	for _, a := range st.Ws.Actions {
+		if err = f(a.Payload); err != nil {
+			return err
+		}
+	}
+	return nil
 
 }
 
@@ -233,6 +288,141 @@ type ScenarioStep struct {
 
 	// assertion expressions
 	Assertions []string
+
+	// gRPC method of the step if the URL scheme is grpc or grpcs
+	Grpc GrpcConf
+
+	// Actions to run on the socket if the URL scheme is ws or wss
+	Ws WsConf
+
+	// Read options of the socket if the URL scheme is tcp or udp
+	Raw RawConf
+
+	// GraphQL operation of the step. Payload of the step is built from it if the Query is given.
+	Graphql GraphqlConf
+
+	// Streaming options of the step if the response is a text/event-stream
+	Sse SseConf
+
+	// Response body is kept in the step result even if the step does not need it, to be exported by the outputs.
+	CaptureBody bool
+
+	// Local IP that the connections of the step are bound to. Nil means the IP is chosen by the OS.
+	SourceIP net.IP
+}
+
+// SseConf includes the streaming options of a Server-Sent Events step. The stream is read until EventCount events
+// are received, Duration seconds pass or the server closes the stream, whichever comes first.
+type SseConf struct {
+	Duration   int
+	EventCount int
+}
+
+// IsSse returns true if the response of the step is read as a Server-Sent Events stream.
+func (si *ScenarioStep) IsSse() bool {
+	return si.Sse.Duration > 0 || si.Sse.EventCount > 0
+}
+
+// GraphqlConf includes the operation of a GraphQL step.
+type GraphqlConf struct {
+	Query         string
+	Variables     map[string]interface{}
+	OperationName string
+}
+
+// Payload returns the JSON request body of the operation.
+func (g *GraphqlConf) Payload() (string, error) {
+	b, err := json.Marshal(struct {
+		Query         string                 `json:"query"`
+		Variables     map[string]interface{} `json:"variables,omitempty"`
+		OperationName string                 `json:"operationName,omitempty"`
+	}{g.Query, g.Variables, g.OperationName})
+	return string(b), err
+}
+
+// IsGraphql returns true if the step is a GraphQL operation.
+func (si *ScenarioStep) IsGraphql() bool {
+	return si.Graphql.Query != ""
+}
+
+// GrpcConf includes the method information of a gRPC step. Payload of the step is the JSON form of the request
+// message and Headers are sent as metadata.
+type GrpcConf struct {
+	// Full method name, package.Service/Method
+	Method string
+
+	// Proto file that defines the method. Server reflection is used if not given.
+	ProtoFile string
+
+	// Directories to search for the imports of the ProtoFile
+	ImportPaths []string
+}
+
+// WsConf includes the actions of a WebSocket step. The socket is opened at the beginning of the step with the
+// Headers of the step, the actions are run in order and the socket is closed at the end of the step.
+type WsConf struct {
+	Actions []WsAction
+}
+
+// WsAction is one action of a WebSocket step.
+type WsAction struct {
+	// One of send, expect and close
+	Type string
+
+	// Text message to send for the send action
+	Payload string
+
+	// The expect action waits for a message that has a value at JsonPath, equal to Equals if it is given.
+	JsonPath string
+	Equals   string
+
+	// The expect action waits for a message that matches Regex
+	Regex string
+
+	// Max wait duration in seconds for the expect action. Timeout of the step is used if not given.
+	Timeout int
+}
+
+// RawConf includes the options of a TCP or UDP step. Payload of the step is sent to the socket as is, or
+// decoded from hex if Format is hex. After sending, the response is read until Delimiter or ReadBytes is
+// received, if neither is given it is read for ReadTimeout seconds. Nothing is read if none of them is given.
+type RawConf struct {
+	// One of text and hex, text by default. Received bytes are hex encoded if it is hex.
+	Format string
+
+	Delimiter   string
+	ReadBytes   int
+	ReadTimeout int
+}
+
+// Protocol returns the protocol of the step by the scheme of its URL. Defaults to HTTP.
+func (si *ScenarioStep) Protocol() string {
+	return protocolOf(si.URL)
+}
+
+func protocolOf(url string) string {
+	scheme := url
+	if i := strings.Index(url, "://"); i >= 0 {
+		scheme = url[:i]
+	}
+
+	switch strings.ToUpper(scheme) {
+	case ProtocolGRPC:
+		return ProtocolGRPC
+	case ProtocolGRPCS:
+		return ProtocolGRPCS
+	case ProtocolWS:
+		return ProtocolWS
+	case ProtocolWSS:
+		return ProtocolWSS
+	case ProtocolTCP:
+		return ProtocolTCP
+	case ProtocolUDP:
+		return ProtocolUDP
+	case ProtocolHTTPS:
+		return ProtocolHTTPS
+	}
+	return ProtocolHTTP
 }
 
 type SourceType string
@@ -257,6 +447,9 @@ type EnvCaptureConf struct {
 	From       SourceType        `json:"from"`
 	Key        *string           `json:"header_key"`
 	CookieName *string           `json:"cookie_name"`
+
+	// For SSE steps, body is the data of the last event of this type. Last event of any type if empty.
+	Event string `json:"event"`
 }
 
 type CsvData struct {
@@ -269,6 +462,53 @@ type Auth struct {
 	Type     string
 	Username string
 	Password string
+
+	// Token endpoint and client credentials of the oauth2 authentication.
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+
+	// Grant of the oauth2 token requests. Username and Password are the resource owner credentials of the password
+	// grant.
+	Grant string
+
+	// Cache of the oauth2 tokens. Tokens are shared by all the iterations in the global cache, each client of the
+	// user engine modes gets its own token in the user cache.
+	Cache string
+
+	// Seconds before the expiry of an oauth2 token to refresh it.
+	RefreshBefore int
+}
+
+// IsOAuth2 reports whether the bearer token of the requests is fetched from an oauth2 token endpoint.
+func (a Auth) IsOAuth2() bool {
+	return a.Type == AuthOAuth2
+}
+
+func (a *Auth) validate() error {
+	if !a.IsOAuth2() {
+		return nil
+	}
+	if a.TokenURL == "" {
+		return fmt.Errorf("token_url of the oauth2 auth is required")
+	}
+	if err := IsTargetValid(a.TokenURL); err != nil {
+		return fmt.Errorf("token_url of the oauth2 auth is not valid: %s", a.TokenURL)
+	}
+	if a.Grant != "" && !util.StringInSlice(a.Grant, oauth2Grants) {
+		return fmt.Errorf("unsupported grant of the oauth2 auth: %s", a.Grant)
+	}
+	if a.Grant == OAuth2GrantPassword && a.Username == "" {
+		return fmt.Errorf("username of the oauth2 auth is required for the password grant")
+	}
+	if a.Cache != "" && !util.StringInSlice(a.Cache, oauth2Caches) {
+		return fmt.Errorf("unsupported cache of the oauth2 auth: %s", a.Cache)
+	}
+	if a.RefreshBefore < 0 {
+		return fmt.Errorf("refresh_before of the oauth2 auth should be greater than or equal to 0")
+	}
+	return nil
 }
 
 func (si *ScenarioStep) validate(definedEnvs map[string]struct{}) error {
@@ -278,11 +518,43 @@ func (si *ScenarioStep) validate(definedEnvs map[string]struct{}) error {
 	if si.Auth != (Auth{}) && !util.StringInSlice(si.Auth.Type, supportedAuthentications) {
 		return fmt.Errorf("unsupported Authentication Method (%s) ", si.Auth.Type)
 	}
+	if err := si.Auth.validate(); err != nil {
+		return err
+	}
+	if h3, _ := si.Custom["h3"].(bool); si.Auth.IsOAuth2() &&
+		(si.Protocol() != ProtocolHTTP && si.Protocol() != ProtocolHTTPS || h3) {
+		return fmt.Errorf("oauth2 auth can only be used with http steps")
+	}
 	if si.ID == 0 {
 		return fmt.Errorf("step ID should be greater than zero")
 	}
-	if !envVarRegexp.MatchString(si.URL) && !validator.IsURL(strings.ReplaceAll(si.URL, " ", "_")) {
-		return fmt.Errorf("target is not valid: %s", si.URL)
+	if err := IsTargetValid(si.URL); err != nil {
+		return err
+	}
+	if h3, ok := si.Custom["h3"].(bool); ok && h3 {
+		if si.Protocol() != ProtocolHTTPS {
+			return fmt.Errorf("h3 can only be used with https targets")
+		}
+		if h2, ok := si.Custom["h2"].(bool); ok && h2 {
+			return fmt.Errorf("h2 and h3 can not be used together")
+		}
+	}
+	if si.Sse.Duration < 0 || si.Sse.EventCount < 0 {
+		return fmt.Errorf("duration and event_count of sse should be greater than or equal to 0")
+	}
+	switch si.Protocol() {
+	case ProtocolGRPC, ProtocolGRPCS:
+		if err := si.Grpc.validate(); err != nil {
+			return err
+		}
+	case ProtocolWS, ProtocolWSS:
+		if err := si.Ws.validate(); err != nil {
+			return err
+		}
+	case ProtocolTCP, ProtocolUDP:
+		if err := si.Raw.validate(); err != nil {
+			return err
+		}
 	}
 	if si.Sleep != "" {
 		sleep := strings.Split(si.Sleep, "-")
@@ -372,8 +644,61 @@ func ParseTLS(certFile, keyFile string) (tls.Certificate, *x509.CertPool, error)
 }
 
 func IsTargetValid(url string) error {
-	if !envVarRegexp.MatchString(url) && !validator.IsURL(strings.ReplaceAll(url, " ", "_")) {
+	target := url
+	switch protocolOf(url) {
+	case ProtocolGRPC, ProtocolGRPCS, ProtocolTCP, ProtocolUDP:
+		// validator doesn't know the grpc and raw socket schemes, validate the address part
+		i := strings.Index(url, "://")
+		if i < 0 {
+			return fmt.Errorf("target is not valid: %s", url)
+		}
+		target = "http" + url[i:]
+	}
+
+	if !envVarRegexp.MatchString(url) && !validator.IsURL(strings.ReplaceAll(target, " ", "_")) {
 		return fmt.Errorf("target is not valid: %s", url)
 	}
 	return nil
 }
+
+func (r *RawConf) validate() error {
+	if r.Format != "" && !util.StringInSlice(r.Format, rawFormats) {
+		return fmt.Errorf("unsupported payload format: %s", r.Format)
+	}
+	if r.ReadBytes < 0 {
+		return fmt.Errorf("read_bytes should be greater than or equal to 0")
+	}
+	if r.ReadTimeout < 0 {
+		return fmt.Errorf("read_timeout should be greater than or equal to 0")
+	}
+	return nil
+}
+
+func (w *WsConf) validate() error {
+	for _, a := range w.Actions {
+		if !util.StringInSlice(a.Type, wsActionTypes) {
+			return fmt.Errorf("unsupported websocket action: %s", a.Type)
+		}
+		if a.Type == WsActionExpect && a.JsonPath == "" && a.Regex == "" {
+			return fmt.Errorf("one of json_path or regex must be specified for the websocket expect action")
+		}
+		if a.Regex != "" {
+			if _, err := regexp.Compile(a.Regex); err != nil {
+				return fmt.Errorf("regex of the websocket expect action is not valid: %v", err)
+			}
+		}
+		if a.Timeout < 0 {
+			return fmt.Errorf("timeout of the websocket expect action should be greater than or equal to 0")
+		}
+	}
+	return nil
+}
+
+func (g *GrpcConf) validate() error {
+	method := strings.TrimPrefix(g.Method, "/")
+	i := strings.LastIndex(method, "/")
+	if i <= 0 || i == len(method)-1 {
+		return fmt.Errorf("grpc method should be in package.Service/Method format: %s", g.Method)
+	}
+	return nil
+}
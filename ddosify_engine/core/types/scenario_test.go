@@ -192,3 +192,219 @@ func TestScenarioStepValid_OSEnvVariableInPayload(t *testing.T) {
 
 	t.Logf("%v", environmentNotDefined)
 }
+
+func TestScenarioStepProtocol(t *testing.T) {
+	tests := []struct {
+		url      string
+		protocol string
+	}{
+		{"http://test.com", ProtocolHTTP},
+		{"https://test.com", ProtocolHTTPS},
+		{"test.com", ProtocolHTTP},
+		{"{{TARGET}}/path", ProtocolHTTP},
+		{"grpc://localhost:50051", ProtocolGRPC},
+		{"GRPCS://test.com:443", ProtocolGRPCS},
+		{"ws://localhost:8080/ws", ProtocolWS},
+		{"wss://test.com/notifications", ProtocolWSS},
+		{"tcp://localhost:6379", ProtocolTCP},
+		{"UDP://10.0.0.1:53", ProtocolUDP},
+	}
+
+	for _, test := range tests {
+		st := ScenarioStep{URL: test.url}
+		if st.Protocol() != test.protocol {
+			t.Errorf("%s: Expected %s, Found %s", test.url, test.protocol, st.Protocol())
+		}
+	}
+}
+
+func TestIsTargetValid(t *testing.T) {
+	tests := []struct {
+		url       string
+		shouldErr bool
+	}{
+		{"https://test.com", false},
+		{"test.com", false},
+		{"grpc://localhost:50051", false},
+		{"grpcs://test.com:443", false},
+		{"grpc", true},
+		{"grpcs", true},
+		{"GRPC", true},
+		{"tcp://localhost:6379", false},
+		{"udp://10.0.0.1:53", false},
+		{"tcp", true},
+		{"udp", true},
+	}
+
+	for _, test := range tests {
+		err := IsTargetValid(test.url)
+		if test.shouldErr && err == nil {
+			t.Errorf("%s: Expected error", test.url)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("%s: Unexpected error: %v", test.url, err)
+		}
+	}
+}
+
+func TestScenarioStepValid_Grpc(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		method    string
+		shouldErr bool
+	}{
+		{"Valid", "grpc://localhost:50051", "helloworld.Greeter/SayHello", false},
+		{"LeadingSlash", "grpcs://test.com:443", "/helloworld.Greeter/SayHello", false},
+		{"MissingMethod", "grpc://localhost:50051", "", true},
+		{"MissingMethodName", "grpc://localhost:50051", "helloworld.Greeter/", true},
+		{"MissingService", "grpc://localhost:50051", "SayHello", true},
+		{"InvalidTarget", "grpc://", "helloworld.Greeter/SayHello", true},
+	}
+
+	for _, test := range tests {
+		st := ScenarioStep{
+			ID:     1,
+			Method: http.MethodGet,
+			URL:    test.url,
+			Grpc:   GrpcConf{Method: test.method},
+		}
+
+		err := st.validate(map[string]struct{}{})
+		if test.shouldErr && err == nil {
+			t.Errorf("%s: should be errored", test.name)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("%s: error occurred %v", test.name, err)
+		}
+	}
+}
+
+func TestScenarioStepValid_Ws(t *testing.T) {
+	tests := []struct {
+		name      string
+		action    WsAction
+		shouldErr bool
+	}{
+		{"Send", WsAction{Type: WsActionSend, Payload: "{{TOKEN}}"}, false},
+		{"ExpectJsonPath", WsAction{Type: WsActionExpect, JsonPath: "type", Equals: "ack"}, false},
+		{"ExpectRegex", WsAction{Type: WsActionExpect, Regex: "ack.*", Timeout: 3}, false},
+		{"Close", WsAction{Type: WsActionClose}, false},
+		{"UnsupportedAction", WsAction{Type: "ping"}, true},
+		{"ExpectWithoutMatcher", WsAction{Type: WsActionExpect}, true},
+		{"InvalidRegex", WsAction{Type: WsActionExpect, Regex: "ack("}, true},
+		{"NegativeTimeout", WsAction{Type: WsActionExpect, JsonPath: "type", Timeout: -1}, true},
+		{"UndefinedEnv", WsAction{Type: WsActionSend, Payload: "{{UNDEFINED}}"}, true},
+	}
+
+	for _, test := range tests {
+		st := ScenarioStep{
+			ID:     1,
+			Method: http.MethodGet,
+			URL:    "wss://test.com/ws",
+			Ws:     WsConf{Actions: []WsAction{test.action}},
+		}
+
+		err := st.validate(map[string]struct{}{"TOKEN": {}})
+		if test.shouldErr && err == nil {
+			t.Errorf("%s: should be errored", test.name)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("%s: error occurred %v", test.name, err)
+		}
+	}
+}
+
+func TestScenarioStepValid_Raw(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		conf      RawConf
+		shouldErr bool
+	}{
+		{"Tcp", "tcp://localhost:6379", RawConf{Delimiter: "\r\n"}, false},
+		{"UdpHex", "udp://10.0.0.1:53", RawConf{Format: RawFormatHex, ReadTimeout: 2}, false},
+		{"UnsupportedFormat", "tcp://localhost:6379", RawConf{Format: "base64"}, true},
+		{"NegativeReadBytes", "tcp://localhost:6379", RawConf{ReadBytes: -1}, true},
+		{"NegativeReadTimeout", "udp://10.0.0.1:53", RawConf{ReadTimeout: -1}, true},
+		{"InvalidTarget", "tcp://", RawConf{}, true},
+	}
+
+	for _, test := range tests {
+		st := ScenarioStep{
+			ID:     1,
+			Method: http.MethodGet,
+			URL:    test.url,
+			Raw:    test.conf,
+		}
+
+		err := st.validate(map[string]struct{}{})
+		if test.shouldErr && err == nil {
+			t.Errorf("%s: should be errored", test.name)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("%s: error occurred %v", test.name, err)
+		}
+	}
+}
+
+func TestScenarioStepValid_Sse(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      SseConf
+		shouldErr bool
+	}{
+		{"Duration", SseConf{Duration: 30}, false},
+		{"EventCount", SseConf{EventCount: 10}, false},
+		{"NegativeDuration", SseConf{Duration: -1}, true},
+		{"NegativeEventCount", SseConf{EventCount: -1}, true},
+	}
+
+	for _, test := range tests {
+		st := ScenarioStep{
+			ID:     1,
+			Method: http.MethodGet,
+			URL:    "https://test.com/stream",
+			Sse:    test.conf,
+		}
+
+		err := st.validate(map[string]struct{}{})
+		if test.shouldErr && err == nil {
+			t.Errorf("%s: should be errored", test.name)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("%s: error occurred %v", test.name, err)
+		}
+	}
+}
+
+func TestScenarioStepValid_H3(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		custom    map[string]interface{}
+		shouldErr bool
+	}{
+		{"H3", "https://test.com", map[string]interface{}{"h3": true}, false},
+		{"H3Disabled", "http://test.com", map[string]interface{}{"h3": false}, false},
+		{"H3WithHttp", "http://test.com", map[string]interface{}{"h3": true}, true},
+		{"H3WithH2", "https://test.com", map[string]interface{}{"h2": true, "h3": true}, true},
+	}
+
+	for _, test := range tests {
+		st := ScenarioStep{
+			ID:     1,
+			Method: http.MethodGet,
+			URL:    test.url,
+			Custom: test.custom,
+		}
+
+		err := st.validate(map[string]struct{}{})
+		if test.shouldErr && err == nil {
+			t.Errorf("%s: should be errored", test.name)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("%s: error occurred %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package util
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+const (
+	// DefaultHistogramPrecision is the number of significant digits kept by a histogram
+	// when no precision is given.
+	DefaultHistogramPrecision = 3
+	// MaxHistogramPrecision is the highest supported number of significant digits.
+	MaxHistogramPrecision = 5
+)
+
+// Histogram is a High Dynamic Range histogram of non-negative int64 values.
+// Values are kept in log-linear buckets so that the recorded value of any sample is within
+// the configured number of significant digits, while the memory usage only depends on the
+// magnitude of the largest recorded value, not on the number of samples.
+// Count, min, max and mean are kept exactly.
+type Histogram struct {
+	subBucketHalfCountMagnitude uint
+	subBucketHalfCount          int
+	subBucketMask               int64
+
+	counts []int64
+	total  int64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+// NewHistogram creates a histogram keeping the given number of significant digits.
+// Precisions out of [1, MaxHistogramPrecision] fall back to DefaultHistogramPrecision.
+func NewHistogram(precision int) *Histogram {
+	if precision < 1 || precision > MaxHistogramPrecision {
+		precision = DefaultHistogramPrecision
+	}
+
+	largestSingleUnitResolution := 2 * int64(math.Pow10(precision))
+	subBucketCountMagnitude := uint(bits.Len64(uint64(largestSingleUnitResolution - 1)))
+	subBucketCount := int64(1) << subBucketCountMagnitude
+
+	return &Histogram{
+		subBucketHalfCountMagnitude: subBucketCountMagnitude - 1,
+		subBucketHalfCount:          int(subBucketCount / 2),
+		subBucketMask:               subBucketCount - 1,
+	}
+}
+
+// Record adds the value to the histogram. Negative values are recorded as zero.
+func (h *Histogram) Record(v int64) {
+	if v < 0 {
+		v = 0
+	}
+
+	i := h.countsIndex(v)
+	if i >= len(h.counts) {
+		counts := make([]int64, i+1, i+1+h.subBucketHalfCount)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[i]++
+
+	if h.total == 0 || v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+	h.total++
+	h.sum += v
+}
+
+// Merge adds all the values recorded by the other histogram.
+func (h *Histogram) Merge(o *Histogram) {
+	if o == nil || o.total == 0 {
+		return
+	}
+	for i, c := range o.counts {
+		if c == 0 {
+			continue
+		}
+		v := o.valueFromIndex(i)
+		j := h.countsIndex(v)
+		if j >= len(h.counts) {
+			counts := make([]int64, j+1)
+			copy(counts, h.counts)
+			h.counts = counts
+		}
+		h.counts[j] += c
+	}
+
+	if h.total == 0 || o.min < h.min {
+		h.min = o.min
+	}
+	if o.max > h.max {
+		h.max = o.max
+	}
+	h.total += o.total
+	h.sum += o.sum
+}
+
+// Count returns the number of recorded values.
+func (h *Histogram) Count() int64 {
+	return h.total
+}
+
+// Min returns the smallest recorded value.
+func (h *Histogram) Min() int64 {
+	return h.min
+}
+
+// Max returns the largest recorded value.
+func (h *Histogram) Max() int64 {
+	return h.max
+}
+
+// Mean returns the average of the recorded values.
+func (h *Histogram) Mean() float64 {
+	if h.total == 0 {
+		return 0
+	}
+	return float64(h.sum) / float64(h.total)
+}
+
+// ValueAtPercentile returns the nearest-rank value at the given percentile, e.g. 99.9.
+func (h *Histogram) ValueAtPercentile(p float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	if p >= 100 {
+		return h.max
+	}
+
+	rank := int64(math.Ceil(float64(h.total) * p / 100))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			v := h.highestEquivalentValue(i)
+			if v > h.max {
+				v = h.max
+			}
+			if v < h.min {
+				v = h.min
+			}
+			return v
+		}
+	}
+	return h.max
+}
+
+func (h *Histogram) countsIndex(v int64) int {
+	pow2Ceiling := bits.Len64(uint64(v | h.subBucketMask))
+	bucketIdx := pow2Ceiling - int(h.subBucketHalfCountMagnitude) - 1
+	subBucketIdx := int(v >> uint(bucketIdx))
+	return (bucketIdx+1)<<h.subBucketHalfCountMagnitude + (subBucketIdx - h.subBucketHalfCount)
+}
+
+func (h *Histogram) bucketOfIndex(i int) (bucketIdx int, subBucketIdx int) {
+	bucketIdx = (i >> h.subBucketHalfCountMagnitude) - 1
+	subBucketIdx = (i & (h.subBucketHalfCount - 1)) + h.subBucketHalfCount
+	if bucketIdx < 0 {
+		subBucketIdx -= h.subBucketHalfCount
+		bucketIdx = 0
+	}
+	return
+}
+
+func (h *Histogram) valueFromIndex(i int) int64 {
+	bucketIdx, subBucketIdx := h.bucketOfIndex(i)
+	return int64(subBucketIdx) << uint(bucketIdx)
+}
+
+func (h *Histogram) highestEquivalentValue(i int) int64 {
+	bucketIdx, _ := h.bucketOfIndex(i)
+	return h.valueFromIndex(i) + int64(1)<<uint(bucketIdx) - 1
+}
+
+// HistogramSummary is a printable snapshot of the main statistics of a histogram.
+type HistogramSummary struct {
+	Count int64   `json:"count"`
+	Min   int64   `json:"min"`
+	Avg   float64 `json:"avg"`
+	P50   int64   `json:"p50"`
+	P90   int64   `json:"p90"`
+	P95   int64   `json:"p95"`
+	P99   int64   `json:"p99"`
+	P999  int64   `json:"p99.9"`
+	Max   int64   `json:"max"`
+}
+
+// Summary returns the main statistics of the histogram.
+func (h *Histogram) Summary() HistogramSummary {
+	return HistogramSummary{
+		Count: h.total,
+		Min:   h.min,
+		Avg:   h.Mean(),
+		P50:   h.ValueAtPercentile(50),
+		P90:   h.ValueAtPercentile(90),
+		P95:   h.ValueAtPercentile(95),
+		P99:   h.ValueAtPercentile(99),
+		P999:  h.ValueAtPercentile(99.9),
+		Max:   h.max,
+	}
+}
+
+func (s HistogramSummary) String() string {
+	return fmt.Sprintf("count=%d min=%d avg=%.2f p50=%d p90=%d p95=%d p99=%d p99.9=%d max=%d",
+		s.Count, s.Min, s.Avg, s.P50, s.P90, s.P95, s.P99, s.P999, s.Max)
+}
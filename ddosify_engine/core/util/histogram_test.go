@@ -0,0 +1,113 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package util
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestHistogramSmallValuesAreExact(t *testing.T) {
+	h := NewHistogram(3)
+	values := []int64{34, 37, 39, 44, 45, 55, 66, 67, 72, 75, 77, 89, 92, 98, 99}
+	for _, v := range values {
+		h.Record(v)
+	}
+
+	tests := map[float64]int64{0: 34, 50: 67, 80: 89, 90: 98, 95: 99, 99: 99, 100: 99}
+	for p, expected := range tests {
+		if v := h.ValueAtPercentile(p); v != expected {
+			t.Errorf("p%v expected %d, found %d", p, expected, v)
+		}
+	}
+	if h.Count() != 15 || h.Min() != 34 || h.Max() != 99 {
+		t.Errorf("Unexpected count, min, max: %d, %d, %d", h.Count(), h.Min(), h.Max())
+	}
+	if h.Mean() != 989.0/15 {
+		t.Errorf("Expected mean %v, found %v", 989.0/15, h.Mean())
+	}
+}
+
+func TestHistogramPrecision(t *testing.T) {
+	for _, precision := range []int{1, 2, 3, 4, 5} {
+		h := NewHistogram(precision)
+		r := rand.New(rand.NewSource(1))
+		values := make([]int64, 10000)
+		for i := range values {
+			values[i] = r.Int63n(int64(time.Minute / time.Microsecond))
+			h.Record(values[i])
+		}
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+		for _, p := range []float64{50, 90, 95, 99, 99.9} {
+			expected := values[int(math.Ceil(float64(len(values))*p/100))-1]
+			found := h.ValueAtPercentile(p)
+			if relErr := math.Abs(float64(found-expected)) / float64(expected); relErr > math.Pow10(-precision) {
+				t.Errorf("precision %d p%v expected %d, found %d", precision, p, expected, found)
+			}
+		}
+	}
+}
+
+func TestHistogramMemoryIsFlat(t *testing.T) {
+	h := NewHistogram(3)
+	for i := 0; i < 1000000; i++ {
+		h.Record(int64(i))
+	}
+	size := len(h.counts)
+	for i := 0; i < 10000000; i++ {
+		h.Record(int64(i % 1000000))
+	}
+	if len(h.counts) != size {
+		t.Errorf("Histogram should not grow with the number of values, %d -> %d", size, len(h.counts))
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	a, b := NewHistogram(3), NewHistogram(3)
+	for i := int64(1); i <= 50; i++ {
+		a.Record(i * 1000)
+		b.Record((i + 50) * 1000)
+	}
+	a.Merge(b)
+	a.Merge(NewHistogram(3))
+
+	if a.Count() != 100 || a.Min() != 1000 || a.Max() != 100000 {
+		t.Errorf("Unexpected count, min, max: %d, %d, %d", a.Count(), a.Min(), a.Max())
+	}
+	if p := a.ValueAtPercentile(50); math.Abs(float64(p-50000)) > 50 {
+		t.Errorf("p50 expected 50000, found %d", p)
+	}
+}
+
+func TestHistogramEmptyAndNegative(t *testing.T) {
+	h := NewHistogram(0)
+	if h.ValueAtPercentile(99) != 0 || h.Mean() != 0 {
+		t.Errorf("Empty histogram should return zero values")
+	}
+	h.Record(-5)
+	if h.Min() != 0 || h.Max() != 0 || h.Count() != 1 {
+		t.Errorf("Negative values should be recorded as zero")
+	}
+}
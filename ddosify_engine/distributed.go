@@ -0,0 +1,175 @@
+/*
+*
+*	Ddosify - Load testing tool for any web system.
+*   Copyright (C) 2021  Ddosify (https://ddosify.com)
+*
+*   This program is free software: you can redistribute it and/or modify
+*   it under the terms of the GNU Affero General Public License as published
+*   by the Free Software Foundation, either version 3 of the License, or
+*   (at your option) any later version.
+*
+*   This program is distributed in the hope that it will be useful,
+*   but WITHOUT ANY WARRANTY; without even the implied warranty of
+*   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+*   GNU Affero General Public License for more details.
+*
+*   You should have received a copy of the GNU Affero General Public License
+*   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*
+ */
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+
+	"go.ddosify.com/ddosify/config"
+	"go.ddosify.com/ddosify/core"
+	"go.ddosify.com/ddosify/core/distributed"
+	"go.ddosify.com/ddosify/core/types"
+)
+
+// Sub commands
+const (
+	cmdController = "controller"
+	cmdAgent      = "agent"
+	cmdCompare    = "compare"
+
+	defaultAgentAddr = ":8765"
+)
+
+// runSubCommand runs the distributed mode and the compare sub commands. Returns false if args is not a sub command.
+func runSubCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case cmdController:
+		fs := flag.NewFlagSet(cmdController, flag.ExitOnError)
+		cfg := fs.String("config", "", "Json config file path of the test")
+		agents := fs.String("agents", "", "Comma separated agent addresses. Ex: -agents '10.0.0.1:8765,10.0.0.2:8765'")
+		fs.Parse(args[1:])
+		startController(*cfg, *agents)
+	case cmdAgent:
+		fs := flag.NewFlagSet(cmdAgent, flag.ExitOnError)
+		addr := fs.String("listen", defaultAgentAddr, "Address to listen for the controller")
+		fs.Parse(args[1:])
+		startAgent(*addr)
+	case cmdCompare:
+		runCompare(args[1:])
+	default:
+		return false
+	}
+	return true
+}
+
+func startController(configFile string, agentsStr string) {
+	if configFile == "" {
+		exitWithMsg("Please provide the config file with -config flag")
+	}
+
+	agents := parseAgents(agentsStr)
+	if len(agents) == 0 {
+		exitWithMsg("Please provide the agent addresses with -agents flag")
+	}
+
+	cfg, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		exitWithMsg(err.Error())
+	}
+
+	c, err := config.NewConfigReader(cfg, config.ConfigTypeJson)
+	if err != nil {
+		exitWithMsg(err.Error())
+	}
+
+	h, err := c.CreateHammer()
+	if err != nil {
+		exitWithMsg(err.Error())
+	}
+
+	if err := h.Validate(); err != nil {
+		exitWithMsg(err.Error())
+	}
+
+	runController(h, cfg, agents)
+}
+
+var runController = func(h types.Hammer, cfg []byte, agents []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	es, err := core.InitEngineServices(h)
+	if err != nil {
+		exitWithMsg(err.Error())
+	}
+
+	controller, err := distributed.NewController(ctx, h, cfg, agents, es)
+	if err != nil {
+		exitWithMsg(err.Error())
+	}
+
+	if err = controller.Init(); err != nil {
+		exitWithMsg(err.Error())
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	defer func() {
+		signal.Stop(c)
+		cancel()
+	}()
+
+	go func() {
+		select {
+		case <-c:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if _, err = controller.Start(); err != nil {
+		exitWithMsg(err.Error())
+	}
+
+	if controller.IsTestFailed() {
+		os.Exit(1)
+	}
+}
+
+var startAgent = func(addr string) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		exitWithMsg(err.Error())
+	}
+
+	agent := distributed.NewAgent()
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		agent.Stop()
+	}()
+
+	fmt.Printf("Agent is listening on %s\n", lis.Addr())
+	if err := agent.Serve(lis); err != nil {
+		exitWithMsg(err.Error())
+	}
+}
+
+func parseAgents(agentsStr string) (agents []string) {
+	for _, a := range strings.Split(agentsStr, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			agents = append(agents, a)
+		}
+	}
+	return
+}
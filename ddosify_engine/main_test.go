@@ -49,6 +49,7 @@ func resetFlags() {
 	*iterCount = types.DefaultIterCount
 	*loadType = types.DefaultLoadType
 	*duration = types.DefaultDuration
+	*stages = ""
 
 	*method = types.DefaultMethod
 	*payload = ""
@@ -459,6 +460,47 @@ func TestParseHeaders(t *testing.T) {
 	}
 }
 
+func TestParseLoadStages(t *testing.T) {
+	valid := types.LoadStages{
+		{Duration: 60, LoadType: types.LoadTypeIncremental, Rate: 500},
+		{Duration: 300, LoadType: types.LoadTypeLinear, Rate: 500},
+	}
+
+	tests := []struct {
+		name      string
+		args      string
+		shouldErr bool
+		expected  types.LoadStages
+	}{
+		{"Empty", "", false, nil},
+		{"Valid", "incremental:60:500, Linear:300:500", false, valid},
+		{"MissingRate", "linear:60", true, nil},
+		{"InvalidDuration", "linear:x:500", true, nil},
+		{"InvalidRate", "linear:60:x", true, nil},
+	}
+
+	for _, test := range tests {
+		tf := func(t *testing.T) {
+			stages, err := parseLoadStages(test.args)
+
+			if test.shouldErr {
+				if err == nil {
+					t.Errorf("Should be errored")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Errored: %v", err)
+				}
+				if !reflect.DeepEqual(test.expected, stages) {
+					t.Errorf("Expected  %#v, Found %#v", test.expected, stages)
+				}
+			}
+		}
+
+		t.Run(test.name, tf)
+	}
+}
+
 func TestRun(t *testing.T) {
 	// Arrange
 	resetFlags()